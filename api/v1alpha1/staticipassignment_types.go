@@ -0,0 +1,77 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AssignmentPhase is the phase of a static public IP address assignment.
+type AssignmentPhase string
+
+const (
+	// AssignmentPhaseAssigned means the static public IP address is assigned to the node
+	AssignmentPhaseAssigned AssignmentPhase = "Assigned"
+	// AssignmentPhaseFailed means the last attempt to assign a static public IP address to the node failed
+	AssignmentPhaseFailed AssignmentPhase = "Failed"
+	// AssignmentPhaseReleased means the static public IP address was released from the node
+	AssignmentPhaseReleased AssignmentPhase = "Released"
+)
+
+// StaticIPAssignmentSpec identifies the node the assignment belongs to.
+type StaticIPAssignmentSpec struct {
+	// NodeName is the name of the Kubernetes node
+	NodeName string `json:"nodeName"`
+	// Instance is the cloud instance ID of the node
+	Instance string `json:"instance,omitempty"`
+	// Cloud is the cloud provider of the node
+	Cloud string `json:"cloud,omitempty"`
+	// Zone is the cloud zone of the node, used to release the address once the node is deleted
+	Zone string `json:"zone,omitempty"`
+}
+
+// StaticIPAssignmentStatus is the observed state of the static public IP address assignment.
+type StaticIPAssignmentStatus struct {
+	// Phase is the phase of the assignment
+	Phase AssignmentPhase `json:"phase,omitempty"`
+	// Address is the static public IP address assigned to the node
+	Address string `json:"address,omitempty"`
+	// ResourceID is the cloud allocation or resource ID of the address
+	ResourceID string `json:"resourceID,omitempty"`
+	// AssignedAt is the time the address was assigned
+	AssignedAt *metav1.Time `json:"assignedAt,omitempty"`
+	// RetryCount is the number of failed attempts before the last assignment or failure
+	RetryCount int32 `json:"retryCount,omitempty"`
+	// LastError is the error of the last failed attempt
+	LastError string `json:"lastError,omitempty"`
+	// LastUpdateTime is the time the assignment was last updated
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.status.address`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Retries",type=integer,JSONPath=`.status.retryCount`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// StaticIPAssignment records the static public IP address assigned to a node.
+type StaticIPAssignment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StaticIPAssignmentSpec   `json:"spec"`
+	Status StaticIPAssignmentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StaticIPAssignmentList contains a list of StaticIPAssignment.
+type StaticIPAssignmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StaticIPAssignment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StaticIPAssignment{}, &StaticIPAssignmentList{})
+}
@@ -0,0 +1,75 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GCPPoolSelector selects GCP static addresses.
+type GCPPoolSelector struct {
+	// Filter is the list of GCP address filters, e.g. labels.env=dev
+	Filter []string `json:"filter,omitempty"`
+}
+
+// AWSPoolSelector selects AWS Elastic IPs.
+type AWSPoolSelector struct {
+	// Filter is the list of AWS shorthand filters, e.g. Name=tag:env,Values=dev
+	Filter []string `json:"filter,omitempty"`
+}
+
+// AzurePoolSelector selects Azure public IP addresses.
+type AzurePoolSelector struct {
+	// Tags is the set of tags the public IP addresses must have
+	Tags map[string]string `json:"tags,omitempty"`
+	// PublicIPPrefix is the resource ID of the public IP prefix to allocate the public IP addresses from
+	PublicIPPrefix string `json:"publicIPPrefix,omitempty"`
+}
+
+// OCIPoolSelector selects OCI reserved public IPs.
+type OCIPoolSelector struct {
+	// FreeformTags is the set of freeform tags the public IPs must have
+	FreeformTags map[string]string `json:"freeformTags,omitempty"`
+}
+
+// StaticIPPoolSelector holds the cloud specific address selector; only the selector of the node cloud is used.
+type StaticIPPoolSelector struct {
+	GCP   *GCPPoolSelector   `json:"gcp,omitempty"`
+	AWS   *AWSPoolSelector   `json:"aws,omitempty"`
+	Azure *AzurePoolSelector `json:"azure,omitempty"`
+	OCI   *OCIPoolSelector   `json:"oci,omitempty"`
+}
+
+// StaticIPPoolSpec defines the addresses of the pool and the nodes that draw addresses from it.
+type StaticIPPoolSpec struct {
+	// NodeSelector selects the nodes that draw addresses from this pool; an empty selector matches all nodes
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// Selector selects the addresses of this pool
+	Selector StaticIPPoolSelector `json:"selector"`
+	// OrderBy is the order by for the addresses of this pool
+	OrderBy string `json:"orderBy,omitempty"`
+	// Priority resolves conflicts between pools matching the same node; the pool with the highest priority wins
+	Priority int32 `json:"priority,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// StaticIPPool is a declarative pool of static public IP addresses.
+type StaticIPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StaticIPPoolSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// StaticIPPoolList contains a list of StaticIPPool.
+type StaticIPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StaticIPPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StaticIPPool{}, &StaticIPPoolList{})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/doitintl/kubeip/internal/address"
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/controller"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/pool"
+	"github.com/doitintl/kubeip/internal/status"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/go-logr/logr/funcr"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+const (
+	controllerLeaderElectionID = "kubeip-controller"
+)
+
+func runController(ctx context.Context, log *logrus.Entry, cfg *config.Config) error {
+	if cfg.DevelopMode {
+		ctx = context.WithValue(ctx, developModeKey, true)
+	}
+	log.WithFields(logrus.Fields{
+		"develop-mode":  cfg.DevelopMode,
+		"node-selector": cfg.NodeSelector,
+	}).Infof("kubeip controller started")
+
+	// route controller-runtime logs to the logrus logger
+	ctrllog.SetLogger(funcr.New(func(prefix, args string) {
+		log.WithField("logger", prefix).Debug(args)
+	}, funcr.Options{}))
+
+	restconfig, err := retrieveKubeConfig(log, cfg)
+	if err != nil {
+		return errors.Wrap(err, "retrieving kube config")
+	}
+
+	clientset, err := kubernetes.NewForConfig(restconfig)
+	if err != nil {
+		return errors.Wrap(err, "initializing kubernetes client")
+	}
+
+	// the controller loads the configuration file once, only the agent applies its changes
+	if cfg, _, err = loadConfig(ctx, log, clientset, cfg); err != nil {
+		return errors.Wrap(err, "loading configuration file")
+	}
+
+	scheme, err := newScheme()
+	if err != nil {
+		return errors.Wrap(err, "initializing scheme")
+	}
+
+	// the manager serves the controller-runtime registry, which includes the kubeip metrics
+	metricsAddress := cfg.MetricsAddress
+	if metricsAddress == "" {
+		metricsAddress = "0"
+	}
+	healthProbeAddress := cfg.HealthProbeAddress
+	if healthProbeAddress == "" {
+		healthProbeAddress = "0"
+	}
+	mgr, err := ctrl.NewManager(restconfig, ctrl.Options{
+		Scheme:                  scheme,
+		LeaderElection:          cfg.LeaderElection,
+		LeaderElectionID:        controllerLeaderElectionID,
+		LeaderElectionNamespace: cfg.LeaseNamespace,
+		Metrics:                 metricsserver.Options{BindAddress: metricsAddress},
+		HealthProbeBindAddress:  healthProbeAddress,
+	})
+	if err != nil {
+		return errors.Wrap(err, "initializing controller manager")
+	}
+	if err = mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		return errors.Wrap(err, "adding health check")
+	}
+	// ready once the manager caches are synced
+	if err = mgr.AddReadyzCheck("informers", func(req *http.Request) error {
+		if !mgr.GetCache().WaitForCacheSync(req.Context()) {
+			return errors.New("informer caches are not synced")
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "adding readiness check")
+	}
+
+	newAssigner := func(ctx context.Context, cloudProvider types.CloudProvider) (address.Assigner, error) {
+		return address.NewAssigner(ctx, log, cloudProvider, cfg) //nolint:wrapcheck
+	}
+	var resolver pool.Resolver
+	if cfg.StaticIPPools {
+		resolver = pool.NewResolver(mgr.GetClient(), cfg.Filter, cfg.OrderBy)
+	}
+	recorder := status.NewNoopRecorder()
+	if cfg.RecordAssignments {
+		recorder = status.NewRecorder(mgr.GetClient())
+	}
+	eventRecorder := events.NewRecorder(mgr.GetEventRecorderFor(events.Component))
+	reconciler, err := controller.NewNodeReconciler(log, mgr.GetClient(), clientset, newAssigner, resolver, recorder, eventRecorder, cfg)
+	if err != nil {
+		return errors.Wrap(err, "initializing node reconciler")
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
+		return errors.Wrap(err, "setting up node reconciler")
+	}
+
+	// blocks until the context is done: SIGTERM, SIGINT
+	if err = mgr.Start(ctx); err != nil {
+		return errors.Wrap(err, "running controller manager")
+	}
+	log.Infof("shutting down kubeip controller")
+	return nil
+}
+
+func controllerCmd(c *cli.Context) error {
+	// setup signal handler for graceful shutdown: SIGTERM, SIGINT
+	ctx := signals.SetupSignalHandler()
+	log := prepareLogger(c.String("log-level"), c.Bool("json"))
+	cfg, err := config.NewConfig(c)
+	if err != nil {
+		log.WithError(err).Error("error loading kubeip controller configuration")
+		return err //nolint:wrapcheck
+	}
+
+	if err = runController(ctx, log, cfg); err != nil {
+		log.WithError(err).Error("error running kubeip controller")
+		return err
+	}
+
+	return nil
+}
@@ -3,21 +3,34 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"reflect"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/doitintl/kubeip/api/v1alpha1"
 	"github.com/doitintl/kubeip/internal/address"
 	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/health"
 	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
 	nd "github.com/doitintl/kubeip/internal/node"
+	"github.com/doitintl/kubeip/internal/pool"
+	"github.com/doitintl/kubeip/internal/status"
 	"github.com/doitintl/kubeip/internal/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 )
 
@@ -26,7 +39,6 @@ type contextKey string
 const (
 	developModeKey       contextKey = "develop-mode"
 	unassignTimeout                 = 5 * time.Minute
-	kubeipLockName                  = "kubeip-lock"
 	defaultLeaseDuration            = 5
 )
 
@@ -42,28 +54,18 @@ const (
 	// DefaultRetryInterval is the default retry interval
 	defaultRetryInterval = time.Minute
 	defaultRetryAttempts = 60
+	// DefaultConfigReloadInterval is the default interval to check the configuration file for changes
+	defaultConfigReloadInterval = time.Minute
+	// DefaultReconcileInterval is the default interval to check that the static public IP address is still assigned
+	defaultReconcileInterval = 5 * time.Minute
+	// the agent is reported stuck when its retry loops do not advance for this many retry intervals (and the minimum stall timeout)
+	stallRetryIntervals = 3
+	minStallTimeout     = 15 * time.Minute
 )
 
 func prepareLogger(level string, json bool) *logrus.Entry {
 	logger := logrus.New()
-
-	// set debug log level
-	switch level {
-	case "debug", "DEBUG":
-		logger.SetLevel(logrus.DebugLevel)
-	case "info", "INFO":
-		logger.SetLevel(logrus.InfoLevel)
-	case "warning", "WARNING":
-		logger.SetLevel(logrus.WarnLevel)
-	case "error", "ERROR":
-		logger.SetLevel(logrus.ErrorLevel)
-	case "fatal", "FATAL":
-		logger.SetLevel(logrus.FatalLevel)
-	case "panic", "PANIC":
-		logger.SetLevel(logrus.PanicLevel)
-	default:
-		logger.SetLevel(logrus.WarnLevel)
-	}
+	logger.SetLevel(parseLogLevel(level))
 
 	logger.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
@@ -82,7 +84,36 @@ func prepareLogger(level string, json bool) *logrus.Entry {
 	return log
 }
 
-func assignAddress(c context.Context, log *logrus.Entry, client kubernetes.Interface, assigner address.Assigner, node *types.Node, cfg *config.Config) (string, error) {
+// parseLogLevel returns the log level, warning if unknown
+func parseLogLevel(level string) logrus.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return logrus.DebugLevel
+	case "info":
+		return logrus.InfoLevel
+	case "warning":
+		return logrus.WarnLevel
+	case "error":
+		return logrus.ErrorLevel
+	case "fatal":
+		return logrus.FatalLevel
+	case "panic":
+		return logrus.PanicLevel
+	default:
+		return logrus.WarnLevel
+	}
+}
+
+// reporters publish the static public IP address assignment of the node
+type reporters struct {
+	status     status.Recorder
+	events     events.Recorder
+	labeler    nd.Labeler
+	conditions nd.ConditionReporter
+}
+
+//nolint:funlen
+func assignAddress(c context.Context, log *logrus.Entry, client kubernetes.Interface, assigner address.Assigner, rep *reporters, probe *health.Probe, node *types.Node, cfg *config.Config) (string, error) {
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -90,10 +121,11 @@ func assignAddress(c context.Context, log *logrus.Entry, client kubernetes.Inter
 	ticker := time.NewTicker(cfg.RetryInterval)
 	defer ticker.Stop()
 
-	// create new cluster wide lock
-	lock := lease.NewKubeLeaseLock(client, kubeipLockName, cfg.LeaseNamespace, node.Instance, cfg.LeaseDuration)
+	// create new lock in the configured scope: cluster wide, per filter or per candidate address
+	lock := lease.NewScopedLock(log, client, node.Instance, cfg.Filter, cfg.LockScope, cfg.LeaseNamespace, cfg.LeaseDuration)
 
 	for retryCounter := 0; retryCounter <= cfg.RetryAttempts; retryCounter++ {
+		probe.Progress()
 		log.WithFields(logrus.Fields{
 			"node":           node.Name,
 			"instance":       node.Instance,
@@ -105,18 +137,30 @@ func assignAddress(c context.Context, log *logrus.Entry, client kubernetes.Inter
 			if err := lock.Lock(ctx); err != nil {
 				return "", errors.Wrap(err, "failed to acquire lock")
 			}
-			log.Debug("lock acquired")
+			log.WithField("fencing-token", lock.Token()).Debug("lock acquired")
 			defer func() {
 				lock.Unlock(ctx) //nolint:errcheck
 				log.Debug("lock released")
 			}()
-			assignedAddress, err := assigner.Assign(ctx, node.Instance, node.Zone, cfg.Filter, cfg.OrderBy)
+			assignedAddress, err := assigner.Assign(lease.NewContext(events.NewContext(ctx, rep.events, node), lock), node.Instance, node.Zone, cfg.Filter, cfg.OrderBy)
 			if err != nil {
-				return "", err //nolint:wrapcheck
+				return assignedAddress, err //nolint:wrapcheck
 			}
 			return assignedAddress, nil
 		}(c)
+		if errors.Is(err, address.ErrStaticIPAlreadyAssigned) {
+			rep.events.Eventf(node, corev1.EventTypeNormal, events.ReasonAlreadyAssigned, "Static public IP address %s is already assigned", assignedAddress)
+		} else if err == nil {
+			rep.events.Eventf(node, corev1.EventTypeNormal, events.ReasonAssigned, "Assigned static public IP address %s", assignedAddress)
+		}
 		if err == nil || errors.Is(err, address.ErrStaticIPAlreadyAssigned) {
+			recordAssigned(ctx, log, assigner, rep, node, assignedAddress, retryCounter)
+			if len(cfg.TaintKeys) > 0 {
+				// the address is ready once reported by the node, see waitForAddressToBeReported
+				setStaticIPReady(ctx, log, rep, node, corev1.ConditionFalse, nd.ConditionReasonWaitingForReport, "Static public IP address %s is assigned, waiting for the node to report it", assignedAddress)
+			} else {
+				setStaticIPReady(ctx, log, rep, node, corev1.ConditionTrue, nd.ConditionReasonAssigned, "Static public IP address %s is assigned", assignedAddress)
+			}
 			return assignedAddress, nil
 		}
 
@@ -124,6 +168,17 @@ func assignAddress(c context.Context, log *logrus.Entry, client kubernetes.Inter
 			"node":     node.Name,
 			"instance": node.Instance,
 		}).Error("failed to assign static public IP address to node")
+		if errors.Is(err, address.ErrNoAvailableStaticIP) {
+			rep.events.Eventf(node, corev1.EventTypeWarning, events.ReasonPoolExhausted, "No static public IP address available: %v", err)
+			setStaticIPReady(ctx, log, rep, node, corev1.ConditionFalse, nd.ConditionReasonPoolExhausted, "No static public IP address available: %v", err)
+		} else {
+			rep.events.Eventf(node, corev1.EventTypeWarning, events.ReasonAssignFailed, "Failed to assign static public IP address: %v", err)
+			setStaticIPReady(ctx, log, rep, node, corev1.ConditionFalse, nd.ConditionReasonCloudError, "Failed to assign static public IP address: %v", err)
+		}
+		if recordErr := rep.status.Failed(ctx, node, retryCounter+1, err); recordErr != nil {
+			log.WithError(recordErr).Warn("failed to record static public IP address assignment failure")
+		}
+		metrics.Retries.WithLabelValues(metrics.OperationAssign).Inc()
 		log.Infof("retrying after %v", cfg.RetryInterval)
 
 		select {
@@ -137,7 +192,28 @@ func assignAddress(c context.Context, log *logrus.Entry, client kubernetes.Inter
 	return "", errors.New("reached maximum number of retries")
 }
 
-func waitForAddressToBeReported(c context.Context, log *logrus.Entry, explorer nd.Explorer, node *types.Node, assignedAddress string, cfg *config.Config) error {
+func recordAssigned(ctx context.Context, log *logrus.Entry, assigner address.Assigner, rep *reporters, node *types.Node, assignedAddress string, retryCount int) {
+	resourceID, err := address.LookupResourceID(ctx, assigner, assignedAddress)
+	if err != nil {
+		log.WithError(err).WithField("address", assignedAddress).Warn("failed to look up static public IP address resource ID")
+	}
+	if err = rep.status.Assigned(ctx, node, assignedAddress, resourceID, retryCount); err != nil {
+		log.WithError(err).Warn("failed to record static public IP address assignment")
+	}
+	if err = rep.labeler.SetAssigned(ctx, node, assignedAddress, resourceID); err != nil {
+		log.WithError(err).Warn("failed to label node with static public IP address")
+	}
+}
+
+// setStaticIPReady sets the StaticIPReady condition of the node with the formatted message; a failure is logged only,
+// not to hold the assignment or the release.
+func setStaticIPReady(ctx context.Context, log *logrus.Entry, rep *reporters, node *types.Node, status corev1.ConditionStatus, reason, messageFmt string, args ...interface{}) {
+	if err := rep.conditions.SetStaticIPReady(ctx, node, status, reason, fmt.Sprintf(messageFmt, args...)); err != nil {
+		log.WithError(err).WithField("reason", reason).Warn("failed to set node StaticIPReady condition")
+	}
+}
+
+func waitForAddressToBeReported(c context.Context, log *logrus.Entry, explorer nd.Explorer, rep *reporters, probe *health.Probe, node *types.Node, assignedAddress string, cfg *config.Config) error {
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -146,6 +222,7 @@ func waitForAddressToBeReported(c context.Context, log *logrus.Entry, explorer n
 	defer ticker.Stop()
 
 	for retryCounter := 0; retryCounter <= cfg.RetryAttempts; retryCounter++ {
+		probe.Progress()
 		log.WithFields(logrus.Fields{
 			"node":           node.Name,
 			"instance":       node.Instance,
@@ -156,17 +233,16 @@ func waitForAddressToBeReported(c context.Context, log *logrus.Entry, explorer n
 
 		nodeInfo, err := explorer.GetNode(ctx, node.Name)
 		if err == nil {
-			for _, ip := range nodeInfo.ExternalIPs {
-				if ip.String() == assignedAddress {
-					log.WithFields(logrus.Fields{
-						"node":           node.Name,
-						"instance":       node.Instance,
-						"address":        assignedAddress,
-						"retry-counter":  retryCounter,
-						"retry-attempts": cfg.RetryAttempts,
-					}).Info("Node is reporting assigned address")
-					return nil
-				}
+			if address.Reported(nodeInfo.ExternalIPs, assignedAddress) {
+				log.WithFields(logrus.Fields{
+					"node":           node.Name,
+					"instance":       node.Instance,
+					"address":        assignedAddress,
+					"retry-counter":  retryCounter,
+					"retry-attempts": cfg.RetryAttempts,
+				}).Info("Node is reporting assigned address")
+				setStaticIPReady(ctx, log, rep, node, corev1.ConditionTrue, nd.ConditionReasonAssigned, "Static public IP address %s is assigned and reported by the node", assignedAddress)
+				return nil
 			}
 			log.WithError(err).WithFields(logrus.Fields{
 				"node":     node.Name,
@@ -181,6 +257,7 @@ func waitForAddressToBeReported(c context.Context, log *logrus.Entry, explorer n
 			}).Error("failed to check if node is reporting the assigned address")
 		}
 
+		metrics.Retries.WithLabelValues(metrics.OperationWaitAddress).Inc()
 		log.Infof("retrying after %v", cfg.RetryInterval)
 
 		select {
@@ -195,6 +272,7 @@ func waitForAddressToBeReported(c context.Context, log *logrus.Entry, explorer n
 }
 
 func run(c context.Context, log *logrus.Entry, cfg *config.Config) error {
+	start := time.Now()
 	ctx, cancel := context.WithCancel(c)
 	defer cancel()
 
@@ -204,6 +282,23 @@ func run(c context.Context, log *logrus.Entry, cfg *config.Config) error {
 	}
 	log.WithField("develop-mode", cfg.DevelopMode).Infof("kubeip agent started")
 
+	if cfg.MetricsAddress != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.MetricsAddress); err != nil {
+				log.WithError(err).Error("metrics server failed")
+			}
+		}()
+	}
+
+	probe := health.NewProbe(max(stallRetryIntervals*cfg.RetryInterval, minStallTimeout))
+	if cfg.HealthProbeAddress != "" {
+		go func() {
+			if err := health.Serve(ctx, cfg.HealthProbeAddress, probe); err != nil {
+				log.WithError(err).Error("health probe server failed")
+			}
+		}()
+	}
+
 	restconfig, err := retrieveKubeConfig(log, cfg)
 	if err != nil {
 		return errors.Wrap(err, "retrieving kube config")
@@ -214,6 +309,11 @@ func run(c context.Context, log *logrus.Entry, cfg *config.Config) error {
 		return errors.Wrap(err, "initializing kubernetes client")
 	}
 
+	cfg, watcher, err := loadConfig(ctx, log, clientset, cfg)
+	if err != nil {
+		return errors.Wrap(err, "loading configuration file")
+	}
+
 	explorer := nd.NewExplorer(clientset)
 	n, err := explorer.GetNode(ctx, cfg.NodeName)
 	if err != nil {
@@ -221,64 +321,342 @@ func run(c context.Context, log *logrus.Entry, cfg *config.Config) error {
 	}
 	log.WithField("node", n).Debug("node discovery done")
 
+	// client for kubeip custom resources
+	var kubeipClient client.Client
+	if cfg.StaticIPPools || cfg.RecordAssignments {
+		scheme, err := newScheme()
+		if err != nil {
+			return errors.Wrap(err, "initializing scheme")
+		}
+		kubeipClient, err = client.New(restconfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return errors.Wrap(err, "initializing kubernetes client")
+		}
+	}
+
+	rep := &reporters{
+		status:     status.NewNoopRecorder(),
+		labeler:    nd.NewNoopLabeler(),
+		conditions: nd.NewNoopConditionReporter(),
+	}
+	if cfg.RecordAssignments {
+		rep.status = status.NewRecorder(kubeipClient)
+	}
+	if cfg.LabelNode {
+		rep.labeler = nd.NewLabeler(clientset)
+	}
+	if cfg.NodeCondition {
+		rep.conditions = nd.NewConditionReporter(clientset)
+	}
+
+	// keep the configuration of all nodes to apply the configuration file changes
+	baseCfg := cfg
+	if cfg, err = nodeConfig(ctx, log, kubeipClient, n, baseCfg); err != nil {
+		return err
+	}
+
+	// record the assignment lifecycle events on the node
+	eventRecorder, stopEvents := events.NewBroadcastRecorder(clientset, n.Name)
+	defer stopEvents()
+	rep.events = eventRecorder
+
 	// assign static public IP address with retry (interval and attempts)
 	assigner, err := address.NewAssigner(ctx, log, n.Cloud, cfg)
 	if err != nil {
 		return errors.Wrap(err, "initializing assigner")
 	}
 
-	assignedAddress, err := assignAddress(ctx, log, clientset, assigner, n, cfg)
+	// keep new pods off the node until the static public IP address is assigned
+	tainter := nd.NewTainter(clientset)
+	addTaints(ctx, log, tainter, rep, n, cfg)
+
+	assignedAddress, err := assignAddress(ctx, log, clientset, assigner, rep, probe, n, cfg)
 	if err != nil {
 		return errors.Wrap(err, "assigning static public IP address")
 	}
+	metrics.AssignmentDuration.WithLabelValues(string(n.Cloud)).Observe(time.Since(start).Seconds())
 
-	if cfg.TaintKey != "" {
-		if err := waitForAddressToBeReported(ctx, log, explorer, n, assignedAddress, cfg); err != nil {
-			return errors.Wrap(err, "waiting for node to report assigned address")
+	if len(cfg.TaintKeys) > 0 {
+		if err = removeTaint(ctx, log, explorer, tainter, assigner, rep, probe, n, assignedAddress, cfg); err != nil {
+			return err
 		}
+	}
 
-		logger := log.WithField("taint-key", cfg.TaintKey)
-		tainter := nd.NewTainter(clientset)
+	// the static public IP address is assigned (and reported by the node when a taint key is set)
+	probe.SetReady(true)
 
-		didRemoveTaint, err := tainter.RemoveTaintKey(ctx, n, cfg.TaintKey)
-		if err != nil {
-			logger.Error("removing taint key failed, releasing static public IP address")
-			if releaseErr := releaseIP(assigner, n); releaseErr != nil { //nolint:contextcheck
-				log.WithError(releaseErr).Error("releasing static public IP address after taint key removal failed")
+	// pause the agent to prevent it from exiting immediately after assigning the static public IP address
+	// apply the configuration file changes until the context is done: SIGTERM, SIGINT
+	var changes <-chan *config.Config
+	if watcher != nil && cfg.ConfigReloadInterval > 0 {
+		changes = watcher.Watch(ctx)
+	}
+	// check that the static public IP address is still assigned periodically and when the node addresses change
+	var reconcile <-chan time.Time
+	var nodeChanges <-chan []net.IP
+	if cfg.ReconcileInterval > 0 {
+		ticker := time.NewTicker(cfg.ReconcileInterval)
+		defer ticker.Stop()
+		reconcile = ticker.C
+		nodeChanges = nd.WatchExternalIPs(ctx, clientset, n.Name)
+	}
+	// a failed reconcile or reload stops the loop, and the static public IP address is released on exit as well
+	var runErr error
+	for runErr == nil && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-reconcile:
+			assignedAddress, runErr = reconcileAddress(ctx, log, clientset, explorer, tainter, assigner, rep, probe, n, assignedAddress, cfg)
+		case externalIPs := <-nodeChanges:
+			if address.Reported(externalIPs, assignedAddress) {
+				continue
+			}
+			log.WithFields(logrus.Fields{
+				"address":      assignedAddress,
+				"external-ips": externalIPs,
+			}).Info("node is no longer reporting the assigned address, checking static public IP address assignment")
+			assignedAddress, runErr = reconcileAddress(ctx, log, clientset, explorer, tainter, assigner, rep, probe, n, assignedAddress, cfg)
+		case baseCfg = <-changes:
+			if baseCfg == nil {
+				// the watcher stops when the context is done
+				continue
+			}
+			newCfg, err := nodeConfig(ctx, log, kubeipClient, n, baseCfg)
+			if err != nil {
+				log.WithError(err).Error("failed to apply reloaded configuration, keeping current configuration")
+				continue
+			}
+			if assignedAddress, runErr = reloadConfig(ctx, log, clientset, explorer, tainter, assigner, rep, probe, n, assignedAddress, cfg, newCfg); runErr == nil {
+				cfg = newCfg
 			}
-			return errors.Wrap(err, "removing node taint key")
-		}
-
-		if didRemoveTaint {
-			logger.Info("taint key removed successfully")
-		} else {
-			logger.Warning("taint key not present on node, skipped removal")
 		}
 	}
-
-	// pause the agent to prevent it from exiting immediately after assigning the static public IP address
-	// wait for the context to be done: SIGTERM, SIGINT
-	<-ctx.Done()
 	log.Infof("shutting down kubeip agent")
+	probe.SetReady(false)
 
 	// release the static public IP address on exit
 	if cfg.ReleaseOnExit {
+		// keep new pods off the node once the static public IP address is released
+		taintCtx, taintCancel := context.WithTimeout(context.Background(), unassignTimeout)
+		addTaints(taintCtx, log, tainter, rep, n, cfg) //nolint:contextcheck
+		taintCancel()
 		log.Infof("releasing static public IP address")
-		if releaseErr := releaseIP(assigner, n); releaseErr != nil { //nolint:contextcheck
-			return releaseErr
+		if releaseErr := releaseIP(log, assigner, rep, n); releaseErr != nil { //nolint:contextcheck
+			if runErr == nil {
+				return releaseErr
+			}
+			log.WithError(releaseErr).Error("failed to release static public IP address on exit")
+		} else {
+			log.Infof("static public IP address released")
 		}
-		log.Infof("static public IP address released")
+	}
+	return runErr
+}
+
+// removeTaint waits for the node to report the assigned static public IP address and removes the taint key from the
+// node; the address is released if the taint key removal fails.
+func removeTaint(ctx context.Context, log *logrus.Entry, explorer nd.Explorer, tainter nd.Tainter, assigner address.Assigner, rep *reporters, probe *health.Probe, n *types.Node, assignedAddress string, cfg *config.Config) error {
+	if err := waitForAddressToBeReported(ctx, log, explorer, rep, probe, n, assignedAddress, cfg); err != nil {
+		return errors.Wrap(err, "waiting for node to report assigned address")
+	}
+
+	logger := log.WithField("taint-key", cfg.TaintKeys)
+	didRemoveTaint, err := tainter.RemoveTaintKey(ctx, n, cfg.TaintKeys...)
+	if err != nil {
+		logger.Error("removing taint key failed, releasing static public IP address")
+		if releaseErr := releaseIP(log, assigner, rep, n); releaseErr != nil { //nolint:contextcheck
+			log.WithError(releaseErr).Error("releasing static public IP address after taint key removal failed")
+		}
+		return errors.Wrap(err, "removing node taint key")
+	}
+
+	if didRemoveTaint {
+		logger.Info("taint key removed successfully")
+		rep.events.Eventf(n, corev1.EventTypeNormal, events.ReasonTaintRemoved, "Removed taint key %s", strings.Join(cfg.TaintKeys, ", "))
+	} else {
+		logger.Warning("taint key not present on node, skipped removal")
 	}
 	return nil
 }
 
-func releaseIP(assigner address.Assigner, n *types.Node) error {
+// addTaints adds the taint keys missing on the node with the taint effect, so that new pods do not land on the node
+// while it has no static public IP address; a failure is logged only, not to hold the assignment or the release.
+func addTaints(ctx context.Context, log *logrus.Entry, tainter nd.Tainter, rep *reporters, n *types.Node, cfg *config.Config) {
+	if len(cfg.TaintKeys) == 0 {
+		return
+	}
+	taints := make([]corev1.Taint, 0, len(cfg.TaintKeys))
+	for _, key := range cfg.TaintKeys {
+		taints = append(taints, corev1.Taint{Key: key, Effect: cfg.TaintEffect})
+	}
+	logger := log.WithFields(logrus.Fields{
+		"taint-key":    cfg.TaintKeys,
+		"taint-effect": cfg.TaintEffect,
+	})
+	didAddTaint, err := tainter.AddTaint(ctx, n, taints...)
+	if err != nil {
+		logger.WithError(err).Error("failed to add taint key to node")
+		return
+	}
+	if didAddTaint {
+		logger.Info("taint key added")
+		rep.events.Eventf(n, corev1.EventTypeNormal, events.ReasonTaintAdded, "Added taint key %s", strings.Join(cfg.TaintKeys, ", "))
+	}
+}
+
+// reconcileAddress checks that the static public IP address is still assigned to the instance. If it was detached or
+// replaced, for example from the cloud console or by a maintenance event, the node is tainted again (when a taint key is
+// set) and a static public IP address is assigned again. Returns the assigned static public IP address.
+func reconcileAddress(ctx context.Context, log *logrus.Entry, client kubernetes.Interface, explorer nd.Explorer, tainter nd.Tainter, assigner address.Assigner, rep *reporters, probe *health.Probe, n *types.Node, assignedAddress string, cfg *config.Config) (string, error) {
+	logger := log.WithField("address", assignedAddress)
+	assigned, err := address.IsAssigned(ctx, assigner, n.Instance, n.Zone, assignedAddress)
+	if err != nil {
+		metrics.Reconciles.WithLabelValues(metrics.ResultError).Inc()
+		logger.WithError(err).Warn("failed to check static public IP address assignment, checking again on next reconcile")
+		return assignedAddress, nil
+	}
+	if assigned {
+		metrics.Reconciles.WithLabelValues(metrics.ResultInSync).Inc()
+		logger.Debug("static public IP address is still assigned")
+		return assignedAddress, nil
+	}
+
+	metrics.Reconciles.WithLabelValues(metrics.ResultDrifted).Inc()
+	logger.Warn("static public IP address is no longer assigned to the instance, reassigning")
+	rep.events.Eventf(n, corev1.EventTypeWarning, events.ReasonDrifted, "Static public IP address %s is no longer assigned, reassigning", assignedAddress)
+	setStaticIPReady(ctx, log, rep, n, corev1.ConditionFalse, nd.ConditionReasonDrifted, "Static public IP address %s is no longer assigned to the instance", assignedAddress)
+	probe.SetReady(false)
+
+	// keep new pods off the node until it reports a static public IP address again
+	addTaints(ctx, log, tainter, rep, n, cfg)
+
+	return reassignAddress(ctx, log, client, explorer, tainter, assigner, rep, probe, n, cfg)
+}
+
+// reassignAddress assigns a static public IP address again to the tainted node, after a drift or a release, waits for
+// the node to report it and removes the taint key (when a taint key is set). Returns the assigned static public IP address.
+func reassignAddress(ctx context.Context, log *logrus.Entry, client kubernetes.Interface, explorer nd.Explorer, tainter nd.Tainter, assigner address.Assigner, rep *reporters, probe *health.Probe, n *types.Node, cfg *config.Config) (string, error) {
+	newAddress, err := assignAddress(ctx, log, client, assigner, rep, probe, n, cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "reassigning static public IP address")
+	}
+	if len(cfg.TaintKeys) > 0 {
+		if err = removeTaint(ctx, log, explorer, tainter, assigner, rep, probe, n, newAddress, cfg); err != nil {
+			return "", err
+		}
+	}
+	probe.SetReady(true)
+	return newAddress, nil
+}
+
+// loadConfig loads the configuration file from its source, the config file or the config-map ConfigMap, and returns
+// the configuration with the settings of the file and the watcher of the source; the watcher is nil without source.
+// The config file is already loaded by config.NewConfig, so only the ConfigMap is read here.
+func loadConfig(ctx context.Context, log *logrus.Entry, client kubernetes.Interface, cfg *config.Config) (*config.Config, *config.Watcher, error) {
+	var watcher *config.Watcher
+	switch {
+	case cfg.ConfigFile != "":
+		watcher = config.NewWatcher(log, config.NewFileSource(cfg.ConfigFile), cfg)
+	case cfg.ConfigMap != "":
+		source, err := config.NewConfigMapSource(client, cfg.ConfigMap)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+		watcher = config.NewWatcher(log, source, cfg)
+		if cfg, err = watcher.Load(ctx); err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+	default:
+		return cfg, nil, nil
+	}
+	log.Logger.SetLevel(parseLogLevel(cfg.LogLevel))
+	return cfg, watcher, nil
+}
+
+// nodeConfig returns the configuration of the node: the cloud and node pool sections of the configuration file
+// and the filter and order by of the StaticIPPool matching the node
+func nodeConfig(ctx context.Context, log *logrus.Entry, kubeipClient client.Client, n *types.Node, cfg *config.Config) (*config.Config, error) {
+	cfg = cfg.ForNode(n)
+	if cfg.StaticIPPools {
+		selection, err := pool.NewResolver(kubeipClient, cfg.Filter, cfg.OrderBy).Resolve(ctx, n)
+		if err != nil {
+			return nil, errors.Wrap(err, "resolving static IP pool")
+		}
+		log.WithFields(logrus.Fields{
+			"pool":     selection.Pool,
+			"filter":   selection.Filter,
+			"order-by": selection.OrderBy,
+		}).Info("static IP pool resolved")
+		cfg.Filter = selection.Filter
+		cfg.OrderBy = selection.OrderBy
+	}
+	return cfg, nil
+}
+
+// reloadConfig applies the reloaded configuration: the log level at once, and the filter, order by and retry
+// settings to the next assignment. The assigned static public IP address is kept, unless the new filter excludes
+// it and the reassign policy is reassign; the node is then tainted until it reports the new address, as after a
+// drift. Returns the assigned static public IP address.
+func reloadConfig(ctx context.Context, log *logrus.Entry, client kubernetes.Interface, explorer nd.Explorer, tainter nd.Tainter, assigner address.Assigner, rep *reporters, probe *health.Probe, node *types.Node, assignedAddress string, oldCfg, newCfg *config.Config) (string, error) {
+	log.Logger.SetLevel(parseLogLevel(newCfg.LogLevel))
+	log.WithFields(logrus.Fields{
+		"filter":         newCfg.Filter,
+		"order-by":       newCfg.OrderBy,
+		"retry-interval": newCfg.RetryInterval,
+		"retry-attempts": newCfg.RetryAttempts,
+		"log-level":      newCfg.LogLevel,
+	}).Info("configuration reloaded")
+	if reflect.DeepEqual(oldCfg.Filter, newCfg.Filter) {
+		return assignedAddress, nil
+	}
+
+	match, err := address.MatchFilter(ctx, assigner, assignedAddress, newCfg.Filter)
+	if err != nil {
+		log.WithError(err).WithField("address", assignedAddress).Warn("failed to check static public IP address against new filter, keeping it")
+		return assignedAddress, nil
+	}
+	if match {
+		return assignedAddress, nil
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"address":         assignedAddress,
+		"reassign-policy": newCfg.ReassignPolicy,
+	})
+	if newCfg.ReassignPolicy != config.ReassignPolicyReassign {
+		logger.Warn("new filter excludes the assigned static public IP address, keeping it")
+		rep.events.Eventf(node, corev1.EventTypeWarning, events.ReasonAddressExcluded, "New filter excludes static public IP address %s, keeping it", assignedAddress)
+		return assignedAddress, nil
+	}
+	logger.Info("new filter excludes the assigned static public IP address, reassigning")
+	rep.events.Eventf(node, corev1.EventTypeNormal, events.ReasonAddressExcluded, "New filter excludes static public IP address %s, reassigning", assignedAddress)
+
+	probe.SetReady(false)
+	// keep new pods off the node until it reports the new static public IP address
+	addTaints(ctx, log, tainter, rep, node, newCfg)
+	if err = releaseIP(log, assigner, rep, node); err != nil { //nolint:contextcheck
+		return "", err
+	}
+	return reassignAddress(ctx, log, client, explorer, tainter, assigner, rep, probe, node, newCfg)
+}
+
+func releaseIP(log *logrus.Entry, assigner address.Assigner, rep *reporters, n *types.Node) error {
 	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), unassignTimeout)
 	defer releaseCancel()
 
 	if err := assigner.Unassign(releaseCtx, n.Instance, n.Zone); err != nil {
+		rep.events.Eventf(n, corev1.EventTypeWarning, events.ReasonReleaseFailed, "Failed to release static public IP address: %v", err)
 		return errors.Wrap(err, "failed to release static public IP address")
 	}
+	rep.events.Eventf(n, corev1.EventTypeNormal, events.ReasonReleased, "Released static public IP address")
+	setStaticIPReady(releaseCtx, log, rep, n, corev1.ConditionFalse, nd.ConditionReasonReleased, "Static public IP address released")
+
+	if err := rep.status.Released(releaseCtx, n); err != nil {
+		log.WithError(err).Warn("failed to record static public IP address release")
+	}
+	if err := rep.labeler.ClearAssigned(releaseCtx, n); err != nil {
+		log.WithError(err).Warn("failed to remove static public IP address label from node")
+	}
 
 	return nil
 }
@@ -287,9 +665,13 @@ func runCmd(c *cli.Context) error {
 	// setup signal handler for graceful shutdown: SIGTERM, SIGINT
 	ctx := signals.SetupSignalHandler()
 	log := prepareLogger(c.String("log-level"), c.Bool("json"))
-	cfg := config.NewConfig(c)
+	cfg, err := config.NewConfig(c)
+	if err != nil {
+		log.WithError(err).Error("error loading kubeip agent configuration")
+		return err //nolint:wrapcheck
+	}
 
-	if err := run(ctx, log, cfg); err != nil {
+	if err = run(ctx, log, cfg); err != nil {
 		log.WithError(err).Error("error running kubeip agent")
 		return err
 	}
@@ -300,118 +682,40 @@ func runCmd(c *cli.Context) error {
 //nolint:funlen
 func main() {
 	app := &cli.App{
-		// use ";" instead of "," for slice flag separator
-		// AWS filter values can contain "," and shorthand filter format uses "," to separate Names and Values
-		SliceFlagSeparator: ";",
+		SliceFlagSeparator: config.SliceFlagSeparator,
 		Commands: []*cli.Command{
 			{
 				Name:  "run",
 				Usage: "run agent",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:     "node-name",
 						Usage:    "Kubernetes node name (not needed if running in node)",
 						EnvVars:  []string{"NODE_NAME"},
 						Category: "Configuration",
 					},
+				}, commonFlags()...),
+				Action: runCmd,
+			},
+			{
+				Name:  "controller",
+				Usage: "run cluster wide controller",
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
-						Name:     "project",
-						Usage:    "name of the GCP project or the AWS account ID (not needed if running in node) or OCI compartment OCID (required for OCI)",
-						EnvVars:  []string{"PROJECT"},
-						Category: "Configuration",
-					},
-					&cli.StringFlag{
-						Name:     "region",
-						Usage:    "name of the GCP region or the AWS region or the OCI region (not needed if running in node)",
-						EnvVars:  []string{"REGION"},
-						Category: "Configuration",
-					},
-					&cli.BoolFlag{
-						Name:     "ipv6",
-						Usage:    "enable IPv6 support",
-						EnvVars:  []string{"IPV6"},
-						Category: "Configuration",
-					},
-					&cli.PathFlag{
-						Name:     "kubeconfig",
-						Usage:    "path to Kubernetes configuration file (not needed if running in node)",
-						EnvVars:  []string{"KUBECONFIG"},
-						Category: "Configuration",
-					},
-					&cli.DurationFlag{
-						Name:     "retry-interval",
-						Usage:    "when the agent fails to assign the static public IP address, it will retry after this interval",
-						Value:    defaultRetryInterval,
-						EnvVars:  []string{"RETRY_INTERVAL"},
-						Category: "Configuration",
-					},
-					&cli.StringSliceFlag{
-						Name:     "filter",
-						Usage:    "filter for the IP addresses",
-						EnvVars:  []string{"FILTER"},
-						Category: "Configuration",
-					},
-					&cli.StringFlag{
-						Name:     "order-by",
-						Usage:    "order by for the IP addresses",
-						EnvVars:  []string{"ORDER_BY"},
-						Category: "Configuration",
-					},
-					&cli.IntFlag{
-						Name:     "retry-attempts",
-						Usage:    "number of attempts to assign the static public IP address",
-						Value:    defaultRetryAttempts,
-						EnvVars:  []string{"RETRY_ATTEMPTS"},
-						Category: "Configuration",
-					},
-					&cli.IntFlag{
-						Name:     "lease-duration",
-						Usage:    "duration of the kubernetes lease",
-						Value:    defaultLeaseDuration,
-						EnvVars:  []string{"LEASE_DURATION"},
-						Category: "Configuration",
-					},
-					&cli.StringFlag{
-						Name:     "lease-namespace",
-						Usage:    "namespace of the kubernetes lease",
-						EnvVars:  []string{"LEASE_NAMESPACE"},
-						Value:    "default", // default namespace
+						Name:     "node-selector",
+						Usage:    "label selector of the nodes to assign static public IP addresses to (all nodes if empty)",
+						EnvVars:  []string{"NODE_SELECTOR"},
 						Category: "Configuration",
 					},
 					&cli.BoolFlag{
-						Name:     "release-on-exit",
-						Usage:    "release the static public IP address on exit",
-						EnvVars:  []string{"RELEASE_ON_EXIT"},
+						Name:     "leader-election",
+						Usage:    "enable leader election, using a lease in the lease namespace",
+						EnvVars:  []string{"LEADER_ELECTION"},
 						Category: "Configuration",
 						Value:    true,
 					},
-					&cli.StringFlag{
-						Name:     "taint-key",
-						Usage:    "specify a taint key to remove from the node once the static public IP address is assigned",
-						EnvVars:  []string{"TAINT_KEY"},
-						Category: "Configuration",
-					},
-					&cli.StringFlag{
-						Name:     "log-level",
-						Usage:    "set log level (debug, info(*), warning, error, fatal, panic)",
-						Value:    "info",
-						EnvVars:  []string{"LOG_LEVEL"},
-						Category: "Logging",
-					},
-					&cli.BoolFlag{
-						Name:     "json",
-						Usage:    "produce log in JSON format: Logstash and Splunk friendly",
-						EnvVars:  []string{"LOG_JSON"},
-						Category: "Logging",
-					},
-					&cli.BoolFlag{
-						Name:     "develop-mode",
-						Usage:    "enable develop mode",
-						EnvVars:  []string{"DEV_MODE"},
-						Category: "Development",
-					},
-				},
-				Action: runCmd,
+				}, commonFlags()...),
+				Action: controllerCmd,
 			},
 		},
 		Name:    "kubeip-agent",
@@ -432,6 +736,241 @@ func main() {
 	}
 }
 
+// commonFlags returns the flags shared by the agent and the controller
+//
+//nolint:funlen
+func commonFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.PathFlag{
+			Name:     "config",
+			Usage:    "path to a YAML or JSON configuration file with per-cloud and per-pool sections (flags and environment variables take precedence)",
+			EnvVars:  []string{"CONFIG"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "config-map",
+			Usage:    "namespace/name of a ConfigMap holding the configuration file in its config.yaml key (alternative to config)",
+			EnvVars:  []string{"CONFIG_MAP"},
+			Category: "Configuration",
+		},
+		&cli.DurationFlag{
+			Name:     "config-reload-interval",
+			Usage:    "interval to check the configuration file for changes and apply them without restarting the agent (0 disables reloading)",
+			Value:    defaultConfigReloadInterval,
+			EnvVars:  []string{"CONFIG_RELOAD_INTERVAL"},
+			Category: "Configuration",
+		},
+		&cli.DurationFlag{
+			Name:     "reconcile-interval",
+			Usage:    "interval to check that the static public IP address is still assigned and reassign it if it was detached (0 disables the check)",
+			Value:    defaultReconcileInterval,
+			EnvVars:  []string{"RECONCILE_INTERVAL"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "reassign-policy",
+			Usage:    "policy when a reloaded filter excludes the assigned static public IP address: keep or reassign",
+			Value:    string(config.ReassignPolicyKeep),
+			EnvVars:  []string{"REASSIGN_POLICY"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "project",
+			Usage:    "name of the GCP project or the AWS account ID or the Azure subscription ID (not needed if running in node) or OCI compartment OCID (required for OCI)",
+			EnvVars:  []string{"PROJECT"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "region",
+			Usage:    "name of the GCP region or the AWS region or the Azure location or the OCI region (not needed if running in node)",
+			EnvVars:  []string{"REGION"},
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "ipv6",
+			Usage:    "enable IPv6 support",
+			EnvVars:  []string{"IPV6"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "ip-family",
+			Usage:    "IP family of the static public IP addresses: ipv4, ipv6 or dual (defaults to ipv6 if IPv6 support is enabled, ipv4 otherwise)",
+			EnvVars:  []string{"IP_FAMILY"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "network-interface",
+			Usage:    "network interface receiving the static public IP address: index:<n>, name:<name>, subnet:<subnet> or tag:<key>[=<value>] (GCP, AWS and OCI; defaults to the primary network interface)",
+			EnvVars:  []string{"NETWORK_INTERFACE"},
+			Category: "Configuration",
+		},
+		&cli.IntFlag{
+			Name:     "addresses-per-node",
+			Usage:    "number of static public IP addresses assigned to each node: one per network interface on GCP and AWS, one per private IP of the VNIC on OCI",
+			Value:    1,
+			EnvVars:  []string{"ADDRESSES_PER_NODE"},
+			Category: "Configuration",
+		},
+		&cli.PathFlag{
+			Name:     "kubeconfig",
+			Usage:    "path to Kubernetes configuration file (not needed if running in node)",
+			EnvVars:  []string{"KUBECONFIG"},
+			Category: "Configuration",
+		},
+		&cli.DurationFlag{
+			Name:     "retry-interval",
+			Usage:    "when the agent fails to assign the static public IP address, it will retry after this interval",
+			Value:    defaultRetryInterval,
+			EnvVars:  []string{"RETRY_INTERVAL"},
+			Category: "Configuration",
+		},
+		&cli.StringSliceFlag{
+			Name:     "filter",
+			Usage:    "filter for the IP addresses",
+			EnvVars:  []string{"FILTER"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "order-by",
+			Usage:    "order by for the IP addresses",
+			EnvVars:  []string{"ORDER_BY"},
+			Category: "Configuration",
+		},
+		&cli.IntFlag{
+			Name:     "retry-attempts",
+			Usage:    "number of attempts to assign the static public IP address",
+			Value:    defaultRetryAttempts,
+			EnvVars:  []string{"RETRY_ATTEMPTS"},
+			Category: "Configuration",
+		},
+		&cli.IntFlag{
+			Name:     "lease-duration",
+			Usage:    "duration of the kubernetes lease",
+			Value:    defaultLeaseDuration,
+			EnvVars:  []string{"LEASE_DURATION"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "lease-namespace",
+			Usage:    "namespace of the kubernetes lease",
+			EnvVars:  []string{"LEASE_NAMESPACE"},
+			Value:    "default", // default namespace
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "lock-scope",
+			Usage:    "scope of the lease lock held while assigning an address: cluster (one lock for all nodes), pool (one lock per filter), address (one lock per candidate address) or cloud (claim each candidate address with cloud labels or tags)",
+			EnvVars:  []string{"LOCK_SCOPE"},
+			Value:    string(types.LockScopeCluster),
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "release-on-exit",
+			Usage:    "release the static public IP address on exit",
+			EnvVars:  []string{"RELEASE_ON_EXIT"},
+			Category: "Configuration",
+			Value:    true,
+		},
+		&cli.StringSliceFlag{
+			Name:     "taint-key",
+			Usage:    "specify a taint key to add to the node until the static public IP address is assigned and to remove once it is assigned (can be repeated)",
+			EnvVars:  []string{"TAINT_KEY"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "taint-effect",
+			Usage:    "effect of the taint keys added to the node: NoSchedule, PreferNoSchedule or NoExecute",
+			Value:    string(corev1.TaintEffectNoSchedule),
+			EnvVars:  []string{"TAINT_EFFECT"},
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "static-ip-pools",
+			Usage:    "use the filter and order by of the StaticIPPool resource matching the node (flags are used if no pool matches)",
+			EnvVars:  []string{"STATIC_IP_POOLS"},
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "record-assignments",
+			Usage:    "record the static public IP address assigned to each node in a StaticIPAssignment resource",
+			EnvVars:  []string{"RECORD_ASSIGNMENTS"},
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "label-node",
+			Usage:    "label the node with kubeip.doit.com/assigned=true and annotate it with the static public IP address (requires nodes patch permission)",
+			EnvVars:  []string{"LABEL_NODE"},
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "node-condition",
+			Usage:    "report the static public IP address assignment in the StaticIPReady condition of the node (requires nodes/status patch permission)",
+			EnvVars:  []string{"NODE_CONDITION"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "metrics-address",
+			Usage:    "address of the Prometheus metrics endpoint, for example :9090 (disabled if empty)",
+			EnvVars:  []string{"METRICS_ADDRESS"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "health-probe-address",
+			Usage:    "address of the /healthz and /readyz probes endpoint, for example :8081 (disabled if empty)",
+			EnvVars:  []string{"HEALTH_PROBE_ADDRESS"},
+			Category: "Configuration",
+		},
+		&cli.BoolFlag{
+			Name:     "azure-delete-prefix-ip",
+			Usage:    "delete public IPs allocated from an Azure public IP prefix once released",
+			EnvVars:  []string{"AZURE_DELETE_PREFIX_IP"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "aws-role-arn",
+			Usage:    "ARN of the IAM role assumed to list and associate the AWS elastic IPs, in another account for example",
+			EnvVars:  []string{"AWS_EIP_ROLE_ARN"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "aws-external-id",
+			Usage:    "external ID passed when assuming the AWS elastic IP role",
+			EnvVars:  []string{"AWS_EIP_EXTERNAL_ID"},
+			Category: "Configuration",
+		},
+		&cli.StringFlag{
+			Name:     "log-level",
+			Usage:    "set log level (debug, info(*), warning, error, fatal, panic)",
+			Value:    "info",
+			EnvVars:  []string{"LOG_LEVEL"},
+			Category: "Logging",
+		},
+		&cli.BoolFlag{
+			Name:     "json",
+			Usage:    "produce log in JSON format: Logstash and Splunk friendly",
+			EnvVars:  []string{"LOG_JSON"},
+			Category: "Logging",
+		},
+		&cli.BoolFlag{
+			Name:     "develop-mode",
+			Usage:    "enable develop mode",
+			EnvVars:  []string{"DEV_MODE"},
+			Category: "Development",
+		},
+	}
+}
+
+func newScheme() (*k8sruntime.Scheme, error) {
+	scheme := k8sruntime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "adding kubernetes types to scheme")
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "adding kubeip types to scheme")
+	}
+	return scheme, nil
+}
+
 func kubeConfigFromPath(kubepath string) (*rest.Config, error) {
 	if kubepath == "" {
 		return nil, errEmptyPath
@@ -3,20 +3,36 @@ package main
 import (
 	"context"
 	"net"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/doitintl/kubeip/internal/address"
 	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/health"
 	"github.com/doitintl/kubeip/internal/node"
+	"github.com/doitintl/kubeip/internal/status"
 	"github.com/doitintl/kubeip/internal/types"
 	mocks "github.com/doitintl/kubeip/mocks/address"
 	nodeMocks "github.com/doitintl/kubeip/mocks/node"
 	"github.com/pkg/errors"
 	tmock "github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
+func noopReporters() *reporters {
+	return &reporters{
+		status:     status.NewNoopRecorder(),
+		events:     events.NewNoopRecorder(),
+		labeler:    node.NewNoopLabeler(),
+		conditions: node.NewNoopConditionReporter(),
+	}
+}
+
 func Test_assignAddress(t *testing.T) {
 	type args struct {
 		c          context.Context
@@ -144,7 +160,11 @@ func Test_assignAddress(t *testing.T) {
 			name: "error after a few retries and context is done",
 			args: args{
 				c: func() context.Context {
-					ctx, _ := context.WithTimeout(context.Background(), 10*time.Millisecond) //nolint:govet
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+					go func() {
+						<-ctx.Done()
+						cancel()
+					}()
 					return ctx
 				}(),
 				assignerFn: func(t *testing.T) address.Assigner {
@@ -174,7 +194,7 @@ func Test_assignAddress(t *testing.T) {
 			log := prepareLogger("debug", false)
 			assigner := tt.args.assignerFn(t)
 			client := fake.NewSimpleClientset()
-			assignedAddress, err := assignAddress(tt.args.c, log, client, assigner, tt.args.node, tt.args.cfg)
+			assignedAddress, err := assignAddress(tt.args.c, log, client, assigner, noopReporters(), health.NewProbe(time.Minute), tt.args.node, tt.args.cfg)
 			if err != nil != tt.wantErr {
 				t.Errorf("assignAddress() error = %v, wantErr %v", err, tt.wantErr)
 			} else if assignedAddress != tt.address {
@@ -184,6 +204,375 @@ func Test_assignAddress(t *testing.T) {
 	}
 }
 
+func Test_assignAddress_events(t *testing.T) {
+	log := prepareLogger("debug", false)
+	node := &types.Node{
+		Name:     "test-node",
+		Instance: "test-instance",
+		Region:   "test-region",
+		Zone:     "test-zone",
+	}
+	cfg := &config.Config{
+		RetryAttempts: 3,
+		RetryInterval: time.Millisecond,
+		LeaseDuration: 1,
+	}
+	assigner := mocks.NewAssigner(t)
+	assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("", errors.Wrap(address.ErrNoAvailableStaticIP, "failed to list addresses")).Once()
+	assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("", errors.New("api error")).Once()
+	assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("1.1.1.1", nil).Once()
+
+	client := fake.NewSimpleClientset()
+	rep := noopReporters()
+	var stopEvents func()
+	rep.events, stopEvents = events.NewBroadcastRecorder(client, node.Name)
+	defer stopEvents()
+	if _, err := assignAddress(context.Background(), log, client, assigner, rep, health.NewProbe(time.Minute), node, cfg); err != nil {
+		t.Fatalf("assignAddress() error = %v", err)
+	}
+
+	want := []string{events.ReasonPoolExhausted, events.ReasonAssignFailed, events.ReasonAssigned}
+	var got []string
+	// events are sent to the API server asynchronously
+	for i := 0; i < 100; i++ {
+		list, err := client.CoreV1().Events(metav1.NamespaceDefault).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("failed to list events: %v", err)
+		}
+		got = got[:0]
+		for _, event := range list.Items {
+			if event.InvolvedObject.Kind != "Node" || event.InvolvedObject.Name != node.Name {
+				t.Errorf("event involved object = %v, want node %s", event.InvolvedObject, node.Name)
+			}
+			got = append(got, event.Reason)
+		}
+		if len(got) >= len(want) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("assignAddress() events = %v, want %v", got, want)
+	}
+}
+
+func Test_assignAddress_condition(t *testing.T) {
+	log := prepareLogger("debug", false)
+	n := &types.Node{
+		Name:     "test-node",
+		Instance: "test-instance",
+		Zone:     "test-zone",
+	}
+	cfg := &config.Config{
+		TaintKeys:     []string{"test-taint"},
+		RetryAttempts: 3,
+		RetryInterval: time.Millisecond,
+		LeaseDuration: 1,
+	}
+	assigner := mocks.NewAssigner(t)
+	assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("", errors.Wrap(address.ErrNoAvailableStaticIP, "failed to list addresses")).Once()
+	assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("", errors.New("api error")).Once()
+	assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("1.1.1.1", nil).Once()
+	assigner.EXPECT().Unassign(tmock.Anything, "test-instance", "test-zone").Return(nil).Once()
+	explorer := nodeMocks.NewExplorer(t)
+	explorer.EXPECT().GetNode(tmock.Anything, "test-node").Return(&types.Node{ExternalIPs: []net.IP{net.ParseIP("1.1.1.1")}}, nil).Once()
+
+	conditions := nodeMocks.NewConditionReporter(t)
+	var reasons []string
+	conditions.EXPECT().SetStaticIPReady(tmock.Anything, n, tmock.Anything, tmock.Anything, tmock.Anything).
+		Run(func(_ context.Context, _ *types.Node, status corev1.ConditionStatus, reason, _ string) {
+			reasons = append(reasons, string(status)+"/"+reason)
+		}).Return(nil)
+	rep := noopReporters()
+	rep.conditions = conditions
+
+	assignedAddress, err := assignAddress(context.Background(), log, fake.NewSimpleClientset(), assigner, rep, health.NewProbe(time.Minute), n, cfg)
+	if err != nil {
+		t.Fatalf("assignAddress() error = %v", err)
+	}
+	if err = waitForAddressToBeReported(context.Background(), log, explorer, rep, health.NewProbe(time.Minute), n, assignedAddress, cfg); err != nil {
+		t.Fatalf("waitForAddressToBeReported() error = %v", err)
+	}
+	if err = releaseIP(log, assigner, rep, n); err != nil {
+		t.Fatalf("releaseIP() error = %v", err)
+	}
+
+	want := []string{
+		"False/" + node.ConditionReasonPoolExhausted,
+		"False/" + node.ConditionReasonCloudError,
+		"False/" + node.ConditionReasonWaitingForReport,
+		"True/" + node.ConditionReasonAssigned,
+		"False/" + node.ConditionReasonReleased,
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("StaticIPReady conditions = %v, want %v", reasons, want)
+	}
+}
+
+// filterMatchingAssigner is an assigner checking the assigned address against the filter
+type filterMatchingAssigner struct {
+	*mocks.Assigner
+	*mocks.FilterMatcher
+}
+
+// assignmentCheckingAssigner is an assigner checking the address is still assigned to the instance
+type assignmentCheckingAssigner struct {
+	*mocks.Assigner
+	*mocks.AssignmentChecker
+}
+
+func Test_reconcileAddress(t *testing.T) {
+	n := &types.Node{
+		Name:     "test-node",
+		Instance: "test-instance",
+		Region:   "test-region",
+		Zone:     "test-zone",
+	}
+	tests := []struct {
+		name       string
+		taintKeys  []string
+		assignerFn func(t *testing.T) address.Assigner
+		explorerFn func(t *testing.T) node.Explorer
+		tainterFn  func(t *testing.T) node.Tainter
+		want       string
+		wantErr    bool
+	}{
+		{
+			name: "address still assigned",
+			assignerFn: func(t *testing.T) address.Assigner {
+				checker := mocks.NewAssignmentChecker(t)
+				checker.EXPECT().IsAssigned(tmock.Anything, "test-instance", "test-zone", "1.1.1.1").Return(true, nil).Once()
+				return &assignmentCheckingAssigner{mocks.NewAssigner(t), checker}
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name: "fail to check address keeps it",
+			assignerFn: func(t *testing.T) address.Assigner {
+				checker := mocks.NewAssignmentChecker(t)
+				checker.EXPECT().IsAssigned(tmock.Anything, "test-instance", "test-zone", "1.1.1.1").Return(false, errors.New("error")).Once()
+				return &assignmentCheckingAssigner{mocks.NewAssigner(t), checker}
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name: "address detached is reassigned",
+			assignerFn: func(t *testing.T) address.Assigner {
+				checker := mocks.NewAssignmentChecker(t)
+				checker.EXPECT().IsAssigned(tmock.Anything, "test-instance", "test-zone", "1.1.1.1").Return(false, nil).Once()
+				assigner := mocks.NewAssigner(t)
+				assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("2.2.2.2", nil).Once()
+				return &assignmentCheckingAssigner{assigner, checker}
+			},
+			want: "2.2.2.2",
+		},
+		{
+			name:      "address detached taints node until reassigned address is reported",
+			taintKeys: []string{"test-taint", "test-taint-2"},
+			assignerFn: func(t *testing.T) address.Assigner {
+				checker := mocks.NewAssignmentChecker(t)
+				checker.EXPECT().IsAssigned(tmock.Anything, "test-instance", "test-zone", "1.1.1.1").Return(false, nil).Once()
+				assigner := mocks.NewAssigner(t)
+				assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("2.2.2.2", nil).Once()
+				return &assignmentCheckingAssigner{assigner, checker}
+			},
+			explorerFn: func(t *testing.T) node.Explorer {
+				explorer := nodeMocks.NewExplorer(t)
+				explorer.EXPECT().GetNode(tmock.Anything, "test-node").Return(&types.Node{ExternalIPs: []net.IP{net.ParseIP("2.2.2.2")}}, nil).Once()
+				return explorer
+			},
+			tainterFn: func(t *testing.T) node.Tainter {
+				tainter := nodeMocks.NewTainter(t)
+				tainter.EXPECT().AddTaint(tmock.Anything, n,
+					corev1.Taint{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule},
+					corev1.Taint{Key: "test-taint-2", Effect: corev1.TaintEffectNoSchedule}).Return(true, nil).Once()
+				tainter.EXPECT().RemoveTaintKey(tmock.Anything, n, "test-taint", "test-taint-2").Return(true, nil).Once()
+				return tainter
+			},
+			want: "2.2.2.2",
+		},
+		{
+			name: "fail to reassign detached address",
+			assignerFn: func(t *testing.T) address.Assigner {
+				checker := mocks.NewAssignmentChecker(t)
+				checker.EXPECT().IsAssigned(tmock.Anything, "test-instance", "test-zone", "1.1.1.1").Return(false, nil).Once()
+				assigner := mocks.NewAssigner(t)
+				assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("", errors.New("error")).Once()
+				return &assignmentCheckingAssigner{assigner, checker}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := prepareLogger("debug", false)
+			cfg := &config.Config{
+				RetryAttempts: 0,
+				RetryInterval: time.Millisecond,
+				LeaseDuration: 1,
+				TaintKeys:     tt.taintKeys,
+				TaintEffect:   corev1.TaintEffectNoSchedule,
+			}
+			var explorer node.Explorer = nodeMocks.NewExplorer(t)
+			if tt.explorerFn != nil {
+				explorer = tt.explorerFn(t)
+			}
+			var tainter node.Tainter = nodeMocks.NewTainter(t)
+			if tt.tainterFn != nil {
+				tainter = tt.tainterFn(t)
+			}
+			client := fake.NewSimpleClientset()
+			got, err := reconcileAddress(context.Background(), log, client, explorer, tainter, tt.assignerFn(t), noopReporters(), health.NewProbe(time.Minute), n, "1.1.1.1", cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("reconcileAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_reloadConfig(t *testing.T) {
+	n := &types.Node{
+		Name:     "test-node",
+		Instance: "test-instance",
+		Region:   "test-region",
+		Zone:     "test-zone",
+	}
+	oldCfg := &config.Config{
+		Filter:         []string{"old-filter"},
+		RetryAttempts:  3,
+		RetryInterval:  time.Millisecond,
+		LeaseDuration:  1,
+		ReassignPolicy: config.ReassignPolicyKeep,
+		TaintEffect:    corev1.TaintEffectNoSchedule,
+	}
+	tests := []struct {
+		name       string
+		filter     []string
+		policy     config.ReassignPolicy
+		taintKeys  []string
+		assignerFn func(t *testing.T) address.Assigner
+		explorerFn func(t *testing.T) node.Explorer
+		tainterFn  func(t *testing.T) node.Tainter
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:   "filter unchanged",
+			filter: []string{"old-filter"},
+			policy: config.ReassignPolicyReassign,
+			assignerFn: func(t *testing.T) address.Assigner {
+				return &filterMatchingAssigner{mocks.NewAssigner(t), mocks.NewFilterMatcher(t)}
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name:   "new filter matches assigned address",
+			filter: []string{"new-filter"},
+			policy: config.ReassignPolicyReassign,
+			assignerFn: func(t *testing.T) address.Assigner {
+				matcher := mocks.NewFilterMatcher(t)
+				matcher.EXPECT().MatchFilter(tmock.Anything, "1.1.1.1", []string{"new-filter"}).Return(true, nil).Once()
+				return &filterMatchingAssigner{mocks.NewAssigner(t), matcher}
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name:   "new filter excludes assigned address with keep policy",
+			filter: []string{"new-filter"},
+			policy: config.ReassignPolicyKeep,
+			assignerFn: func(t *testing.T) address.Assigner {
+				matcher := mocks.NewFilterMatcher(t)
+				matcher.EXPECT().MatchFilter(tmock.Anything, "1.1.1.1", []string{"new-filter"}).Return(false, nil).Once()
+				return &filterMatchingAssigner{mocks.NewAssigner(t), matcher}
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name:   "new filter excludes assigned address with reassign policy",
+			filter: []string{"new-filter"},
+			policy: config.ReassignPolicyReassign,
+			assignerFn: func(t *testing.T) address.Assigner {
+				matcher := mocks.NewFilterMatcher(t)
+				matcher.EXPECT().MatchFilter(tmock.Anything, "1.1.1.1", []string{"new-filter"}).Return(false, nil).Once()
+				assigner := mocks.NewAssigner(t)
+				assigner.EXPECT().Unassign(tmock.Anything, "test-instance", "test-zone").Return(nil).Once()
+				assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string{"new-filter"}, "").Return("2.2.2.2", nil).Once()
+				return &filterMatchingAssigner{assigner, matcher}
+			},
+			want: "2.2.2.2",
+		},
+		{
+			name:      "reassigned address taints node until reported",
+			filter:    []string{"new-filter"},
+			policy:    config.ReassignPolicyReassign,
+			taintKeys: []string{"test-taint"},
+			assignerFn: func(t *testing.T) address.Assigner {
+				matcher := mocks.NewFilterMatcher(t)
+				matcher.EXPECT().MatchFilter(tmock.Anything, "1.1.1.1", []string{"new-filter"}).Return(false, nil).Once()
+				assigner := mocks.NewAssigner(t)
+				assigner.EXPECT().Unassign(tmock.Anything, "test-instance", "test-zone").Return(nil).Once()
+				assigner.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string{"new-filter"}, "").Return("2.2.2.2", nil).Once()
+				return &filterMatchingAssigner{assigner, matcher}
+			},
+			explorerFn: func(t *testing.T) node.Explorer {
+				explorer := nodeMocks.NewExplorer(t)
+				explorer.EXPECT().GetNode(tmock.Anything, "test-node").Return(&types.Node{ExternalIPs: []net.IP{net.ParseIP("2.2.2.2")}}, nil).Once()
+				return explorer
+			},
+			tainterFn: func(t *testing.T) node.Tainter {
+				tainter := nodeMocks.NewTainter(t)
+				tainter.EXPECT().AddTaint(tmock.Anything, n, corev1.Taint{Key: "test-taint", Effect: corev1.TaintEffectNoSchedule}).Return(true, nil).Once()
+				tainter.EXPECT().RemoveTaintKey(tmock.Anything, n, "test-taint").Return(true, nil).Once()
+				return tainter
+			},
+			want: "2.2.2.2",
+		},
+		{
+			name:   "fail to release excluded address",
+			filter: []string{"new-filter"},
+			policy: config.ReassignPolicyReassign,
+			assignerFn: func(t *testing.T) address.Assigner {
+				matcher := mocks.NewFilterMatcher(t)
+				matcher.EXPECT().MatchFilter(tmock.Anything, "1.1.1.1", []string{"new-filter"}).Return(false, nil).Once()
+				assigner := mocks.NewAssigner(t)
+				assigner.EXPECT().Unassign(tmock.Anything, "test-instance", "test-zone").Return(errors.New("error")).Once()
+				return &filterMatchingAssigner{assigner, matcher}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := prepareLogger("debug", false)
+			newCfg := *oldCfg
+			newCfg.Filter = tt.filter
+			newCfg.ReassignPolicy = tt.policy
+			newCfg.TaintKeys = tt.taintKeys
+			var explorer node.Explorer = nodeMocks.NewExplorer(t)
+			if tt.explorerFn != nil {
+				explorer = tt.explorerFn(t)
+			}
+			var tainter node.Tainter = nodeMocks.NewTainter(t)
+			if tt.tainterFn != nil {
+				tainter = tt.tainterFn(t)
+			}
+			client := fake.NewSimpleClientset()
+			got, err := reloadConfig(context.Background(), log, client, explorer, tainter, tt.assignerFn(t), noopReporters(), health.NewProbe(time.Minute), n, "1.1.1.1", oldCfg, &newCfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reloadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("reloadConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_waitForAddressToBeReported(t *testing.T) {
 	type args struct {
 		c          context.Context
@@ -344,7 +733,11 @@ func Test_waitForAddressToBeReported(t *testing.T) {
 			name: "error after a few retries and context is done",
 			args: args{
 				c: func() context.Context {
-					ctx, _ := context.WithTimeout(context.Background(), 10*time.Millisecond) //nolint:govet
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+					go func() {
+						<-ctx.Done()
+						cancel()
+					}()
 					return ctx
 				}(),
 				explorerFn: func(t *testing.T) node.Explorer {
@@ -373,7 +766,7 @@ func Test_waitForAddressToBeReported(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			log := prepareLogger("debug", false)
 			explorer := tt.args.explorerFn(t)
-			err := waitForAddressToBeReported(tt.args.c, log, explorer, tt.args.node, tt.args.address, tt.args.cfg)
+			err := waitForAddressToBeReported(tt.args.c, log, explorer, noopReporters(), health.NewProbe(time.Minute), tt.args.node, tt.args.address, tt.args.cfg)
 			if err != nil != tt.wantErr {
 				t.Errorf("waitForAddressToBeReported() error = %v, wantErr %v", err, tt.wantErr)
 			}
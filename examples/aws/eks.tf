@@ -165,12 +165,12 @@ resource "kubernetes_cluster_role" "kubeip_cluster_role" {
   rule {
     api_groups = ["*"]
     resources  = ["nodes"]
-    verbs      = ["get"]
+    verbs      = ["get", "list", "watch"]
   }
   rule {
     api_groups = ["coordination.k8s.io"]
     resources  = ["leases"]
-    verbs      = ["create", "delete", "get"]
+    verbs      = ["create", "delete", "get", "list", "update"]
   }
   depends_on = [
     kubernetes_service_account.kubeip_service_account,
@@ -13,20 +13,98 @@ var (
 	ErrUnknownCloudProvider    = errors.New("unknown cloud provider")
 	ErrStaticIPAlreadyAssigned = errors.New("static public IP already assigned")
 	ErrNoStaticIPAssigned      = errors.New("no static public IP assigned")
+	ErrStaticIPNotFound        = errors.New("static public IP not found")
+	ErrNoAvailableStaticIP     = errors.New("no available static public IP")
 )
 
+// noAvailableStaticIPError keeps the cloud specific message of an exhausted address pool and matches ErrNoAvailableStaticIP
+type noAvailableStaticIPError string
+
+func (e noAvailableStaticIPError) Error() string {
+	return string(e)
+}
+
+func (e noAvailableStaticIPError) Is(target error) bool {
+	return target == ErrNoAvailableStaticIP //nolint:errorlint,goerr113
+}
+
 type Assigner interface {
 	Assign(ctx context.Context, instanceID, zone string, filter []string, orderBy string) (string, error)
 	Unassign(ctx context.Context, instanceID, zone string) error
 }
 
+// ResourceIDGetter is implemented by assigners that can look up the cloud allocation or resource ID of a static public IP address.
+type ResourceIDGetter interface {
+	GetResourceID(ctx context.Context, address string) (string, error)
+}
+
+// LookupResourceID returns the resource ID of the address, or an empty string if the assigner does not support the lookup.
+func LookupResourceID(ctx context.Context, assigner Assigner, address string) (string, error) {
+	getter, ok := assigner.(ResourceIDGetter)
+	if !ok {
+		return "", nil
+	}
+	return getter.GetResourceID(ctx, address) //nolint:wrapcheck
+}
+
+// FilterMatcher is implemented by assigners that can check whether an assigned static public IP address matches a filter.
+type FilterMatcher interface {
+	MatchFilter(ctx context.Context, address string, filter []string) (bool, error)
+}
+
+// MatchFilter returns true if the address matches the filter, or if the assigner does not support the check.
+func MatchFilter(ctx context.Context, assigner Assigner, address string, filter []string) (bool, error) {
+	matcher, ok := assigner.(FilterMatcher)
+	if !ok {
+		return true, nil
+	}
+	return matcher.MatchFilter(ctx, address, filter) //nolint:wrapcheck
+}
+
+// AssignmentChecker is implemented by assigners that can check whether a static public IP address is still assigned to an instance.
+type AssignmentChecker interface {
+	IsAssigned(ctx context.Context, instanceID, zone, address string) (bool, error)
+}
+
+// IsAssigned returns true if the address is still assigned to the instance, or if the assigner does not support the check.
+func IsAssigned(ctx context.Context, assigner Assigner, instanceID, zone, address string) (bool, error) {
+	checker, ok := assigner.(AssignmentChecker)
+	if !ok {
+		return true, nil
+	}
+	return checker.IsAssigned(ctx, instanceID, zone, address) //nolint:wrapcheck
+}
+
+// NewAssigner creates the assigner of the cloud provider, instrumented with assign and unassign metrics.
 func NewAssigner(ctx context.Context, logger *logrus.Entry, provider types.CloudProvider, cfg *config.Config) (Assigner, error) {
+	assigner, err := newCloudAssigner(ctx, logger, provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newInstrumentedAssigner(assigner, provider), nil
+}
+
+func newCloudAssigner(ctx context.Context, logger *logrus.Entry, provider types.CloudProvider, cfg *config.Config) (Assigner, error) {
+	switch cfg.IPFamily {
+	case types.IPFamilyDual:
+		return newDualStackAssigner(ctx, logger, provider, cfg)
+	case "", types.IPFamilyIPv4, types.IPFamilyIPv6:
+		if cfg.AddressesPerNode > 1 {
+			return newMultiAddressAssigner(ctx, logger, provider, cfg)
+		}
+		return newSingleStackAssigner(ctx, logger, provider, cfg)
+	default:
+		return nil, errors.New("unknown IP family " + string(cfg.IPFamily))
+	}
+}
+
+func newSingleStackAssigner(ctx context.Context, logger *logrus.Entry, provider types.CloudProvider, cfg *config.Config) (Assigner, error) {
 	if provider == types.CloudProviderAWS {
-		return NewAwsAssigner(ctx, logger, cfg.Region)
+		return NewAwsAssigner(ctx, logger, cfg)
 	} else if provider == types.CloudProviderAzure {
-		return &azureAssigner{}, nil
+		return NewAzureAssigner(ctx, logger, cfg)
 	} else if provider == types.CloudProviderGCP {
-		return NewGCPAssigner(ctx, logger, cfg.Project, cfg.Region, cfg.IPv6)
+		return NewGCPAssigner(ctx, logger, cfg)
 	} else if provider == types.CloudProviderOCI {
 		return NewOCIAssigner(ctx, logger, cfg)
 	}
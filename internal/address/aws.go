@@ -4,17 +4,29 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/doitintl/kubeip/internal/cloud"
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
+	kubeiptypes "github.com/doitintl/kubeip/internal/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
 	shorthandFilterTokens = 2
+	// eipRoleSessionName is the session name of the role assumed to manage the elastic IPs
+	eipRoleSessionName = "kubeip"
 )
 
 type awsAssigner struct {
@@ -23,36 +35,88 @@ type awsAssigner struct {
 	instanceGetter cloud.Ec2InstanceGetter
 	eipLister      cloud.EipLister
 	eipAssigner    cloud.EipAssigner
+	eipTagger      cloud.EipTagger
+	// networkInterfaceLister lists the network interfaces of the instance with their tags
+	networkInterfaceLister cloud.Ec2NetworkInterfaceLister
+	// networkInterface selects the network interface of the instance receiving the elastic IP
+	networkInterface kubeiptypes.NetworkInterfaceSelector
+	// cloudClaims claims each elastic IP with its tags before associating it, instead of relying on a lease lock
+	cloudClaims bool
 }
 
-func NewAwsAssigner(ctx context.Context, logger *logrus.Entry, region string) (Assigner, error) {
+// NewAwsAssigner creates the AWS assigner; the elastic IPs are listed and associated with the aws-role-arn role when set,
+// while the instances and network interfaces are looked up with the local identity.
+func NewAwsAssigner(ctx context.Context, logger *logrus.Entry, cfg *config.Config) (Assigner, error) {
 	// initialize AWS client
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.Region))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load AWS config")
 	}
 
 	// create AWS client for EC2 service in the given region with default config and credentials
-	client := ec2.NewFromConfig(cfg)
+	client := ec2.NewFromConfig(awsCfg)
+
+	if cfg.IPv6 {
+		// the IPv6 assigner does not claim the addresses with tags
+		if cfg.LockScope == kubeiptypes.LockScopeCloud {
+			return nil, errors.New("cloud lock scope is not supported for IPv6 addresses on AWS")
+		}
+		return &awsIPv6Assigner{
+			region:                 cfg.Region,
+			logger:                 logger,
+			instanceGetter:         cloud.NewEc2InstanceGetter(client),
+			ipv6Lister:             cloud.NewIpv6AddressLister(client),
+			ipv6Assigner:           cloud.NewIpv6AddressAssigner(client),
+			networkInterfaceLister: cloud.NewEc2NetworkInterfaceLister(client),
+			networkInterface:       cfg.NetworkInterface,
+		}, nil
+	}
+
+	// create AWS client for the elastic IPs, assuming the role if set
+	eipClient := newEipClient(awsCfg, client, cfg.AwsRoleARN, cfg.AwsExternalID)
+	if cfg.AwsRoleARN != "" {
+		logger.WithField("roleARN", cfg.AwsRoleARN).Info("assuming AWS role to manage elastic IPs")
+	}
 
 	// initialize AWS instance getter
 	instanceGetter := cloud.NewEc2InstanceGetter(client)
 
 	// initialize AWS elastic IP lister
-	eipLister := cloud.NewEipLister(client)
+	eipLister := cloud.NewEipLister(eipClient)
 
 	// initialize AWS elastic IP internalAssigner
-	eipAssigner := cloud.NewEipAssigner(client)
+	eipAssigner := cloud.NewEipAssigner(eipClient)
 
 	return &awsAssigner{
-		region:         region,
-		logger:         logger,
-		instanceGetter: instanceGetter,
-		eipLister:      eipLister,
-		eipAssigner:    eipAssigner,
+		region:                 cfg.Region,
+		logger:                 logger,
+		instanceGetter:         instanceGetter,
+		eipLister:              eipLister,
+		eipAssigner:            eipAssigner,
+		eipTagger:              cloud.NewEipTagger(eipClient),
+		cloudClaims:            cfg.LockScope == kubeiptypes.LockScopeCloud,
+		networkInterfaceLister: cloud.NewEc2NetworkInterfaceLister(client),
+		networkInterface:       cfg.NetworkInterface,
 	}, nil
 }
 
+// newEipClient returns the EC2 client managing the elastic IPs: the client of the local identity, or a client with the
+// credentials of the assumed role if roleARN is set. The credentials are cached and refreshed before they expire.
+func newEipClient(cfg aws.Config, client *ec2.Client, roleARN, externalID string) *ec2.Client {
+	if roleARN == "" {
+		return client
+	}
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = eipRoleSessionName
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	roleCfg := cfg.Copy()
+	roleCfg.Credentials = aws.NewCredentialsCache(provider)
+	return ec2.NewFromConfig(roleCfg)
+}
+
 // parseShorthandFilter parses shorthand filter string into filter name and values
 // shorthand filter format: Name=string,Values=string,string ...
 // https://awscli.amazonaws.com/v2/documentation/api/latest/reference/ec2/describe-addresses.html#options
@@ -166,9 +230,24 @@ func (a *awsAssigner) forceCheckAddressAssigned(ctx context.Context, allocationI
 }
 
 func (a *awsAssigner) Assign(ctx context.Context, instanceID, _ string, filter []string, orderBy string) (string, error) {
-	// get elastic IP attached to the instance
-	err := a.checkElasticIPAssigned(ctx, instanceID)
+	// get EC2 instance
+	instance, err := a.instanceGetter.Get(ctx, instanceID, a.region)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get instance %s", instanceID)
+	}
+	// get the selected network interface ID, by default the primary network interface (DeviceIndex == 0)
+	networkInterfaceID, err := a.getNetworkInterfaceID(ctx, instance)
 	if err != nil {
+		return "", errors.Wrapf(err, "failed to get network interface ID for instance %s", instanceID)
+	}
+
+	// get elastic IP attached to the network interface
+	assigned, err := a.getNetworkInterfaceElasticIP(ctx, networkInterfaceID)
+	if err == nil {
+		a.logger.WithField("address", aws.ToString(assigned.PublicIp)).Infof("elastic IP already assigned on instance %s", instanceID)
+		return aws.ToString(assigned.PublicIp), ErrStaticIPAlreadyAssigned
+	}
+	if !errors.Is(err, ErrNoStaticIPAssigned) {
 		return "", errors.Wrapf(err, "check if elastic IP is already assigned to instance %s", instanceID)
 	}
 
@@ -178,17 +257,6 @@ func (a *awsAssigner) Assign(ctx context.Context, instanceID, _ string, filter [
 		return "", errors.Wrap(err, "failed to get available elastic IPs")
 	}
 
-	// get EC2 instance
-	instance, err := a.instanceGetter.Get(ctx, instanceID, a.region)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to get instance %s", instanceID)
-	}
-	// get primary network interface ID with public IP address (DeviceIndex == 0)
-	networkInterfaceID, err := a.getNetworkInterfaceID(instance)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to get network interface ID for instance %s", instanceID)
-	}
-
 	// try to assign available addresses until succeeds
 	// due to concurrency, it is possible that another kubeip instance will assign the same address
 	var assignedAddress string
@@ -199,7 +267,25 @@ func (a *awsAssigner) Assign(ctx context.Context, instanceID, _ string, filter [
 			"allocation_id":      *addresses[i].AllocationId,
 			"networkInterfaceID": networkInterfaceID,
 		}).Debug("assigning elastic IP to the instance")
-		err = a.tryAssignAddress(ctx, &addresses[i], networkInterfaceID, instanceID)
+		// claim the address when the lock scope is address; skip the addresses claimed by other nodes
+		claimCtx, release, claimErr := lease.ClaimAddress(ctx, *addresses[i].PublicIp)
+		if claimErr != nil {
+			err = claimErr
+			a.logger.WithError(err).Debug("skipping elastic IP address")
+			continue
+		}
+		events.Eventf(claimCtx, corev1.EventTypeNormal, events.ReasonAddressSelected, "Selected elastic IP %s", *addresses[i].PublicIp)
+		// check the lock is still held before associating the elastic IP
+		if err = lease.CheckHeld(claimCtx); err != nil {
+			release()
+			return "", errors.Wrap(err, "failed to check lock before assigning elastic IP")
+		}
+		if a.cloudClaims {
+			err = a.tryClaimAndAssignAddress(claimCtx, &addresses[i], networkInterfaceID, instanceID)
+		} else {
+			err = a.tryAssignAddress(claimCtx, &addresses[i], networkInterfaceID, instanceID)
+		}
+		release()
 		if err != nil {
 			a.logger.WithError(err).Warn("failed to assign elastic IP address")
 			a.logger.Debug("retrying with another address")
@@ -234,46 +320,144 @@ func (a *awsAssigner) tryAssignAddress(ctx context.Context, address *types.Addre
 	return nil
 }
 
-func (a *awsAssigner) getNetworkInterfaceID(instance *types.Instance) (string, error) {
-	// get network interface ID
-	if len(instance.NetworkInterfaces) == 0 {
-		return "", errors.Errorf("no network interfaces found for instance %s", *instance.InstanceId)
+// tryClaimAndAssignAddress claims the elastic IP with its tags before associating it. Tags are last writer wins, so the
+// claim is read back before the association, which fails if the elastic IP was associated concurrently; the association
+// is verified and the claim removed once the elastic IP is associated or failed to associate.
+func (a *awsAssigner) tryClaimAndAssignAddress(ctx context.Context, address *types.Address, networkInterfaceID, instanceID string) error {
+	allocationID := *address.AllocationId
+	latest, available, err := a.getElasticIP(ctx, allocationID, false)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return errors.Errorf("address %s is already assigned", *address.PublicIp)
+	}
+	now := time.Now()
+	if tags := tagMap(latest.Tags); !claimable(tags, instanceID, now) {
+		return errors.Wrapf(lease.ErrAddressClaimed, "address %s is claimed by %s", *address.PublicIp, tags[claimKey])
 	}
-	// get primary network interface ID with public IP address (DeviceIndex == 0)
-	networkInterfaceID := ""
-	for _, ni := range instance.NetworkInterfaces {
-		if ni.Association != nil && ni.Association.PublicIp != nil &&
-			ni.Attachment != nil && ni.Attachment.DeviceIndex != nil && *ni.Attachment.DeviceIndex == 0 {
-			networkInterfaceID = *ni.NetworkInterfaceId
-			break
+	claim := claimTags(instanceID, now)
+	if err = a.eipTagger.Tag(ctx, allocationID, claim); err != nil {
+		return errors.Wrapf(err, "failed to claim address %s", *address.PublicIp)
+	}
+	defer func() {
+		// only the tags with the values of this claim are deleted; a claim left behind goes stale after claimTTL
+		if untagErr := a.eipTagger.Untag(ctx, allocationID, claim); untagErr != nil {
+			a.logger.WithError(untagErr).WithField("address", *address.PublicIp).Warn("failed to release address claim")
 		}
+	}()
+
+	latest, available, err = a.getElasticIP(ctx, allocationID, false)
+	if err != nil {
+		return err
 	}
-	if networkInterfaceID == "" {
-		return "", errors.Errorf("no network interfaces with public IP address found for instance %s", *instance.InstanceId)
+	if !available {
+		return errors.Errorf("address %s is already assigned", *address.PublicIp)
+	}
+	if !claimedBy(tagMap(latest.Tags), instanceID) {
+		return errors.Wrapf(lease.ErrAddressClaimed, "address %s was claimed concurrently", *address.PublicIp)
+	}
+	// the association does not allow reassociation: it fails if the elastic IP was associated concurrently
+	if err = a.eipAssigner.Assign(ctx, networkInterfaceID, allocationID); err != nil {
+		return errors.Wrapf(err, "failed to assign elastic IP %s to the instance %s", *address.PublicIp, instanceID)
+	}
+
+	// verify the elastic IP is associated with the network interface of the instance
+	latest, associated, err := a.getElasticIP(ctx, allocationID, true)
+	if err != nil {
+		return err
 	}
-	return networkInterfaceID, nil
+	if !associated || latest.NetworkInterfaceId == nil || *latest.NetworkInterfaceId != networkInterfaceID {
+		return errors.Errorf("claimed address %s is not assigned to the instance %s", *address.PublicIp, instanceID)
+	}
+	return nil
 }
 
-func (a *awsAssigner) checkElasticIPAssigned(ctx context.Context, instanceID string) error {
+// getElasticIP returns the elastic IP of the allocation and true if it is associated (inUse) or not
+func (a *awsAssigner) getElasticIP(ctx context.Context, allocationID string, inUse bool) (*types.Address, bool, error) {
 	filters := make(map[string][]string)
-	filters["instance-id"] = []string{instanceID}
-	addresses, err := a.eipLister.List(ctx, filters, true)
+	filters["allocation-id"] = []string{allocationID}
+	addresses, err := a.eipLister.List(ctx, filters, inUse)
 	if err != nil {
-		return errors.Wrapf(err, "failed to list elastic IPs attached to instance %s", instanceID)
+		return nil, false, errors.Wrapf(err, "failed to list elastic IPs by allocation-id %s", allocationID)
 	}
-	if len(addresses) > 0 {
-		return ErrStaticIPAlreadyAssigned
+	if len(addresses) == 0 {
+		return nil, false, nil
 	}
-	return nil
+	return &addresses[0], true, nil
 }
 
+func tagMap(tags []types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag.Key != nil && tag.Value != nil {
+			m[*tag.Key] = *tag.Value
+		}
+	}
+	return m
+}
+
+func (a *awsAssigner) getNetworkInterfaceID(ctx context.Context, instance *types.Instance) (string, error) {
+	if len(instance.NetworkInterfaces) == 0 {
+		return "", errors.Errorf("no network interfaces found for instance %s", *instance.InstanceId)
+	}
+	// the network interface may have no public IP address, the elastic IP is associated with its primary private IP address
+	ni, err := findNetworkInterface(ctx, a.networkInterfaceLister, a.networkInterface, instance)
+	if err != nil {
+		return "", err
+	}
+	return *ni.NetworkInterfaceId, nil
+}
+
+// findNetworkInterface returns the network interface of the instance matching the selector by device index, ENI ID,
+// subnet ID or tag; the primary selector matches the network interface with device index 0.
+func findNetworkInterface(ctx context.Context, lister cloud.Ec2NetworkInterfaceLister, selector kubeiptypes.NetworkInterfaceSelector, instance *types.Instance) (*types.InstanceNetworkInterface, error) {
+	// the tags of the network interfaces are not part of the instance description
+	tags := make(map[string]map[string]string)
+	if selector.Kind == kubeiptypes.NetworkInterfaceByTag {
+		networkInterfaces, err := lister.List(ctx, aws.ToString(instance.InstanceId))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list network interfaces")
+		}
+		for _, ni := range networkInterfaces {
+			tags[aws.ToString(ni.NetworkInterfaceId)] = tagMap(ni.TagSet)
+		}
+	}
+	for i, ni := range instance.NetworkInterfaces {
+		if ni.Attachment == nil || ni.Attachment.DeviceIndex == nil || ni.NetworkInterfaceId == nil {
+			continue
+		}
+		if selector.Match(int(*ni.Attachment.DeviceIndex), *ni.NetworkInterfaceId, aws.ToString(ni.SubnetId), tags[*ni.NetworkInterfaceId]) {
+			return &instance.NetworkInterfaces[i], nil
+		}
+	}
+	if selector.IsPrimary() {
+		return nil, errors.Errorf("no primary network interface found for instance %s", aws.ToString(instance.InstanceId))
+	}
+	return nil, errors.Errorf("no network interface matching %s found for instance %s", selector, aws.ToString(instance.InstanceId))
+}
+
+// getAssignedElasticIP returns the elastic IP associated with the selected network interface of the instance,
+// ErrNoStaticIPAssigned if none; the elastic IPs of the other network interfaces belong to other address slots.
 func (a *awsAssigner) getAssignedElasticIP(ctx context.Context, instanceID string) (*types.Address, error) {
-	// get elastic IP attached to the instance
+	instance, err := a.instanceGetter.Get(ctx, instanceID, a.region)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get instance %s", instanceID)
+	}
+	networkInterfaceID, err := a.getNetworkInterfaceID(ctx, instance)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get network interface ID for instance %s", instanceID)
+	}
+	return a.getNetworkInterfaceElasticIP(ctx, networkInterfaceID)
+}
+
+// getNetworkInterfaceElasticIP returns the elastic IP associated with the network interface, ErrNoStaticIPAssigned if none.
+func (a *awsAssigner) getNetworkInterfaceElasticIP(ctx context.Context, networkInterfaceID string) (*types.Address, error) {
 	filters := make(map[string][]string)
-	filters["instance-id"] = []string{instanceID}
+	filters["network-interface-id"] = []string{networkInterfaceID}
 	addresses, err := a.eipLister.List(ctx, filters, true)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to list elastic IPs attached to instance %s", instanceID)
+		return nil, errors.Wrapf(err, "failed to list elastic IPs attached to network interface %s", networkInterfaceID)
 	}
 	if len(addresses) == 0 {
 		return nil, ErrNoStaticIPAssigned
@@ -294,8 +478,9 @@ func (a *awsAssigner) getAvailableElasticIPs(ctx context.Context, filter []strin
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to list available elastic IPs")
 	}
+	metrics.FreeAddresses.WithLabelValues(string(kubeiptypes.CloudProviderAWS)).Set(float64(len(addresses)))
 	if len(addresses) == 0 {
-		return nil, errors.Errorf("no available elastic IPs")
+		return nil, noAvailableStaticIPError("no available elastic IPs")
 	}
 	// sort addresses by orderBy field
 	sortAddressesByField(addresses, orderBy)
@@ -328,3 +513,47 @@ func (a *awsAssigner) Unassign(ctx context.Context, instanceID, _ string) error
 
 	return nil
 }
+
+// MatchFilter returns true if the elastic IP matches the filter.
+func (a *awsAssigner) MatchFilter(ctx context.Context, address string, filter []string) (bool, error) {
+	filters := make(map[string][]string)
+	for _, f := range filter {
+		name, values, err := parseShorthandFilter(f)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to parse filter %s", f)
+		}
+		filters[name] = values
+	}
+	filters["public-ip"] = []string{address}
+	addresses, err := a.eipLister.List(ctx, filters, true)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list elastic IP %s", address)
+	}
+	return len(addresses) > 0, nil
+}
+
+// GetResourceID returns the allocation ID of the elastic IP.
+func (a *awsAssigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	filters := make(map[string][]string)
+	filters["public-ip"] = []string{address}
+	addresses, err := a.eipLister.List(ctx, filters, true)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list elastic IP %s", address)
+	}
+	if len(addresses) == 0 || addresses[0].AllocationId == nil {
+		return "", ErrStaticIPNotFound
+	}
+	return *addresses[0].AllocationId, nil
+}
+
+// IsAssigned returns true if the elastic IP is still associated with the instance.
+func (a *awsAssigner) IsAssigned(ctx context.Context, instanceID, _, address string) (bool, error) {
+	assigned, err := a.getAssignedElasticIP(ctx, instanceID)
+	if errors.Is(err, ErrNoStaticIPAssigned) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return assigned.PublicIp != nil && *assigned.PublicIp == address, nil
+}
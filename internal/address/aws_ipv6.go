@@ -0,0 +1,308 @@
+package address
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/doitintl/kubeip/internal/cloud"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
+	kubeiptypes "github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxIPv6Candidates is the maximum number of free addresses of an IPv6 CIDR reservation tried by the assigner
+const maxIPv6Candidates = 256
+
+// awsIPv6Assigner assigns a static IPv6 address to the network interface of the instance, from the explicit IPv6 CIDR
+// reservations of its subnet. EC2 never auto-assigns the addresses of an explicit reservation, so any address of a
+// reservation held by the network interface is a static IPv6 address.
+type awsIPv6Assigner struct {
+	region         string
+	logger         *logrus.Entry
+	instanceGetter cloud.Ec2InstanceGetter
+	ipv6Lister     cloud.Ipv6AddressLister
+	ipv6Assigner   cloud.Ipv6AddressAssigner
+	// networkInterfaceLister lists the network interfaces of the instance with their tags
+	networkInterfaceLister cloud.Ec2NetworkInterfaceLister
+	// networkInterface selects the network interface of the instance receiving the IPv6 address
+	networkInterface kubeiptypes.NetworkInterfaceSelector
+}
+
+// Assign assigns an available IPv6 address of the subnet CIDR reservations matching the filter, in the order of the
+// reservations; EC2 rejects an address already assigned to another network interface of the subnet.
+func (a *awsIPv6Assigner) Assign(ctx context.Context, instanceID, _ string, filter []string, orderBy string) (string, error) {
+	ni, err := a.getNetworkInterface(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+	networkInterfaceID := aws.ToString(ni.NetworkInterfaceId)
+	subnetID := aws.ToString(ni.SubnetId)
+
+	// get IPv6 address of any reservation assigned to the network interface
+	reservations, err := a.ipv6Lister.ListReservations(ctx, subnetID, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "check if IPv6 address is already assigned to instance %s", instanceID)
+	}
+	if address := reservedIPv6Address(instanceIPv6Addresses(ni), reservations); address != "" {
+		return address, ErrStaticIPAlreadyAssigned
+	}
+
+	// get available IPv6 addresses based on filter and orderBy
+	addresses, err := a.getAvailableIPv6Addresses(ctx, subnetID, filter, orderBy)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get available IPv6 addresses")
+	}
+
+	for _, address := range addresses {
+		a.logger.WithFields(logrus.Fields{
+			"instance":           instanceID,
+			"address":            address,
+			"networkInterfaceID": networkInterfaceID,
+		}).Debug("assigning IPv6 address to the instance")
+		// claim the address when the lock scope is address; skip the addresses claimed by other nodes
+		claimCtx, release, claimErr := lease.ClaimAddress(ctx, address)
+		if claimErr != nil {
+			err = claimErr
+			a.logger.WithError(err).Debug("skipping IPv6 address")
+			continue
+		}
+		events.Eventf(claimCtx, corev1.EventTypeNormal, events.ReasonAddressSelected, "Selected IPv6 address %s", address)
+		// check the lock is still held before assigning the IPv6 address
+		if err = lease.CheckHeld(claimCtx); err != nil {
+			release()
+			return "", errors.Wrap(err, "failed to check lock before assigning IPv6 address")
+		}
+		err = a.ipv6Assigner.Assign(claimCtx, networkInterfaceID, address)
+		release()
+		if err != nil {
+			a.logger.WithError(err).Warn("failed to assign IPv6 address")
+			a.logger.Debug("retrying with another address")
+			continue
+		}
+		a.logger.WithFields(logrus.Fields{
+			"instance": instanceID,
+			"address":  address,
+		}).Info("IPv6 address assigned to the instance")
+		return address, nil
+	}
+	return "", errors.Wrap(err, "failed to assign IPv6 address")
+}
+
+// getNetworkInterface returns the network interface of the instance receiving the IPv6 address
+func (a *awsIPv6Assigner) getNetworkInterface(ctx context.Context, instanceID string) (*types.InstanceNetworkInterface, error) {
+	instance, err := a.instanceGetter.Get(ctx, instanceID, a.region)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get instance %s", instanceID)
+	}
+	ni, err := findNetworkInterface(ctx, a.networkInterfaceLister, a.networkInterface, instance)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get network interface for instance %s", instanceID)
+	}
+	return ni, nil
+}
+
+// getAvailableIPv6Addresses returns the addresses of the reservations matching the filter that are not assigned to a
+// network interface of the subnet, at most maxIPv6Candidates per reservation
+func (a *awsIPv6Assigner) getAvailableIPv6Addresses(ctx context.Context, subnetID string, filter []string, orderBy string) ([]string, error) {
+	filters := make(map[string][]string)
+	for _, f := range filter {
+		name, values, err := parseShorthandFilter(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse filter %s", f)
+		}
+		filters[name] = values
+	}
+	reservations, err := a.ipv6Lister.ListReservations(ctx, subnetID, filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list IPv6 CIDR reservations")
+	}
+	sortReservationsByField(reservations, orderBy)
+
+	networkInterfaces, err := a.ipv6Lister.ListNetworkInterfaces(ctx, map[string][]string{"subnet-id": {subnetID}})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list IPv6 addresses in use")
+	}
+	inUse := make(map[netip.Addr]bool)
+	for _, ni := range networkInterfaces {
+		for _, address := range ni.Ipv6Addresses {
+			if ip, parseErr := netip.ParseAddr(aws.ToString(address.Ipv6Address)); parseErr == nil {
+				inUse[ip] = true
+			}
+		}
+	}
+
+	var addresses []string
+	for _, reservation := range reservations {
+		prefix, parseErr := netip.ParsePrefix(aws.ToString(reservation.Cidr))
+		if parseErr != nil || !prefix.Addr().Is6() {
+			continue
+		}
+		candidates := 0
+		for ip := prefix.Masked().Addr(); prefix.Contains(ip) && candidates < maxIPv6Candidates; ip = ip.Next() {
+			if !inUse[ip] {
+				addresses = append(addresses, ip.String())
+				candidates++
+			}
+		}
+	}
+	metrics.FreeAddresses.WithLabelValues(string(kubeiptypes.CloudProviderAWS)).Set(float64(len(addresses)))
+	if len(addresses) == 0 {
+		return nil, noAvailableStaticIPError("no available IPv6 addresses in the subnet CIDR reservations")
+	}
+	a.logger.WithField("reservations", len(reservations)).Debugf("Found %d available IPv6 addresses", len(addresses))
+	return addresses, nil
+}
+
+// sortReservationsByField sorts the reservations by the given field: SubnetCidrReservationId, Cidr or Tag:<key>
+func sortReservationsByField(reservations []types.SubnetCidrReservation, sortBy string) {
+	var key func(reservation *types.SubnetCidrReservation) string
+	switch {
+	case strings.HasPrefix(sortBy, "Tag:"):
+		tag := strings.TrimPrefix(sortBy, "Tag:")
+		key = func(reservation *types.SubnetCidrReservation) string {
+			return tagMap(reservation.Tags)[tag]
+		}
+	case sortBy == "SubnetCidrReservationId":
+		key = func(reservation *types.SubnetCidrReservation) string {
+			return aws.ToString(reservation.SubnetCidrReservationId)
+		}
+	case sortBy == "Cidr":
+		key = func(reservation *types.SubnetCidrReservation) string {
+			return aws.ToString(reservation.Cidr)
+		}
+	default:
+		return
+	}
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return key(&reservations[i]) < key(&reservations[j])
+	})
+}
+
+// Unassign unassigns the IPv6 address of a reservation from the network interface of the instance.
+func (a *awsIPv6Assigner) Unassign(ctx context.Context, instanceID, _ string) error {
+	ni, err := a.getNetworkInterface(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	reservations, err := a.ipv6Lister.ListReservations(ctx, aws.ToString(ni.SubnetId), nil)
+	if err != nil {
+		return errors.Wrapf(err, "check if IPv6 address is assigned to instance %s", instanceID)
+	}
+	address := reservedIPv6Address(instanceIPv6Addresses(ni), reservations)
+	if address == "" {
+		return ErrNoStaticIPAssigned
+	}
+	if err = a.ipv6Assigner.Unassign(ctx, aws.ToString(ni.NetworkInterfaceId), address); err != nil {
+		return errors.Wrap(err, "failed to unassign IPv6 address")
+	}
+	a.logger.WithFields(logrus.Fields{
+		"instance": instanceID,
+		"address":  address,
+	}).Info("IPv6 address unassigned from the instance")
+	return nil
+}
+
+// MatchFilter returns true if the IPv6 address belongs to a reservation matching the filter.
+func (a *awsIPv6Assigner) MatchFilter(ctx context.Context, address string, filter []string) (bool, error) {
+	_, err := a.findReservation(ctx, address, filter)
+	if errors.Is(err, ErrStaticIPNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetResourceID returns the ID of the subnet CIDR reservation of the IPv6 address.
+func (a *awsIPv6Assigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	reservation, err := a.findReservation(ctx, address, nil)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(reservation.SubnetCidrReservationId), nil
+}
+
+// IsAssigned returns true if the IPv6 address is still assigned to the network interface of the instance.
+func (a *awsIPv6Assigner) IsAssigned(ctx context.Context, instanceID, _, address string) (bool, error) {
+	ni, err := a.getNetworkInterface(ctx, instanceID)
+	if err != nil {
+		return false, err
+	}
+	ip, err := netip.ParseAddr(address)
+	if err != nil {
+		return false, nil //nolint:nilerr
+	}
+	for _, assigned := range instanceIPv6Addresses(ni) {
+		if assigned == ip {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findReservation returns the reservation matching the filter that contains the assigned IPv6 address,
+// ErrStaticIPNotFound if none
+func (a *awsIPv6Assigner) findReservation(ctx context.Context, address string, filter []string) (*types.SubnetCidrReservation, error) {
+	filters := make(map[string][]string)
+	for _, f := range filter {
+		name, values, err := parseShorthandFilter(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse filter %s", f)
+		}
+		filters[name] = values
+	}
+	ip, err := netip.ParseAddr(address)
+	if err != nil {
+		return nil, ErrStaticIPNotFound
+	}
+	networkInterfaces, err := a.ipv6Lister.ListNetworkInterfaces(ctx, map[string][]string{"ipv6-addresses.ipv6-address": {address}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list network interface of IPv6 address %s", address)
+	}
+	if len(networkInterfaces) == 0 {
+		return nil, ErrStaticIPNotFound
+	}
+	reservations, err := a.ipv6Lister.ListReservations(ctx, aws.ToString(networkInterfaces[0].SubnetId), filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list IPv6 CIDR reservations")
+	}
+	for i := range reservations {
+		if prefix, parseErr := netip.ParsePrefix(aws.ToString(reservations[i].Cidr)); parseErr == nil && prefix.Contains(ip) {
+			return &reservations[i], nil
+		}
+	}
+	return nil, ErrStaticIPNotFound
+}
+
+// instanceIPv6Addresses returns the IPv6 addresses of the network interface
+func instanceIPv6Addresses(ni *types.InstanceNetworkInterface) []netip.Addr {
+	addresses := make([]netip.Addr, 0, len(ni.Ipv6Addresses))
+	for _, address := range ni.Ipv6Addresses {
+		if ip, err := netip.ParseAddr(aws.ToString(address.Ipv6Address)); err == nil {
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses
+}
+
+// reservedIPv6Address returns the first address within a reservation, empty if none
+func reservedIPv6Address(addresses []netip.Addr, reservations []types.SubnetCidrReservation) string {
+	for _, reservation := range reservations {
+		prefix, err := netip.ParsePrefix(aws.ToString(reservation.Cidr))
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if prefix.Contains(address) {
+				return address.String()
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,219 @@
+package address
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/doitintl/kubeip/internal/cloud"
+	mocks "github.com/doitintl/kubeip/mocks/cloud"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func newIPv6TestInstance(ipv6Addresses ...string) *types.Instance {
+	addresses := make([]types.InstanceIpv6Address, 0, len(ipv6Addresses))
+	for _, address := range ipv6Addresses {
+		addresses = append(addresses, types.InstanceIpv6Address{Ipv6Address: aws.String(address)})
+	}
+	return &types.Instance{
+		InstanceId: aws.String("i-0abcd1234efgh5678"),
+		NetworkInterfaces: []types.InstanceNetworkInterface{
+			{
+				NetworkInterfaceId: aws.String("eni-0abcd1234efgh5678"),
+				SubnetId:           aws.String("subnet-0abcd1234efgh5678"),
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(0)},
+				Ipv6Addresses:      addresses,
+			},
+		},
+	}
+}
+
+func Test_awsIPv6Assigner_Assign(t *testing.T) {
+	const subnetID = "subnet-0abcd1234efgh5678"
+	const eniID = "eni-0abcd1234efgh5678"
+	reservations := []types.SubnetCidrReservation{
+		{
+			SubnetCidrReservationId: aws.String("scr-2"),
+			Cidr:                    aws.String("2600:1f18::20/126"),
+			Tags:                    []types.Tag{{Key: aws.String("order"), Value: aws.String("2")}},
+		},
+		{
+			SubnetCidrReservationId: aws.String("scr-1"),
+			Cidr:                    aws.String("2600:1f18::10/126"),
+			Tags:                    []types.Tag{{Key: aws.String("order"), Value: aws.String("1")}},
+		},
+	}
+	inUse := []types.NetworkInterface{
+		{Ipv6Addresses: []types.NetworkInterfaceIpv6Address{{Ipv6Address: aws.String("2600:1f18::10")}}},
+	}
+	tests := []struct {
+		name           string
+		instance       *types.Instance
+		filter         []string
+		orderBy        string
+		listerFn       func(t *testing.T) cloud.Ipv6AddressLister
+		ipv6AssignerFn func(t *testing.T) cloud.Ipv6AddressAssigner
+		want           string
+		wantErr        error
+	}{
+		{
+			name:     "assign first free address of the first reservation",
+			instance: newIPv6TestInstance("2600:1f18::abcd"),
+			filter:   []string{"Name=tag:kubeip,Values=reserved"},
+			orderBy:  "Tag:order",
+			listerFn: func(t *testing.T) cloud.Ipv6AddressLister {
+				mock := mocks.NewIpv6AddressLister(t)
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string(nil)).Return(reservations, nil).Once()
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string{"tag:kubeip": {"reserved"}}).Return(append([]types.SubnetCidrReservation(nil), reservations...), nil).Once()
+				mock.EXPECT().ListNetworkInterfaces(context.TODO(), map[string][]string{"subnet-id": {subnetID}}).Return(inUse, nil).Once()
+				return mock
+			},
+			ipv6AssignerFn: func(t *testing.T) cloud.Ipv6AddressAssigner {
+				mock := mocks.NewIpv6AddressAssigner(t)
+				mock.EXPECT().Assign(context.TODO(), eniID, "2600:1f18::11").Return(nil).Once()
+				return mock
+			},
+			want: "2600:1f18::11",
+		},
+		{
+			name:     "retry with next address when assignment fails",
+			instance: newIPv6TestInstance(),
+			listerFn: func(t *testing.T) cloud.Ipv6AddressLister {
+				mock := mocks.NewIpv6AddressLister(t)
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string(nil)).Return(reservations, nil).Once()
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string{}).Return(reservations[:1], nil).Once()
+				mock.EXPECT().ListNetworkInterfaces(context.TODO(), map[string][]string{"subnet-id": {subnetID}}).Return(nil, nil).Once()
+				return mock
+			},
+			ipv6AssignerFn: func(t *testing.T) cloud.Ipv6AddressAssigner {
+				mock := mocks.NewIpv6AddressAssigner(t)
+				mock.EXPECT().Assign(context.TODO(), eniID, "2600:1f18::20").Return(errors.New("address in use")).Once()
+				mock.EXPECT().Assign(context.TODO(), eniID, "2600:1f18::21").Return(nil).Once()
+				return mock
+			},
+			want: "2600:1f18::21",
+		},
+		{
+			name:     "reserved address already assigned",
+			instance: newIPv6TestInstance("2600:1f18::abcd", "2600:1f18::22"),
+			listerFn: func(t *testing.T) cloud.Ipv6AddressLister {
+				mock := mocks.NewIpv6AddressLister(t)
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string(nil)).Return(reservations, nil).Once()
+				return mock
+			},
+			ipv6AssignerFn: func(t *testing.T) cloud.Ipv6AddressAssigner {
+				return mocks.NewIpv6AddressAssigner(t)
+			},
+			want:    "2600:1f18::22",
+			wantErr: ErrStaticIPAlreadyAssigned,
+		},
+		{
+			name:     "all reserved addresses in use",
+			instance: newIPv6TestInstance(),
+			listerFn: func(t *testing.T) cloud.Ipv6AddressLister {
+				mock := mocks.NewIpv6AddressLister(t)
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string(nil)).Return(nil, nil).Once()
+				mock.EXPECT().ListReservations(context.TODO(), subnetID, map[string][]string{}).Return([]types.SubnetCidrReservation{
+					{SubnetCidrReservationId: aws.String("scr-3"), Cidr: aws.String("2600:1f18::30/128")},
+				}, nil).Once()
+				mock.EXPECT().ListNetworkInterfaces(context.TODO(), map[string][]string{"subnet-id": {subnetID}}).Return([]types.NetworkInterface{
+					{Ipv6Addresses: []types.NetworkInterfaceIpv6Address{{Ipv6Address: aws.String("2600:1f18::30")}}},
+				}, nil).Once()
+				return mock
+			},
+			ipv6AssignerFn: func(t *testing.T) cloud.Ipv6AddressAssigner {
+				return mocks.NewIpv6AddressAssigner(t)
+			},
+			wantErr: ErrNoAvailableStaticIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceGetter := mocks.NewEc2InstanceGetter(t)
+			instanceGetter.EXPECT().Get(context.TODO(), "i-0abcd1234efgh5678", "us-east-1").Return(tt.instance, nil).Once()
+			a := &awsIPv6Assigner{
+				region:         "us-east-1",
+				logger:         logrus.NewEntry(logrus.New()),
+				instanceGetter: instanceGetter,
+				ipv6Lister:     tt.listerFn(t),
+				ipv6Assigner:   tt.ipv6AssignerFn(t),
+			}
+			got, err := a.Assign(context.TODO(), "i-0abcd1234efgh5678", "", tt.filter, tt.orderBy)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Assign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_awsIPv6Assigner_Unassign(t *testing.T) {
+	reservations := []types.SubnetCidrReservation{
+		{SubnetCidrReservationId: aws.String("scr-1"), Cidr: aws.String("2600:1f18::10/126")},
+	}
+	tests := []struct {
+		name     string
+		instance *types.Instance
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "unassign reserved address",
+			instance: newIPv6TestInstance("2600:1f18::abcd", "2600:1f18::12"),
+			want:     "2600:1f18::12",
+		},
+		{
+			name:     "no reserved address assigned",
+			instance: newIPv6TestInstance("2600:1f18::abcd"),
+			wantErr:  ErrNoStaticIPAssigned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceGetter := mocks.NewEc2InstanceGetter(t)
+			instanceGetter.EXPECT().Get(context.TODO(), "i-0abcd1234efgh5678", "us-east-1").Return(tt.instance, nil).Once()
+			lister := mocks.NewIpv6AddressLister(t)
+			lister.EXPECT().ListReservations(context.TODO(), "subnet-0abcd1234efgh5678", map[string][]string(nil)).Return(reservations, nil).Once()
+			assigner := mocks.NewIpv6AddressAssigner(t)
+			if tt.want != "" {
+				assigner.EXPECT().Unassign(context.TODO(), "eni-0abcd1234efgh5678", tt.want).Return(nil).Once()
+			}
+			a := &awsIPv6Assigner{
+				region:         "us-east-1",
+				logger:         logrus.NewEntry(logrus.New()),
+				instanceGetter: instanceGetter,
+				ipv6Lister:     lister,
+				ipv6Assigner:   assigner,
+			}
+			err := a.Unassign(context.TODO(), "i-0abcd1234efgh5678", "")
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Unassign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_awsIPv6Assigner_MatchFilter(t *testing.T) {
+	lister := mocks.NewIpv6AddressLister(t)
+	lister.EXPECT().ListNetworkInterfaces(context.TODO(), map[string][]string{"ipv6-addresses.ipv6-address": {"2600:1f18::12"}}).Return([]types.NetworkInterface{
+		{SubnetId: aws.String("subnet-0abcd1234efgh5678")},
+	}, nil).Twice()
+	lister.EXPECT().ListReservations(context.TODO(), "subnet-0abcd1234efgh5678", map[string][]string{"tag:kubeip": {"reserved"}}).Return([]types.SubnetCidrReservation{
+		{SubnetCidrReservationId: aws.String("scr-1"), Cidr: aws.String("2600:1f18::10/126")},
+	}, nil).Once()
+	lister.EXPECT().ListReservations(context.TODO(), "subnet-0abcd1234efgh5678", map[string][]string{"tag:kubeip": {"other"}}).Return(nil, nil).Once()
+	a := &awsIPv6Assigner{logger: logrus.NewEntry(logrus.New()), ipv6Lister: lister}
+
+	got, err := a.MatchFilter(context.TODO(), "2600:1f18::12", []string{"Name=tag:kubeip,Values=reserved"})
+	if err != nil || !got {
+		t.Errorf("MatchFilter() = %v, %v, want true", got, err)
+	}
+	got, err = a.MatchFilter(context.TODO(), "2600:1f18::12", []string{"Name=tag:kubeip,Values=other"})
+	if err != nil || got {
+		t.Errorf("MatchFilter() = %v, %v, want false", got, err)
+	}
+}
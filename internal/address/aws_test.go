@@ -4,13 +4,20 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/doitintl/kubeip/internal/cloud"
+	"github.com/doitintl/kubeip/internal/lease"
+	kubeiptypes "github.com/doitintl/kubeip/internal/types"
 	mocks "github.com/doitintl/kubeip/mocks/cloud"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	tmock "github.com/stretchr/testify/mock"
 )
 
 func Test_sortAddressesByTag(t *testing.T) {
@@ -421,6 +428,31 @@ func Test_sortAddressesByField(t *testing.T) {
 	}
 }
 
+// testTwoENIInstanceGetter returns an instance getter of the instance with two network interfaces
+func testTwoENIInstanceGetter(t *testing.T) cloud.Ec2InstanceGetter {
+	mock := mocks.NewEc2InstanceGetter(t)
+	mock.EXPECT().Get(tmock.Anything, "i-0abcd1234efgh5678", tmock.Anything).Return(testTwoENIInstance("i-0abcd1234efgh5678"), nil).Once()
+	return mock
+}
+
+// testTwoENIInstance returns an instance with a primary and a secondary network interface
+func testTwoENIInstance(instanceID string) *types.Instance {
+	return &types.Instance{
+		InstanceId: aws.String(instanceID),
+		NetworkInterfaces: []types.InstanceNetworkInterface{
+			{
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(0)},
+				Association:        &types.InstanceNetworkInterfaceAssociation{PublicIp: aws.String("100.0.0.1")},
+				NetworkInterfaceId: aws.String("eni-primary"),
+			},
+			{
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(1)},
+				NetworkInterfaceId: aws.String("eni-secondary"),
+			},
+		},
+	}
+}
+
 func Test_awsAssigner_Assign(t *testing.T) {
 	type args struct {
 		ctx        context.Context
@@ -435,6 +467,7 @@ func Test_awsAssigner_Assign(t *testing.T) {
 		instanceGetterFn func(t *testing.T, args *args) cloud.Ec2InstanceGetter
 		eipListerFn      func(t *testing.T, args *args) cloud.EipLister
 		eipAssignerFn    func(t *testing.T, args *args) cloud.EipAssigner
+		selector         kubeiptypes.NetworkInterfaceSelector
 	}
 	tests := []struct {
 		name    string
@@ -469,7 +502,7 @@ func Test_awsAssigner_Assign(t *testing.T) {
 				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
 					mock := mocks.NewEipLister(t)
 					mock.EXPECT().List(args.ctx, map[string][]string{
-						"instance-id": {args.instanceID},
+						"network-interface-id": {"eni-0abcd1234efgh5678"},
 					}, true).Return([]types.Address{}, nil).Once()
 					mock.EXPECT().List(args.ctx, map[string][]string{
 						"tag:env":    {"test"},
@@ -543,10 +576,13 @@ func Test_awsAssigner_Assign(t *testing.T) {
 		{
 			name: "instance already has EIP assigned",
 			fields: fields{
-				region: "us-east-1",
-				logger: logrus.NewEntry(logrus.New()),
+				region:  "us-east-1",
+				logger:  logrus.NewEntry(logrus.New()),
+				address: "100.0.0.1",
 				instanceGetterFn: func(t *testing.T, args *args) cloud.Ec2InstanceGetter {
-					return nil
+					mock := mocks.NewEc2InstanceGetter(t)
+					mock.EXPECT().Get(args.ctx, args.instanceID, "us-east-1").Return(testTwoENIInstance(args.instanceID), nil)
+					return mock
 				},
 				eipAssignerFn: func(t *testing.T, args *args) cloud.EipAssigner {
 					return nil
@@ -554,7 +590,7 @@ func Test_awsAssigner_Assign(t *testing.T) {
 				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
 					mock := mocks.NewEipLister(t)
 					mock.EXPECT().List(args.ctx, map[string][]string{
-						"instance-id": {args.instanceID},
+						"network-interface-id": {"eni-primary"},
 					}, true).Return([]types.Address{
 						{
 							AllocationId: aws.String("eipalloc-0abcd1234efgh5678"),
@@ -576,20 +612,60 @@ func Test_awsAssigner_Assign(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "assign EIP to second network interface when first one already has EIP assigned",
+			fields: fields{
+				region:   "us-east-1",
+				logger:   logrus.NewEntry(logrus.New()),
+				address:  "100.0.0.2",
+				selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceByIndex, Value: "1"},
+				instanceGetterFn: func(t *testing.T, args *args) cloud.Ec2InstanceGetter {
+					mock := mocks.NewEc2InstanceGetter(t)
+					mock.EXPECT().Get(args.ctx, args.instanceID, "us-east-1").Return(testTwoENIInstance(args.instanceID), nil)
+					return mock
+				},
+				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
+					mock := mocks.NewEipLister(t)
+					mock.EXPECT().List(args.ctx, map[string][]string{
+						"network-interface-id": {"eni-secondary"},
+					}, true).Return([]types.Address{}, nil).Once()
+					mock.EXPECT().List(args.ctx, map[string][]string{}, false).Return([]types.Address{
+						{AllocationId: aws.String("eipalloc-0abcd1234efgh5679"), PublicIp: aws.String("100.0.0.2")},
+					}, nil).Once()
+					mock.EXPECT().List(args.ctx, map[string][]string{
+						"allocation-id": {"eipalloc-0abcd1234efgh5679"},
+					}, true).Return([]types.Address{
+						{AllocationId: aws.String("eipalloc-0abcd1234efgh5679"), PublicIp: aws.String("100.0.0.2")},
+					}, nil).Once()
+					return mock
+				},
+				eipAssignerFn: func(t *testing.T, args *args) cloud.EipAssigner {
+					mock := mocks.NewEipAssigner(t)
+					mock.EXPECT().Assign(args.ctx, "eni-secondary", "eipalloc-0abcd1234efgh5679").Return(nil)
+					return mock
+				},
+			},
+			args: args{
+				ctx:        context.Background(),
+				instanceID: "i-0abcd1234efgh5678",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &awsAssigner{
-				region:         tt.fields.region,
-				logger:         tt.fields.logger,
-				instanceGetter: tt.fields.instanceGetterFn(t, &tt.args),
-				eipLister:      tt.fields.eipListerFn(t, &tt.args),
-				eipAssigner:    tt.fields.eipAssignerFn(t, &tt.args),
+				region:           tt.fields.region,
+				logger:           tt.fields.logger,
+				instanceGetter:   tt.fields.instanceGetterFn(t, &tt.args),
+				eipLister:        tt.fields.eipListerFn(t, &tt.args),
+				eipAssigner:      tt.fields.eipAssignerFn(t, &tt.args),
+				networkInterface: tt.fields.selector,
 			}
 			address, err := a.Assign(tt.args.ctx, tt.args.instanceID, "", tt.args.filter, tt.args.orderBy)
 			if err != nil != tt.wantErr {
 				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
-			} else if address != tt.fields.address {
+			}
+			if address != tt.fields.address {
 				t.Fatalf("Assign() = %v, want %v", address, tt.fields.address)
 			}
 		})
@@ -827,11 +903,29 @@ func Test_awsAssigner_getNetworkInterfaceID(t *testing.T) {
 	type args struct {
 		instance *types.Instance
 	}
+	multiENI := &types.Instance{
+		InstanceId: aws.String("i-0abcd1234efgh5678"),
+		NetworkInterfaces: []types.InstanceNetworkInterface{
+			{
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(0)},
+				Association:        &types.InstanceNetworkInterfaceAssociation{PublicIp: aws.String("100.0.0.1")},
+				NetworkInterfaceId: aws.String("eni-primary"),
+				SubnetId:           aws.String("subnet-private"),
+			},
+			{
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(1)},
+				NetworkInterfaceId: aws.String("eni-secondary"),
+				SubnetId:           aws.String("subnet-public"),
+			},
+		},
+	}
 	tests := []struct {
-		name    string
-		args    args
-		want    string
-		wantErr bool
+		name     string
+		args     args
+		selector kubeiptypes.NetworkInterfaceSelector
+		listerFn func(t *testing.T) cloud.Ec2NetworkInterfaceLister
+		want     string
+		wantErr  bool
 	}{
 		{
 			name: "get network interface ID",
@@ -863,7 +957,7 @@ func Test_awsAssigner_getNetworkInterfaceID(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "no public IP",
+			name: "primary network interface without public IP",
 			args: args{
 				instance: &types.Instance{
 					InstanceId: aws.String("i-0abcd1234efgh5678"),
@@ -872,9 +966,24 @@ func Test_awsAssigner_getNetworkInterfaceID(t *testing.T) {
 							Attachment: &types.InstanceNetworkInterfaceAttachment{
 								DeviceIndex: aws.Int32(0),
 							},
-							Association: &types.InstanceNetworkInterfaceAssociation{
-								PublicIp: nil,
+							NetworkInterfaceId: aws.String("eni-0abcd1234efgh5678"),
+						},
+					},
+				},
+			},
+			want: "eni-0abcd1234efgh5678",
+		},
+		{
+			name: "no primary network interface",
+			args: args{
+				instance: &types.Instance{
+					InstanceId: aws.String("i-0abcd1234efgh5678"),
+					NetworkInterfaces: []types.InstanceNetworkInterface{
+						{
+							Attachment: &types.InstanceNetworkInterfaceAttachment{
+								DeviceIndex: aws.Int32(1),
 							},
+							NetworkInterfaceId: aws.String("eni-0abcd1234efgh5678"),
 						},
 					},
 				},
@@ -909,11 +1018,52 @@ func Test_awsAssigner_getNetworkInterfaceID(t *testing.T) {
 			},
 			want: "eni-0abcd1234efgh5679",
 		},
+		{
+			name:     "secondary network interface without public IP by device index",
+			args:     args{instance: multiENI},
+			selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceByIndex, Value: "1"},
+			want:     "eni-secondary",
+		},
+		{
+			name:     "network interface by ENI ID",
+			args:     args{instance: multiENI},
+			selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceByName, Value: "eni-primary"},
+			want:     "eni-primary",
+		},
+		{
+			name:     "network interface by subnet",
+			args:     args{instance: multiENI},
+			selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceBySubnet, Value: "subnet-public"},
+			want:     "eni-secondary",
+		},
+		{
+			name:     "network interface by tag",
+			args:     args{instance: multiENI},
+			selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceByTag, Value: "role=public"},
+			listerFn: func(t *testing.T) cloud.Ec2NetworkInterfaceLister {
+				mock := mocks.NewEc2NetworkInterfaceLister(t)
+				mock.EXPECT().List(tmock.Anything, "i-0abcd1234efgh5678").Return([]types.NetworkInterface{
+					{NetworkInterfaceId: aws.String("eni-primary"), TagSet: []types.Tag{{Key: aws.String("role"), Value: aws.String("private")}}},
+					{NetworkInterfaceId: aws.String("eni-secondary"), TagSet: []types.Tag{{Key: aws.String("role"), Value: aws.String("public")}}},
+				}, nil)
+				return mock
+			},
+			want: "eni-secondary",
+		},
+		{
+			name:     "no network interface matching selector",
+			args:     args{instance: multiENI},
+			selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceByIndex, Value: "2"},
+			wantErr:  true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a := &awsAssigner{}
-			got, err := a.getNetworkInterfaceID(tt.args.instance)
+			a := &awsAssigner{networkInterface: tt.selector}
+			if tt.listerFn != nil {
+				a.networkInterfaceLister = tt.listerFn(t)
+			}
+			got, err := a.getNetworkInterfaceID(context.TODO(), tt.args.instance)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getNetworkInterfaceID() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -932,6 +1082,7 @@ func Test_awsAssigner_getAssignedElasticIP(t *testing.T) {
 	type fields struct {
 		region      string
 		eipListerFn func(t *testing.T, args *args) cloud.EipLister
+		selector    kubeiptypes.NetworkInterfaceSelector
 	}
 	tests := []struct {
 		name    string
@@ -950,7 +1101,7 @@ func Test_awsAssigner_getAssignedElasticIP(t *testing.T) {
 				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
 					mock := mocks.NewEipLister(t)
 					mock.EXPECT().List(context.TODO(), map[string][]string{
-						"instance-id": {args.instanceID},
+						"network-interface-id": {"eni-primary"},
 					}, true).Return([]types.Address{
 						{
 							AllocationId: aws.String("eipalloc-0abcd1234efgh5678"),
@@ -975,7 +1126,25 @@ func Test_awsAssigner_getAssignedElasticIP(t *testing.T) {
 				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
 					mock := mocks.NewEipLister(t)
 					mock.EXPECT().List(context.TODO(), map[string][]string{
-						"instance-id": {args.instanceID},
+						"network-interface-id": {"eni-primary"},
+					}, true).Return([]types.Address{}, nil).Once()
+					return mock
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "EIP assigned to another network interface",
+			args: args{
+				instanceID: "i-0abcd1234efgh5678",
+			},
+			fields: fields{
+				region:   "us-east-1",
+				selector: kubeiptypes.NetworkInterfaceSelector{Kind: kubeiptypes.NetworkInterfaceByIndex, Value: "1"},
+				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
+					mock := mocks.NewEipLister(t)
+					mock.EXPECT().List(context.TODO(), map[string][]string{
+						"network-interface-id": {"eni-secondary"},
 					}, true).Return([]types.Address{}, nil).Once()
 					return mock
 				},
@@ -986,8 +1155,10 @@ func Test_awsAssigner_getAssignedElasticIP(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &awsAssigner{
-				region:    tt.fields.region,
-				eipLister: tt.fields.eipListerFn(t, &tt.args),
+				region:           tt.fields.region,
+				instanceGetter:   testTwoENIInstanceGetter(t),
+				eipLister:        tt.fields.eipListerFn(t, &tt.args),
+				networkInterface: tt.fields.selector,
 			}
 			got, err := a.getAssignedElasticIP(context.TODO(), tt.args.instanceID)
 			if (err != nil) != tt.wantErr {
@@ -1026,7 +1197,7 @@ func Test_awsAssigner_Unassign(t *testing.T) {
 				eipListerFn: func(t *testing.T, args *args) cloud.EipLister {
 					mock := mocks.NewEipLister(t)
 					mock.EXPECT().List(context.TODO(), map[string][]string{
-						"instance-id": {args.instanceID},
+						"network-interface-id": {"eni-primary"},
 					}, true).Return([]types.Address{
 						{
 							AllocationId:  aws.String("eipalloc-0abcd1234efgh5678"),
@@ -1047,10 +1218,11 @@ func Test_awsAssigner_Unassign(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &awsAssigner{
-				region:      tt.fields.region,
-				logger:      logrus.NewEntry(logrus.New()),
-				eipLister:   tt.fields.eipListerFn(t, &tt.args),
-				eipAssigner: tt.fields.eipAssignerFn(t, &tt.args),
+				region:         tt.fields.region,
+				logger:         logrus.NewEntry(logrus.New()),
+				instanceGetter: testTwoENIInstanceGetter(t),
+				eipLister:      tt.fields.eipListerFn(t, &tt.args),
+				eipAssigner:    tt.fields.eipAssignerFn(t, &tt.args),
 			}
 			if err := a.Unassign(context.TODO(), tt.args.instanceID, ""); (err != nil) != tt.wantErr {
 				t.Errorf("Unassign() error = %v, wantErr %v", err, tt.wantErr)
@@ -1058,3 +1230,345 @@ func Test_awsAssigner_Unassign(t *testing.T) {
 		})
 	}
 }
+
+func Test_awsAssigner_GetResourceID(t *testing.T) {
+	tests := []struct {
+		name        string
+		eipListerFn func(t *testing.T) cloud.EipLister
+		want        string
+		wantErr     error
+	}{
+		{
+			name: "get allocation ID",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"public-ip": {"100.0.0.1"},
+				}, true).Return([]types.Address{
+					{
+						AllocationId: aws.String("eipalloc-0abcd1234efgh5678"),
+						PublicIp:     aws.String("100.0.0.1"),
+					},
+				}, nil).Once()
+				return mock
+			},
+			want: "eipalloc-0abcd1234efgh5678",
+		},
+		{
+			name: "elastic IP not found",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"public-ip": {"100.0.0.1"},
+				}, true).Return([]types.Address{}, nil).Once()
+				return mock
+			},
+			wantErr: ErrStaticIPNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &awsAssigner{
+				eipLister: tt.eipListerFn(t),
+			}
+			got, err := a.GetResourceID(context.TODO(), "100.0.0.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetResourceID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetResourceID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_awsAssigner_MatchFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      []string
+		eipListerFn func(t *testing.T) cloud.EipLister
+		want        bool
+		wantErr     bool
+	}{
+		{
+			name:   "elastic IP matches filter",
+			filter: []string{"Name=tag:env,Values=dev"},
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"tag:env":   {"dev"},
+					"public-ip": {"100.0.0.1"},
+				}, true).Return([]types.Address{
+					{
+						AllocationId: aws.String("eipalloc-0abcd1234efgh5678"),
+						PublicIp:     aws.String("100.0.0.1"),
+					},
+				}, nil).Once()
+				return mock
+			},
+			want: true,
+		},
+		{
+			name:   "elastic IP excluded by filter",
+			filter: []string{"Name=tag:env,Values=prod"},
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"tag:env":   {"prod"},
+					"public-ip": {"100.0.0.1"},
+				}, true).Return([]types.Address{}, nil).Once()
+				return mock
+			},
+		},
+		{
+			name:   "invalid filter",
+			filter: []string{"tag:env=prod"},
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				return mocks.NewEipLister(t)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &awsAssigner{
+				eipLister: tt.eipListerFn(t),
+			}
+			got, err := a.MatchFilter(context.TODO(), "100.0.0.1", tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MatchFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MatchFilter() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_awsAssigner_IsAssigned(t *testing.T) {
+	tests := []struct {
+		name        string
+		eipListerFn func(t *testing.T) cloud.EipLister
+		want        bool
+		wantErr     bool
+	}{
+		{
+			name: "elastic IP still associated",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"network-interface-id": {"eni-primary"},
+				}, true).Return([]types.Address{
+					{
+						AllocationId: aws.String("eipalloc-0abcd1234efgh5678"),
+						PublicIp:     aws.String("100.0.0.1"),
+					},
+				}, nil).Once()
+				return mock
+			},
+			want: true,
+		},
+		{
+			name: "another elastic IP associated",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"network-interface-id": {"eni-primary"},
+				}, true).Return([]types.Address{
+					{
+						AllocationId: aws.String("eipalloc-1abcd1234efgh5678"),
+						PublicIp:     aws.String("100.0.0.2"),
+					},
+				}, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "elastic IP disassociated",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"network-interface-id": {"eni-primary"},
+				}, true).Return([]types.Address{}, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "fail to list elastic IPs",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), map[string][]string{
+					"network-interface-id": {"eni-primary"},
+				}, true).Return(nil, errors.New("error")).Once()
+				return mock
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &awsAssigner{
+				instanceGetter: testTwoENIInstanceGetter(t),
+				eipLister:      tt.eipListerFn(t),
+			}
+			got, err := a.IsAssigned(context.TODO(), "i-0abcd1234efgh5678", "", "100.0.0.1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IsAssigned() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IsAssigned() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_awsAssigner_tryClaimAndAssignAddress(t *testing.T) {
+	const (
+		instanceID         = "i-0123456789abcdef0"
+		networkInterfaceID = "eni-0123456789abcdef0"
+		allocationID       = "eipalloc-0abcd1234efgh5678"
+	)
+	filter := map[string][]string{"allocation-id": {allocationID}}
+	claimedByInstance := tmock.MatchedBy(func(tags map[string]string) bool {
+		return claimedBy(tags, instanceID)
+	})
+	available := func(tags map[string]string) []types.Address {
+		address := types.Address{AllocationId: aws.String(allocationID), PublicIp: aws.String("100.0.0.1")}
+		for k, v := range tags {
+			address.Tags = append(address.Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+		return []types.Address{address}
+	}
+	tests := []struct {
+		name          string
+		eipListerFn   func(t *testing.T) cloud.EipLister
+		eipAssignerFn func(t *testing.T) cloud.EipAssigner
+		eipTaggerFn   func(t *testing.T) cloud.EipTagger
+		wantClaimed   bool
+		wantErr       bool
+	}{
+		{
+			name: "claim and assign elastic IP",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(nil), nil).Once()
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(claimTags(instanceID, time.Now())), nil).Once()
+				mock.EXPECT().List(context.TODO(), filter, true).Return([]types.Address{
+					{AllocationId: aws.String(allocationID), NetworkInterfaceId: aws.String(networkInterfaceID)},
+				}, nil).Once()
+				return mock
+			},
+			eipAssignerFn: func(t *testing.T) cloud.EipAssigner {
+				mock := mocks.NewEipAssigner(t)
+				mock.EXPECT().Assign(context.TODO(), networkInterfaceID, allocationID).Return(nil).Once()
+				return mock
+			},
+			eipTaggerFn: func(t *testing.T) cloud.EipTagger {
+				mock := mocks.NewEipTagger(t)
+				mock.EXPECT().Tag(context.TODO(), allocationID, claimedByInstance).Return(nil).Once()
+				mock.EXPECT().Untag(context.TODO(), allocationID, claimedByInstance).Return(nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "elastic IP claimed by another instance",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(claimTags("i-another", time.Now())), nil).Once()
+				return mock
+			},
+			eipAssignerFn: func(t *testing.T) cloud.EipAssigner {
+				return mocks.NewEipAssigner(t)
+			},
+			eipTaggerFn: func(t *testing.T) cloud.EipTagger {
+				return mocks.NewEipTagger(t)
+			},
+			wantClaimed: true,
+			wantErr:     true,
+		},
+		{
+			name: "elastic IP claimed concurrently by another instance",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(nil), nil).Once()
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(claimTags("i-another", time.Now())), nil).Once()
+				return mock
+			},
+			eipAssignerFn: func(t *testing.T) cloud.EipAssigner {
+				return mocks.NewEipAssigner(t)
+			},
+			eipTaggerFn: func(t *testing.T) cloud.EipTagger {
+				mock := mocks.NewEipTagger(t)
+				mock.EXPECT().Tag(context.TODO(), allocationID, claimedByInstance).Return(nil).Once()
+				mock.EXPECT().Untag(context.TODO(), allocationID, claimedByInstance).Return(nil).Once()
+				return mock
+			},
+			wantClaimed: true,
+			wantErr:     true,
+		},
+		{
+			name: "elastic IP associated concurrently",
+			eipListerFn: func(t *testing.T) cloud.EipLister {
+				mock := mocks.NewEipLister(t)
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(nil), nil).Once()
+				mock.EXPECT().List(context.TODO(), filter, false).Return(available(claimTags(instanceID, time.Now())), nil).Once()
+				return mock
+			},
+			eipAssignerFn: func(t *testing.T) cloud.EipAssigner {
+				mock := mocks.NewEipAssigner(t)
+				mock.EXPECT().Assign(context.TODO(), networkInterfaceID, allocationID).Return(errors.New("resource already associated")).Once()
+				return mock
+			},
+			eipTaggerFn: func(t *testing.T) cloud.EipTagger {
+				mock := mocks.NewEipTagger(t)
+				mock.EXPECT().Tag(context.TODO(), allocationID, claimedByInstance).Return(nil).Once()
+				mock.EXPECT().Untag(context.TODO(), allocationID, claimedByInstance).Return(nil).Once()
+				return mock
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &awsAssigner{
+				logger:      logrus.NewEntry(logrus.New()),
+				eipLister:   tt.eipListerFn(t),
+				eipAssigner: tt.eipAssignerFn(t),
+				eipTagger:   tt.eipTaggerFn(t),
+				cloudClaims: true,
+			}
+			address := &available(nil)[0]
+			err := a.tryClaimAndAssignAddress(context.TODO(), address, networkInterfaceID, instanceID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tryClaimAndAssignAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, lease.ErrAddressClaimed) != tt.wantClaimed {
+				t.Errorf("tryClaimAndAssignAddress() error = %v, want claimed %v", err, tt.wantClaimed)
+			}
+		})
+	}
+}
+
+func Test_newEipClient(t *testing.T) {
+	cfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("key", "secret", "")}
+	client := ec2.NewFromConfig(cfg)
+
+	if got := newEipClient(cfg, client, "", ""); got != client {
+		t.Errorf("newEipClient() without role = %p, want local client %p", got, client)
+	}
+
+	got := newEipClient(cfg, client, "arn:aws:iam::123456789012:role/kubeip", "external-id")
+	if got == client {
+		t.Fatal("newEipClient() with role returned the local client")
+	}
+	cache, ok := got.Options().Credentials.(*aws.CredentialsCache)
+	if !ok || !cache.IsCredentialsProvider(&stscreds.AssumeRoleProvider{}) {
+		t.Errorf("newEipClient() credentials = %T, want cached assume role credentials", got.Options().Credentials)
+	}
+	if _, ok = client.Options().Credentials.(credentials.StaticCredentialsProvider); !ok {
+		t.Errorf("newEipClient() changed the local client credentials to %T", client.Options().Credentials)
+	}
+}
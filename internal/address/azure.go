@@ -1,14 +1,593 @@
 package address
 
-import "context"
+import (
+	"context"
+	"sort"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	"github.com/doitintl/kubeip/internal/cloud"
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	azureTagFilterPrefix      = "tags."
+	azurePrefixFilterPrefix   = "publicIPPrefix="
+	azureTagOrderPrefix       = "Tag:"
+	azureTagFilterTokens      = 2
+	azureAllocatedTag         = "kubeip-allocated" // tag set on public IPs created by kubeip from a public IP prefix
+	azureAssignedTag          = "kubeip-public-ip" // tag set on network interfaces with the ID of the public IP assigned by kubeip
+	azureAssignedIPv6Tag      = "kubeip-public-ipv6"
+	azurePublicIPNamePrefix   = "kubeip-"
+	azurePublicIPv6NameSuffix = "-ipv6" // name suffix of the IPv6 public IPs allocated from a public IP prefix
+)
+
+// azureFilters holds the parsed filters for Azure public IPs.
+type azureFilters struct {
+	// Tags that the public IP must have
+	Tags map[string]string
+	// PublicIPPrefix is the resource ID of the public IP prefix to allocate public IPs from
+	PublicIPPrefix string
+}
+
+// azureAssigner is an Assigner implementation for Microsoft Azure.
 type azureAssigner struct {
+	logger         *logrus.Entry
+	location       string
+	ipv6           bool
+	deletePrefixIP bool
+	nicGetter      cloud.AzureInterfaceGetter
+	nicUpdater     cloud.AzureInterfaceUpdater
+	publicIPLister cloud.AzurePublicIPLister
+	prefixManager  cloud.AzurePublicIPPrefixManager
 }
 
-func (a *azureAssigner) Assign(_ context.Context, _, _ string, _ []string, _ string) (string, error) {
-	return "", nil
+// NewAzureAssigner creates a new Assigner for Microsoft Azure.
+// The subscription ID is taken from the project and the location from the region;
+// if not set, both are read from the instance metadata service.
+func NewAzureAssigner(ctx context.Context, logger *logrus.Entry, cfg *config.Config) (Assigner, error) {
+	if cfg.LockScope == types.LockScopeCloud {
+		return nil, errors.New("cloud lock scope is not supported on Azure")
+	}
+	if !cfg.NetworkInterface.IsPrimary() {
+		return nil, errors.New("network interface selector is not supported on Azure")
+	}
+	subscriptionID := cfg.Project
+	location := cfg.Region
+	if subscriptionID == "" || location == "" {
+		metadata, err := cloud.GetAzureInstanceMetadata(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get instance metadata")
+		}
+		if cloud.IsAzureScaleSetVM(metadata.ResourceID) {
+			return nil, cloud.ErrAzureScaleSetVM
+		}
+		if subscriptionID == "" {
+			subscriptionID = metadata.SubscriptionID
+		}
+		if location == "" {
+			location = metadata.Location
+		}
+	}
+
+	// use default credential chain: environment, workload identity, managed identity
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Azure credential")
+	}
+
+	factory, err := armnetwork.NewClientFactory(subscriptionID, cred, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Azure network client")
+	}
+
+	return &azureAssigner{
+		logger:         logger,
+		location:       location,
+		ipv6:           cfg.IPv6,
+		deletePrefixIP: cfg.AzureDeletePrefixIP,
+		nicGetter:      cloud.NewAzureInterfaceGetter(factory.NewInterfacesClient()),
+		nicUpdater:     cloud.NewAzureInterfaceUpdater(factory.NewInterfacesClient()),
+		publicIPLister: cloud.NewAzurePublicIPLister(factory.NewPublicIPAddressesClient()),
+		prefixManager:  cloud.NewAzurePublicIPPrefixManager(factory.NewPublicIPPrefixesClient(), factory.NewPublicIPAddressesClient()),
+	}, nil
 }
 
-func (a *azureAssigner) Unassign(_ context.Context, _, _ string) error {
+// Assign attaches a free Standard SKU public IP to the primary IP configuration of the VM network interface.
+// If a public IP matching the filter is already attached, it returns this IP with ErrStaticIPAlreadyAssigned.
+// If the filter selects a public IP prefix and no free public IP from the prefix exists, a new one is allocated from the prefix.
+func (a *azureAssigner) Assign(ctx context.Context, instanceID, _ string, filter []string, orderBy string) (string, error) {
+	filters, err := parseAzureFilters(filter)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse filter")
+	}
+
+	// get VM primary network interface
+	nic, err := a.nicGetter.Get(ctx, instanceID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get network interface of instance %s", instanceID)
+	}
+	ipConfig, err := getIPConfiguration(nic, a.ipv6)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get IP configuration of instance %s", instanceID)
+	}
+
+	// list public IPs matching the filter
+	addresses, err := a.listPublicIPs(ctx, filters, orderBy)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list public IPs")
+	}
+
+	// check if one of the matching public IPs is already attached to the instance
+	if current := ipConfig.Properties.PublicIPAddress; current != nil && current.ID != nil {
+		for _, address := range addresses {
+			if strings.EqualFold(*address.ID, *current.ID) {
+				a.logger.WithField("address", *address.Properties.IPAddress).Infof("public IP already assigned on instance %s", instanceID)
+				a.tagAssignedPublicIP(ctx, nic, address)
+				return *address.Properties.IPAddress, ErrStaticIPAlreadyAssigned
+			}
+		}
+	}
+
+	available := make([]*armnetwork.PublicIPAddress, 0, len(addresses))
+	for _, address := range addresses {
+		if isAzurePublicIPAvailable(address) {
+			available = append(available, address)
+		}
+	}
+	metrics.FreeAddresses.WithLabelValues(string(types.CloudProviderAzure)).Set(float64(len(available)))
+	if len(available) == 0 {
+		if filters.PublicIPPrefix == "" {
+			return "", noAvailableStaticIPError("no available public IPs")
+		}
+		if err = lease.CheckHeld(ctx); err != nil {
+			return "", errors.Wrap(err, "failed to check lock before allocating public IP")
+		}
+		address, allocErr := a.allocatePrefixPublicIP(ctx, instanceID, filters)
+		if allocErr != nil {
+			return "", errors.Wrapf(allocErr, "failed to allocate public IP from prefix %s", filters.PublicIPPrefix)
+		}
+		available = append(available, address)
+	}
+	// log available addresses IPs
+	ips := make([]string, 0, len(available))
+	for _, address := range available {
+		ips = append(ips, *address.Properties.IPAddress)
+	}
+	a.logger.WithField("addresses", ips).Debugf("found %d available addresses", len(available))
+
+	// try to assign available addresses until succeeds
+	// due to concurrency, it is possible that another kubeip instance will assign the same address
+	for _, address := range available {
+		// check if context is done before trying to assign an address
+		if ctx.Err() != nil {
+			return "", errors.Wrap(ctx.Err(), "context cancelled while assigning addresses")
+		}
+		// claim the address when the lock scope is address; skip the addresses claimed by other nodes
+		claimCtx, release, claimErr := lease.ClaimAddress(ctx, *address.Properties.IPAddress)
+		if claimErr != nil {
+			err = claimErr
+			a.logger.WithError(err).Debug("skipping public IP")
+			continue
+		}
+		events.Eventf(claimCtx, corev1.EventTypeNormal, events.ReasonAddressSelected, "Selected public IP %s", *address.Properties.IPAddress)
+		// check the lock is still held before attaching the public IP
+		if err = lease.CheckHeld(claimCtx); err != nil {
+			release()
+			return "", errors.Wrap(err, "failed to check lock before assigning public IP")
+		}
+		err = a.tryAssignAddress(claimCtx, nic, ipConfig, address)
+		release()
+		if err != nil {
+			a.logger.WithError(err).WithField("address", *address.Properties.IPAddress).Warn("failed to assign public IP")
+			continue
+		}
+		a.logger.WithFields(logrus.Fields{
+			"instance": instanceID,
+			"address":  *address.Properties.IPAddress,
+			"id":       *address.ID,
+		}).Info("public IP assigned to the instance")
+		return *address.Properties.IPAddress, nil
+	}
+
+	return "", errors.Wrap(err, "failed to assign public IP")
+}
+
+// Unassign detaches the static public IP assigned by kubeip from the primary IP configuration of the VM network interface.
+// Public IPs attached by other means are left in place. Public IPs allocated by kubeip from a public IP prefix are deleted if configured.
+func (a *azureAssigner) Unassign(ctx context.Context, instanceID, _ string) error {
+	nic, err := a.nicGetter.Get(ctx, instanceID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get network interface of instance %s", instanceID)
+	}
+	ipConfig, err := getIPConfiguration(nic, a.ipv6)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get IP configuration of instance %s", instanceID)
+	}
+
+	address, err := a.getAttachedStaticPublicIP(ctx, ipConfig)
+	if err != nil {
+		return err
+	}
+	if !a.isAssignedByKubeIP(nic, address) {
+		a.logger.WithField("id", *address.ID).Infof("public IP attached to instance %s was not assigned by kubeip, skipping", instanceID)
+		return ErrNoStaticIPAssigned
+	}
+
+	ipConfig.Properties.PublicIPAddress = nil
+	delete(nic.Tags, a.assignedTag())
+	if err = a.nicUpdater.Update(ctx, nic); err != nil {
+		return errors.Wrap(err, "failed to detach public IP")
+	}
+	a.logger.WithFields(logrus.Fields{
+		"instance": instanceID,
+		"address":  stringOrEmpty(address.Properties.IPAddress),
+		"id":       *address.ID,
+	}).Info("public IP unassigned from the instance")
+
+	if a.deletePrefixIP && isAzurePrefixAllocatedPublicIP(address) {
+		if err = a.prefixManager.DeletePublicIP(ctx, *address.ID); err != nil {
+			return errors.Wrapf(err, "failed to delete public IP %s allocated from prefix", *address.ID)
+		}
+		a.logger.WithField("id", *address.ID).Info("public IP allocated from prefix deleted")
+	}
+
+	return nil
+}
+
+// getAttachedStaticPublicIP returns the static public IP attached to the IP configuration, ErrNoStaticIPAssigned if none.
+func (a *azureAssigner) getAttachedStaticPublicIP(ctx context.Context, ipConfig *armnetwork.InterfaceIPConfiguration) (*armnetwork.PublicIPAddress, error) {
+	current := ipConfig.Properties.PublicIPAddress
+	if current == nil || current.ID == nil {
+		return nil, ErrNoStaticIPAssigned
+	}
+	address, err := a.publicIPLister.Get(ctx, *current.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get public IP %s", *current.ID)
+	}
+	if !isAzureStaticPublicIP(address) {
+		return nil, ErrNoStaticIPAssigned
+	}
+	return address, nil
+}
+
+// allocatePrefixPublicIP creates a public IP for the instance from the public IP prefix.
+// The public IP is created in the prefix resource group, named after the VM and IP family and tagged with the filter tags.
+func (a *azureAssigner) allocatePrefixPublicIP(ctx context.Context, instanceID string, filters *azureFilters) (*armnetwork.PublicIPAddress, error) {
+	prefixID, err := arm.ParseResourceID(filters.PublicIPPrefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public IP prefix resource ID %s", filters.PublicIPPrefix)
+	}
+	vmID, err := arm.ParseResourceID(instanceID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse VM resource ID %s", instanceID)
+	}
+	prefix, err := a.prefixManager.GetPrefix(ctx, filters.PublicIPPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get public IP prefix")
+	}
+
+	version := armnetwork.IPVersionIPv4
+	if a.ipv6 {
+		version = armnetwork.IPVersionIPv6
+	}
+	tags := map[string]*string{azureAllocatedTag: to.Ptr("true")}
+	for key, value := range filters.Tags {
+		tags[key] = to.Ptr(value)
+	}
+	name := a.prefixPublicIPName(vmID.Name)
+	a.logger.WithFields(logrus.Fields{
+		"prefix": filters.PublicIPPrefix,
+		"name":   name,
+	}).Info("allocating public IP from prefix")
+	address, err := a.prefixManager.CreatePublicIP(ctx, prefixID.ResourceGroupName, name, &armnetwork.PublicIPAddress{
+		Location: prefix.Location,
+		Zones:    prefix.Zones,
+		Tags:     tags,
+		SKU: &armnetwork.PublicIPAddressSKU{
+			Name: to.Ptr(armnetwork.PublicIPAddressSKUNameStandard),
+			Tier: to.Ptr(armnetwork.PublicIPAddressSKUTierRegional),
+		},
+		Properties: &armnetwork.PublicIPAddressPropertiesFormat{
+			PublicIPAllocationMethod: to.Ptr(armnetwork.IPAllocationMethodStatic),
+			PublicIPAddressVersion:   to.Ptr(version),
+			PublicIPPrefix:           &armnetwork.SubResource{ID: prefix.ID},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create public IP")
+	}
+	if address.ID == nil || address.Properties == nil || address.Properties.IPAddress == nil {
+		return nil, errors.Errorf("public IP %s was created without an address", name)
+	}
+	return address, nil
+}
+
+// prefixPublicIPName returns the name of the public IP allocated from a public IP prefix for the VM; IPv6 public IPs
+// are suffixed, so the IPv4 and IPv6 public IPs of a dual-stack VM do not collide.
+func (a *azureAssigner) prefixPublicIPName(vmName string) string {
+	if a.ipv6 {
+		return azurePublicIPNamePrefix + vmName + azurePublicIPv6NameSuffix
+	}
+	return azurePublicIPNamePrefix + vmName
+}
+
+// tryAssignAddress attaches the public IP to the IP configuration.
+// On failure the IP configuration is restored to its previous state.
+func (a *azureAssigner) tryAssignAddress(ctx context.Context, nic *armnetwork.Interface, ipConfig *armnetwork.InterfaceIPConfiguration, address *armnetwork.PublicIPAddress) error {
+	// force check if address is already assigned (reduce the chance of assigning the same address by multiple kubeip instances)
+	latest, err := a.publicIPLister.Get(ctx, *address.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if address %s is assigned", *address.Properties.IPAddress)
+	}
+	if !isAzurePublicIPAvailable(latest) {
+		return errors.Errorf("address %s is already assigned", *address.Properties.IPAddress)
+	}
+
+	previous := ipConfig.Properties.PublicIPAddress
+	previousTag, tagged := nic.Tags[a.assignedTag()]
+	ipConfig.Properties.PublicIPAddress = &armnetwork.PublicIPAddress{ID: address.ID}
+	setAzureTag(nic, a.assignedTag(), *address.ID)
+	if err = a.nicUpdater.Update(ctx, nic); err != nil {
+		ipConfig.Properties.PublicIPAddress = previous
+		if tagged {
+			nic.Tags[a.assignedTag()] = previousTag
+		} else {
+			delete(nic.Tags, a.assignedTag())
+		}
+		return errors.Wrapf(err, "failed to attach public IP %s", *address.Properties.IPAddress)
+	}
 	return nil
 }
+
+// tagAssignedPublicIP records the public IP attached to the network interface as assigned by kubeip,
+// so it is released on node deletion. Public IPs attached by older kubeip versions are not tagged yet.
+func (a *azureAssigner) tagAssignedPublicIP(ctx context.Context, nic *armnetwork.Interface, address *armnetwork.PublicIPAddress) {
+	if a.isAssignedByKubeIP(nic, address) {
+		return
+	}
+	setAzureTag(nic, a.assignedTag(), *address.ID)
+	if err := a.nicUpdater.Update(ctx, nic); err != nil {
+		a.logger.WithError(err).WithField("id", *address.ID).Warn("failed to tag network interface with the assigned public IP")
+	}
+}
+
+// assignedTag returns the network interface tag holding the ID of the public IP assigned by kubeip.
+func (a *azureAssigner) assignedTag() string {
+	if a.ipv6 {
+		return azureAssignedIPv6Tag
+	}
+	return azureAssignedTag
+}
+
+// isAssignedByKubeIP checks if the public IP attached to the network interface was assigned or allocated by kubeip.
+func (a *azureAssigner) isAssignedByKubeIP(nic *armnetwork.Interface, address *armnetwork.PublicIPAddress) bool {
+	if stringOrEmpty(address.Tags[azureAllocatedTag]) == "true" {
+		return true
+	}
+	return strings.EqualFold(stringOrEmpty(nic.Tags[a.assignedTag()]), stringOrEmpty(address.ID))
+}
+
+func setAzureTag(nic *armnetwork.Interface, key, value string) {
+	if nic.Tags == nil {
+		nic.Tags = make(map[string]*string)
+	}
+	nic.Tags[key] = to.Ptr(value)
+}
+
+// listPublicIPs returns the public IPs in the assigner location matching the filter, sorted by orderBy.
+func (a *azureAssigner) listPublicIPs(ctx context.Context, filters *azureFilters, orderBy string) ([]*armnetwork.PublicIPAddress, error) {
+	list, err := a.publicIPLister.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list public IPs")
+	}
+
+	version := armnetwork.IPVersionIPv4
+	if a.ipv6 {
+		version = armnetwork.IPVersionIPv6
+	}
+	addresses := make([]*armnetwork.PublicIPAddress, 0, len(list))
+	for _, address := range list {
+		if address.ID == nil || address.Properties == nil || address.Properties.IPAddress == nil {
+			continue
+		}
+		if a.location != "" && !strings.EqualFold(stringOrEmpty(address.Location), a.location) {
+			continue
+		}
+		if address.Properties.PublicIPAddressVersion != nil && *address.Properties.PublicIPAddressVersion != version {
+			continue
+		}
+		if !matchAzureTags(address.Tags, filters.Tags) {
+			continue
+		}
+		if filters.PublicIPPrefix != "" && !isAzurePublicIPFromPrefix(address, filters.PublicIPPrefix) {
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+	sortPublicIPsByField(addresses, orderBy)
+	return addresses, nil
+}
+
+// getIPConfiguration returns the IP configuration to attach the public IP to:
+// the primary one for IPv4, or the first IPv6 one when IPv6 is enabled.
+func getIPConfiguration(nic *armnetwork.Interface, ipv6 bool) (*armnetwork.InterfaceIPConfiguration, error) {
+	if nic == nil || nic.Properties == nil || len(nic.Properties.IPConfigurations) == 0 {
+		return nil, errors.New("network interface has no IP configurations")
+	}
+	for _, ipConfig := range nic.Properties.IPConfigurations {
+		if ipConfig.Properties == nil {
+			continue
+		}
+		if ipv6 {
+			if ipConfig.Properties.PrivateIPAddressVersion != nil && *ipConfig.Properties.PrivateIPAddressVersion == armnetwork.IPVersionIPv6 {
+				return ipConfig, nil
+			}
+			continue
+		}
+		if ipConfig.Properties.Primary != nil && *ipConfig.Properties.Primary {
+			return ipConfig, nil
+		}
+	}
+	if ipv6 {
+		return nil, errors.New("network interface has no IPv6 IP configuration")
+	}
+	return nil, errors.New("network interface has no primary IP configuration")
+}
+
+// isAzureStaticPublicIP checks if the public IP is a Standard SKU (always static) public IP.
+func isAzureStaticPublicIP(address *armnetwork.PublicIPAddress) bool {
+	return address != nil && address.SKU != nil && address.SKU.Name != nil &&
+		*address.SKU.Name == armnetwork.PublicIPAddressSKUNameStandard
+}
+
+// isAzurePublicIPAvailable checks if the public IP is static and not associated with any resource.
+func isAzurePublicIPAvailable(address *armnetwork.PublicIPAddress) bool {
+	if !isAzureStaticPublicIP(address) || address.Properties == nil {
+		return false
+	}
+	return address.Properties.IPConfiguration == nil && address.Properties.NatGateway == nil
+}
+
+// isAzurePublicIPFromPrefix checks if the public IP was allocated from the given public IP prefix.
+func isAzurePublicIPFromPrefix(address *armnetwork.PublicIPAddress, prefixID string) bool {
+	if address == nil || address.Properties == nil || address.Properties.PublicIPPrefix == nil {
+		return false
+	}
+	return strings.EqualFold(stringOrEmpty(address.Properties.PublicIPPrefix.ID), prefixID)
+}
+
+// isAzurePrefixAllocatedPublicIP checks if the public IP was allocated by kubeip from a public IP prefix.
+func isAzurePrefixAllocatedPublicIP(address *armnetwork.PublicIPAddress) bool {
+	if address == nil || address.Properties == nil || address.Properties.PublicIPPrefix == nil {
+		return false
+	}
+	return stringOrEmpty(address.Tags[azureAllocatedTag]) == "true"
+}
+
+// parseAzureFilters parses the filters for Azure public IPs.
+// All filters are combined with AND condition.
+// Filter should be in following format:
+//   - "tags.key=value"
+//   - "publicIPPrefix=<public IP prefix resource ID>"
+func parseAzureFilters(filter []string) (*azureFilters, error) {
+	filters := &azureFilters{Tags: make(map[string]string, len(filter))}
+	for _, f := range filter {
+		if strings.HasPrefix(f, azurePrefixFilterPrefix) {
+			filters.PublicIPPrefix = strings.TrimPrefix(f, azurePrefixFilterPrefix)
+			if filters.PublicIPPrefix == "" {
+				return nil, errors.New("invalid filter format for Azure, public IP prefix resource ID is empty")
+			}
+			continue
+		}
+		if !strings.HasPrefix(f, azureTagFilterPrefix) {
+			return nil, errors.New("invalid filter format for Azure, should be in format tags.key=value or publicIPPrefix=<id>, found: " + f)
+		}
+		split := strings.SplitN(strings.TrimPrefix(f, azureTagFilterPrefix), "=", azureTagFilterTokens)
+		if len(split) != azureTagFilterTokens || split[0] == "" {
+			return nil, errors.New("invalid filter format for Azure, should be in format tags.key=value or publicIPPrefix=<id>, found: " + f)
+		}
+		filters.Tags[split[0]] = split[1]
+	}
+	return filters, nil
+}
+
+// matchAzureTags checks if the target tags contain all the filter keys and values.
+func matchAzureTags(target map[string]*string, filter map[string]string) bool {
+	for key, value := range filter {
+		if val, ok := target[key]; !ok || val == nil || *val != value {
+			return false
+		}
+	}
+	return true
+}
+
+// sortPublicIPsByField sorts public IPs by the given field: Name, IPAddress or Tag:<key>
+func sortPublicIPsByField(addresses []*armnetwork.PublicIPAddress, sortBy string) {
+	if strings.HasPrefix(sortBy, azureTagOrderPrefix) {
+		key := strings.TrimPrefix(sortBy, azureTagOrderPrefix)
+		sort.SliceStable(addresses, func(i, j int) bool {
+			return stringOrEmpty(addresses[i].Tags[key]) < stringOrEmpty(addresses[j].Tags[key])
+		})
+		return
+	}
+	switch sortBy {
+	case "Name":
+		sort.SliceStable(addresses, func(i, j int) bool {
+			return stringOrEmpty(addresses[i].Name) < stringOrEmpty(addresses[j].Name)
+		})
+	case "IPAddress":
+		sort.SliceStable(addresses, func(i, j int) bool {
+			return stringOrEmpty(addresses[i].Properties.IPAddress) < stringOrEmpty(addresses[j].Properties.IPAddress)
+		})
+	}
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// MatchFilter returns true if the public IP address matches the filter.
+func (a *azureAssigner) MatchFilter(ctx context.Context, address string, filter []string) (bool, error) {
+	filters, err := parseAzureFilters(filter)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to parse filter")
+	}
+	addresses, err := a.listPublicIPs(ctx, filters, "")
+	if err != nil {
+		return false, err
+	}
+	for _, pip := range addresses {
+		if stringOrEmpty(pip.Properties.IPAddress) == address {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetResourceID returns the resource ID of the public IP address.
+func (a *azureAssigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	addresses, err := a.publicIPLister.List(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list public IP addresses")
+	}
+	for _, pip := range addresses {
+		if pip.Properties != nil && stringOrEmpty(pip.Properties.IPAddress) == address {
+			return stringOrEmpty(pip.ID), nil
+		}
+	}
+	return "", ErrStaticIPNotFound
+}
+
+// IsAssigned returns true if the static public IP is still attached to the IP configuration of the VM network interface.
+func (a *azureAssigner) IsAssigned(ctx context.Context, instanceID, _, address string) (bool, error) {
+	nic, err := a.nicGetter.Get(ctx, instanceID)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get network interface of instance %s", instanceID)
+	}
+	ipConfig, err := getIPConfiguration(nic, a.ipv6)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get IP configuration of instance %s", instanceID)
+	}
+	attached, err := a.getAttachedStaticPublicIP(ctx, ipConfig)
+	if errors.Is(err, ErrNoStaticIPAssigned) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return stringOrEmpty(attached.Properties.IPAddress) == address, nil
+}
@@ -0,0 +1,724 @@
+package address
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	"github.com/doitintl/kubeip/internal/cloud"
+	cmocks "github.com/doitintl/kubeip/mocks/cloud"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testAzureVMID     = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm-0"
+	testAzureNicID    = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-0-nic"
+	testAzurePrefixID = "/subscriptions/sub/resourceGroups/ip-rg/providers/Microsoft.Network/publicIPPrefixes/prefix"
+)
+
+func testAzurePrefixPublicIP(name, ip string, attached bool) *armnetwork.PublicIPAddress {
+	address := testAzurePublicIP(name, ip, map[string]*string{azureAllocatedTag: to.Ptr("true")}, attached)
+	address.Properties.PublicIPPrefix = &armnetwork.SubResource{ID: to.Ptr(testAzurePrefixID)}
+	return address
+}
+
+func testAzurePublicIP(name, ip string, tags map[string]*string, attached bool) *armnetwork.PublicIPAddress {
+	address := &armnetwork.PublicIPAddress{
+		ID:       to.Ptr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/" + name),
+		Name:     to.Ptr(name),
+		Location: to.Ptr("westeurope"),
+		Tags:     tags,
+		SKU:      &armnetwork.PublicIPAddressSKU{Name: to.Ptr(armnetwork.PublicIPAddressSKUNameStandard)},
+		Properties: &armnetwork.PublicIPAddressPropertiesFormat{
+			IPAddress:              to.Ptr(ip),
+			PublicIPAddressVersion: to.Ptr(armnetwork.IPVersionIPv4),
+		},
+	}
+	if attached {
+		address.Properties.IPConfiguration = &armnetwork.IPConfiguration{ID: to.Ptr("other-ip-config")}
+	}
+	return address
+}
+
+func testAzureNic(publicIPID *string) *armnetwork.Interface {
+	ipConfig := &armnetwork.InterfaceIPConfiguration{
+		Name: to.Ptr("ipconfig1"),
+		Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{
+			Primary: to.Ptr(true),
+		},
+	}
+	if publicIPID != nil {
+		ipConfig.Properties.PublicIPAddress = &armnetwork.PublicIPAddress{ID: publicIPID}
+	}
+	return &armnetwork.Interface{
+		ID: to.Ptr(testAzureNicID),
+		Properties: &armnetwork.InterfacePropertiesFormat{
+			IPConfigurations: []*armnetwork.InterfaceIPConfiguration{ipConfig},
+		},
+	}
+}
+
+func testAzureAssignedNic(publicIPID *string) *armnetwork.Interface {
+	nic := testAzureNic(publicIPID)
+	nic.Tags = map[string]*string{azureAssignedTag: publicIPID}
+	return nic
+}
+
+func Test_azureAssigner_Assign(t *testing.T) {
+	type args struct {
+		instanceID string
+		filter     []string
+		orderBy    string
+	}
+	type fields struct {
+		deletePrefixIP   bool
+		nicGetterFn      func(t *testing.T) cloud.AzureInterfaceGetter
+		nicUpdaterFn     func(t *testing.T) cloud.AzureInterfaceUpdater
+		publicIPListerFn func(t *testing.T) cloud.AzurePublicIPLister
+		prefixManagerFn  func(t *testing.T) cloud.AzurePublicIPPrefixManager
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			name: "assign first available public IP",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.MatchedBy(func(nic *armnetwork.Interface) bool {
+						pip := nic.Properties.IPConfigurations[0].Properties.PublicIPAddress
+						id := *testAzurePublicIP("pip-a", "1.1.1.1", nil, false).ID
+						return pip != nil && *pip.ID == id && *nic.Tags[azureAssignedTag] == id
+					})).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-b", "2.2.2.2", map[string]*string{"env": to.Ptr("test")}, false),
+						testAzurePublicIP("pip-c", "3.3.3.3", map[string]*string{"env": to.Ptr("test")}, true),
+						testAzurePublicIP("pip-a", "1.1.1.1", map[string]*string{"env": to.Ptr("test")}, false),
+						testAzurePublicIP("pip-d", "4.4.4.4", map[string]*string{"env": to.Ptr("prod")}, false),
+					}, nil).Once()
+					mockLister.EXPECT().Get(mock.Anything, *testAzurePublicIP("pip-a", "1.1.1.1", nil, false).ID).
+						Return(testAzurePublicIP("pip-a", "1.1.1.1", nil, false), nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+				filter:     []string{"tags.env=test"},
+				orderBy:    "Name",
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name: "public IP already assigned",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureAssignedNic(testAzurePublicIP("pip-a", "1.1.1.1", nil, true).ID), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, true),
+					}, nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+			},
+			want:    "1.1.1.1",
+			wantErr: ErrStaticIPAlreadyAssigned,
+		},
+		{
+			name: "tag public IP already assigned by previous version",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(testAzurePublicIP("pip-a", "1.1.1.1", nil, true).ID), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.MatchedBy(func(nic *armnetwork.Interface) bool {
+						return *nic.Tags[azureAssignedTag] == *testAzurePublicIP("pip-a", "1.1.1.1", nil, true).ID
+					})).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, true),
+					}, nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+			},
+			want:    "1.1.1.1",
+			wantErr: ErrStaticIPAlreadyAssigned,
+		},
+		{
+			name: "skip address assigned by another agent",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, false),
+						testAzurePublicIP("pip-b", "2.2.2.2", nil, false),
+					}, nil).Once()
+					mockLister.EXPECT().Get(mock.Anything, *testAzurePublicIP("pip-a", "1.1.1.1", nil, false).ID).
+						Return(testAzurePublicIP("pip-a", "1.1.1.1", nil, true), nil).Once()
+					mockLister.EXPECT().Get(mock.Anything, *testAzurePublicIP("pip-b", "2.2.2.2", nil, false).ID).
+						Return(testAzurePublicIP("pip-b", "2.2.2.2", nil, false), nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+				orderBy:    "IPAddress",
+			},
+			want: "2.2.2.2",
+		},
+		{
+			name: "no available public IPs",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, true),
+					}, nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+			},
+			wantErr: errors.New("no available public IPs"),
+		},
+		{
+			name: "failed to update network interface",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.Anything).Return(errors.New("error")).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, false),
+					}, nil).Once()
+					mockLister.EXPECT().Get(mock.Anything, mock.Anything).Return(testAzurePublicIP("pip-a", "1.1.1.1", nil, false), nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+			},
+			wantErr: errors.New("failed to assign public IP: failed to attach public IP 1.1.1.1: error"),
+		},
+		{
+			name: "reuse free public IP from prefix",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, false),
+						testAzurePrefixPublicIP("kubeip-vm-1", "5.5.5.5", false),
+					}, nil).Once()
+					mockLister.EXPECT().Get(mock.Anything, *testAzurePrefixPublicIP("kubeip-vm-1", "5.5.5.5", false).ID).
+						Return(testAzurePrefixPublicIP("kubeip-vm-1", "5.5.5.5", false), nil).Once()
+					return mockLister
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+				filter:     []string{"publicIPPrefix=" + testAzurePrefixID},
+			},
+			want: "5.5.5.5",
+		},
+		{
+			name: "allocate new public IP from prefix",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return([]*armnetwork.PublicIPAddress{
+						testAzurePublicIP("pip-a", "1.1.1.1", nil, false),
+						testAzurePrefixPublicIP("kubeip-vm-1", "5.5.5.5", true),
+					}, nil).Once()
+					mockLister.EXPECT().Get(mock.Anything, *testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.6", false).ID).
+						Return(testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.6", false), nil).Once()
+					return mockLister
+				},
+				prefixManagerFn: func(t *testing.T) cloud.AzurePublicIPPrefixManager {
+					mockManager := cmocks.NewAzurePublicIPPrefixManager(t)
+					mockManager.EXPECT().GetPrefix(mock.Anything, testAzurePrefixID).Return(&armnetwork.PublicIPPrefix{
+						ID:       to.Ptr(testAzurePrefixID),
+						Location: to.Ptr("westeurope"),
+					}, nil).Once()
+					mockManager.EXPECT().CreatePublicIP(mock.Anything, "ip-rg", "kubeip-vm-0", mock.MatchedBy(func(address *armnetwork.PublicIPAddress) bool {
+						return *address.Properties.PublicIPPrefix.ID == testAzurePrefixID &&
+							*address.Tags[azureAllocatedTag] == "true" && *address.Tags["env"] == "test"
+					})).Return(testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.6", false), nil).Once()
+					return mockManager
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+				filter:     []string{"publicIPPrefix=" + testAzurePrefixID, "tags.env=test"},
+			},
+			want: "5.5.5.6",
+		},
+		{
+			name: "failed to allocate public IP from exhausted prefix",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().List(mock.Anything).Return(nil, nil).Once()
+					return mockLister
+				},
+				prefixManagerFn: func(t *testing.T) cloud.AzurePublicIPPrefixManager {
+					mockManager := cmocks.NewAzurePublicIPPrefixManager(t)
+					mockManager.EXPECT().GetPrefix(mock.Anything, testAzurePrefixID).Return(&armnetwork.PublicIPPrefix{
+						ID:       to.Ptr(testAzurePrefixID),
+						Location: to.Ptr("westeurope"),
+					}, nil).Once()
+					mockManager.EXPECT().CreatePublicIP(mock.Anything, "ip-rg", "kubeip-vm-0", mock.Anything).Return(nil, errors.New("prefix exhausted")).Once()
+					return mockManager
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+				filter:     []string{"publicIPPrefix=" + testAzurePrefixID},
+			},
+			wantErr: errors.New("failed to allocate public IP from prefix " + testAzurePrefixID + ": failed to create public IP: prefix exhausted"),
+		},
+		{
+			name: "failed to get network interface",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(nil, errors.New("error")).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					return cmocks.NewAzurePublicIPLister(t)
+				},
+			},
+			args: args{
+				instanceID: testAzureVMID,
+			},
+			wantErr: errors.New("failed to get network interface of instance " + testAzureVMID + ": error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &azureAssigner{
+				logger:         logrus.NewEntry(logrus.New()),
+				location:       "westeurope",
+				deletePrefixIP: tt.fields.deletePrefixIP,
+				nicGetter:      tt.fields.nicGetterFn(t),
+				nicUpdater:     tt.fields.nicUpdaterFn(t),
+				publicIPLister: tt.fields.publicIPListerFn(t),
+				prefixManager:  cmocks.NewAzurePublicIPPrefixManager(t),
+			}
+			if tt.fields.prefixManagerFn != nil {
+				a.prefixManager = tt.fields.prefixManagerFn(t)
+			}
+			got, err := a.Assign(context.Background(), tt.args.instanceID, "", tt.args.filter, tt.args.orderBy)
+			if !matchErr(err, tt.wantErr) {
+				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Assign() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_azureAssigner_Assign_dualStackPrefix(t *testing.T) {
+	ipv4Address := testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.6", false)
+	ipv6Address := testAzurePrefixPublicIP("kubeip-vm-0-ipv6", "2001:db8::6", false)
+	ipv6Address.Properties.PublicIPAddressVersion = to.Ptr(armnetwork.IPVersionIPv6)
+	nic := testAzureNic(nil)
+	nic.Properties.IPConfigurations = append(nic.Properties.IPConfigurations, &armnetwork.InterfaceIPConfiguration{
+		Name: to.Ptr("ipconfig-v6"),
+		Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{
+			PrivateIPAddressVersion: to.Ptr(armnetwork.IPVersionIPv6),
+		},
+	})
+
+	mockGetter := cmocks.NewAzureInterfaceGetter(t)
+	mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(nic, nil).Twice()
+	mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+	mockUpdater.EXPECT().Update(mock.Anything, nic).Return(nil).Twice()
+	mockLister := cmocks.NewAzurePublicIPLister(t)
+	mockLister.EXPECT().List(mock.Anything).Return(nil, nil).Twice()
+	mockLister.EXPECT().Get(mock.Anything, *ipv4Address.ID).Return(ipv4Address, nil).Once()
+	mockLister.EXPECT().Get(mock.Anything, *ipv6Address.ID).Return(ipv6Address, nil).Once()
+	mockManager := cmocks.NewAzurePublicIPPrefixManager(t)
+	mockManager.EXPECT().GetPrefix(mock.Anything, testAzurePrefixID).Return(&armnetwork.PublicIPPrefix{
+		ID:       to.Ptr(testAzurePrefixID),
+		Location: to.Ptr("westeurope"),
+	}, nil).Twice()
+	mockManager.EXPECT().CreatePublicIP(mock.Anything, "ip-rg", "kubeip-vm-0", mock.MatchedBy(func(address *armnetwork.PublicIPAddress) bool {
+		return *address.Properties.PublicIPAddressVersion == armnetwork.IPVersionIPv4
+	})).Return(ipv4Address, nil).Once()
+	mockManager.EXPECT().CreatePublicIP(mock.Anything, "ip-rg", "kubeip-vm-0-ipv6", mock.MatchedBy(func(address *armnetwork.PublicIPAddress) bool {
+		return *address.Properties.PublicIPAddressVersion == armnetwork.IPVersionIPv6
+	})).Return(ipv6Address, nil).Once()
+
+	newAssigner := func(ipv6 bool) *azureAssigner {
+		return &azureAssigner{
+			logger:         logrus.NewEntry(logrus.New()),
+			location:       "westeurope",
+			ipv6:           ipv6,
+			nicGetter:      mockGetter,
+			nicUpdater:     mockUpdater,
+			publicIPLister: mockLister,
+			prefixManager:  mockManager,
+		}
+	}
+	a := &dualStackAssigner{ipv4: newAssigner(false), ipv6: newAssigner(true), logger: logrus.NewEntry(logrus.New())}
+	got, err := a.Assign(context.Background(), testAzureVMID, "", []string{"publicIPPrefix=" + testAzurePrefixID}, "")
+	if err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if got != "5.5.5.6,2001:db8::6" {
+		t.Errorf("Assign() got = %v, want %v", got, "5.5.5.6,2001:db8::6")
+	}
+	for i, want := range []*string{ipv4Address.ID, ipv6Address.ID} {
+		if attached := nic.Properties.IPConfigurations[i].Properties.PublicIPAddress; attached == nil || *attached.ID != *want {
+			t.Errorf("IP configuration %d public IP = %v, want %v", i, attached, *want)
+		}
+	}
+}
+
+func Test_azureAssigner_Unassign(t *testing.T) {
+	type fields struct {
+		deletePrefixIP   bool
+		nicGetterFn      func(t *testing.T) cloud.AzureInterfaceGetter
+		nicUpdaterFn     func(t *testing.T) cloud.AzureInterfaceUpdater
+		publicIPListerFn func(t *testing.T) cloud.AzurePublicIPLister
+		prefixManagerFn  func(t *testing.T) cloud.AzurePublicIPPrefixManager
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		wantErr error
+	}{
+		{
+			name: "detach static public IP",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureAssignedNic(testAzurePublicIP("pip-a", "1.1.1.1", nil, true).ID), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.MatchedBy(func(nic *armnetwork.Interface) bool {
+						_, tagged := nic.Tags[azureAssignedTag]
+						return nic.Properties.IPConfigurations[0].Properties.PublicIPAddress == nil && !tagged
+					})).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().Get(mock.Anything, mock.Anything).Return(testAzurePublicIP("pip-a", "1.1.1.1", nil, true), nil).Once()
+					return mockLister
+				},
+			},
+		},
+		{
+			name: "public IP not assigned by kubeip is not released",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(testAzurePublicIP("pip-a", "1.1.1.1", nil, true).ID), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().Get(mock.Anything, mock.Anything).Return(testAzurePublicIP("pip-a", "1.1.1.1", nil, true), nil).Once()
+					return mockLister
+				},
+			},
+			wantErr: ErrNoStaticIPAssigned,
+		},
+		{
+			name: "detach and delete public IP allocated from prefix",
+			fields: fields{
+				deletePrefixIP: true,
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.5", true).ID), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().Get(mock.Anything, mock.Anything).Return(testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.5", true), nil).Once()
+					return mockLister
+				},
+				prefixManagerFn: func(t *testing.T) cloud.AzurePublicIPPrefixManager {
+					mockManager := cmocks.NewAzurePublicIPPrefixManager(t)
+					mockManager.EXPECT().DeletePublicIP(mock.Anything, *testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.5", true).ID).Return(nil).Once()
+					return mockManager
+				},
+			},
+		},
+		{
+			name: "keep public IP allocated from prefix when delete is disabled",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.5", true).ID), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					mockUpdater := cmocks.NewAzureInterfaceUpdater(t)
+					mockUpdater.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+					return mockUpdater
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().Get(mock.Anything, mock.Anything).Return(testAzurePrefixPublicIP("kubeip-vm-0", "5.5.5.5", true), nil).Once()
+					return mockLister
+				},
+			},
+		},
+		{
+			name: "no public IP attached",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(nil), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					return cmocks.NewAzurePublicIPLister(t)
+				},
+			},
+			wantErr: ErrNoStaticIPAssigned,
+		},
+		{
+			name: "basic SKU public IP is not released",
+			fields: fields{
+				nicGetterFn: func(t *testing.T) cloud.AzureInterfaceGetter {
+					mockGetter := cmocks.NewAzureInterfaceGetter(t)
+					mockGetter.EXPECT().Get(mock.Anything, testAzureVMID).Return(testAzureNic(to.Ptr("basic-pip")), nil).Once()
+					return mockGetter
+				},
+				nicUpdaterFn: func(t *testing.T) cloud.AzureInterfaceUpdater {
+					return cmocks.NewAzureInterfaceUpdater(t)
+				},
+				publicIPListerFn: func(t *testing.T) cloud.AzurePublicIPLister {
+					mockLister := cmocks.NewAzurePublicIPLister(t)
+					mockLister.EXPECT().Get(mock.Anything, "basic-pip").Return(&armnetwork.PublicIPAddress{
+						ID:  to.Ptr("basic-pip"),
+						SKU: &armnetwork.PublicIPAddressSKU{Name: to.Ptr(armnetwork.PublicIPAddressSKUNameBasic)},
+					}, nil).Once()
+					return mockLister
+				},
+			},
+			wantErr: ErrNoStaticIPAssigned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &azureAssigner{
+				logger:         logrus.NewEntry(logrus.New()),
+				deletePrefixIP: tt.fields.deletePrefixIP,
+				nicGetter:      tt.fields.nicGetterFn(t),
+				nicUpdater:     tt.fields.nicUpdaterFn(t),
+				publicIPLister: tt.fields.publicIPListerFn(t),
+				prefixManager:  cmocks.NewAzurePublicIPPrefixManager(t),
+			}
+			if tt.fields.prefixManagerFn != nil {
+				a.prefixManager = tt.fields.prefixManagerFn(t)
+			}
+			err := a.Unassign(context.Background(), testAzureVMID, "")
+			if !matchErr(err, tt.wantErr) {
+				t.Errorf("Unassign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_azureAssigner_GetResourceID(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "get public IP resource ID",
+			address: "2.2.2.2",
+			want:    *testAzurePublicIP("pip-b", "2.2.2.2", nil, true).ID,
+		},
+		{
+			name:    "public IP not found",
+			address: "3.3.3.3",
+			wantErr: ErrStaticIPNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := cmocks.NewAzurePublicIPLister(t)
+			lister.EXPECT().List(context.Background()).Return([]*armnetwork.PublicIPAddress{
+				testAzurePublicIP("pip-a", "1.1.1.1", nil, false),
+				testAzurePublicIP("pip-b", "2.2.2.2", nil, true),
+			}, nil).Once()
+			a := &azureAssigner{publicIPLister: lister}
+			got, err := a.GetResourceID(context.Background(), tt.address)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetResourceID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetResourceID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseAzureFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  []string
+		want    *azureFilters
+		wantErr error
+	}{
+		{
+			name:   "no filter",
+			filter: nil,
+			want:   &azureFilters{Tags: map[string]string{}},
+		},
+		{
+			name:   "valid filters",
+			filter: []string{"tags.env=dev", "tags.app=streamer"},
+			want:   &azureFilters{Tags: map[string]string{"env": "dev", "app": "streamer"}},
+		},
+		{
+			name:   "public IP prefix filter",
+			filter: []string{"publicIPPrefix=" + testAzurePrefixID, "tags.env=dev"},
+			want:   &azureFilters{Tags: map[string]string{"env": "dev"}, PublicIPPrefix: testAzurePrefixID},
+		},
+		{
+			name:    "empty public IP prefix",
+			filter:  []string{"publicIPPrefix="},
+			wantErr: errors.New("invalid filter format for Azure, public IP prefix resource ID is empty"),
+		},
+		{
+			name:    "invalid prefix",
+			filter:  []string{"labels.env=dev"},
+			wantErr: errors.New("invalid filter format for Azure, should be in format tags.key=value or publicIPPrefix=<id>, found: labels.env=dev"),
+		},
+		{
+			name:    "missing value",
+			filter:  []string{"tags.env"},
+			wantErr: errors.New("invalid filter format for Azure, should be in format tags.key=value or publicIPPrefix=<id>, found: tags.env"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAzureFilters(tt.filter)
+			if !matchErr(err, tt.wantErr) {
+				t.Errorf("parseAzureFilters() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAzureFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
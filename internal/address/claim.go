@@ -0,0 +1,65 @@
+package address
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// claimKey is the label or tag key of the cloud side claim of an address, set to the claiming instance
+	claimKey = "kubeip-claim"
+	// claimTimeKey is the label or tag key of the time of the claim, in seconds since the epoch
+	claimTimeKey = "kubeip-claim-time"
+	// claimTTL is the time after which a claim left by a crashed holder is stale and can be taken over
+	claimTTL = 2 * defaultTimeout
+)
+
+// claimable returns true if the address labels or tags hold no claim, a claim of the holder or a stale claim
+func claimable(tags map[string]string, holder string, now time.Time) bool {
+	claimer, ok := tags[claimKey]
+	if !ok || claimer == holder {
+		return true
+	}
+	seconds, err := strconv.ParseInt(tags[claimTimeKey], 10, 64)
+	if err != nil {
+		// a claim without a valid time is stale
+		return true
+	}
+	return now.Sub(time.Unix(seconds, 0)) > claimTTL
+}
+
+// claimedBy returns true if the address labels or tags hold a claim of the holder
+func claimedBy(tags map[string]string, holder string) bool {
+	return tags[claimKey] == holder
+}
+
+// claimTags returns the claim labels or tags of the holder
+func claimTags(holder string, now time.Time) map[string]string {
+	return map[string]string{
+		claimKey:     holder,
+		claimTimeKey: strconv.FormatInt(now.Unix(), 10),
+	}
+}
+
+// withClaim returns a copy of the labels or tags with the claim of the holder
+func withClaim(tags map[string]string, holder string, now time.Time) map[string]string {
+	claimed := make(map[string]string, len(tags)+2) //nolint:gomnd
+	for k, v := range tags {
+		claimed[k] = v
+	}
+	for k, v := range claimTags(holder, now) {
+		claimed[k] = v
+	}
+	return claimed
+}
+
+// withoutClaim returns a copy of the labels or tags without the claim
+func withoutClaim(tags map[string]string) map[string]string {
+	released := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if k != claimKey && k != claimTimeKey {
+			released[k] = v
+		}
+	}
+	return released
+}
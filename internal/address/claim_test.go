@@ -0,0 +1,64 @@
+package address
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_claimable(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		tags map[string]string
+		want bool
+	}{
+		{
+			name: "no claim",
+			tags: map[string]string{"env": "prod"},
+			want: true,
+		},
+		{
+			name: "claimed by the holder",
+			tags: claimTags("holder", now),
+			want: true,
+		},
+		{
+			name: "claimed by another holder",
+			tags: claimTags("another-holder", now.Add(-time.Minute)),
+		},
+		{
+			name: "stale claim of another holder",
+			tags: claimTags("another-holder", now.Add(-claimTTL-time.Minute)),
+			want: true,
+		},
+		{
+			name: "claim without time",
+			tags: map[string]string{claimKey: "another-holder", claimTimeKey: "yesterday"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := claimable(tt.tags, "holder", now); got != tt.want {
+				t.Errorf("claimable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withClaim(t *testing.T) {
+	now := time.Now()
+	tags := map[string]string{"env": "prod"}
+	claimed := withClaim(tags, "holder", now)
+	if !claimedBy(claimed, "holder") || claimed["env"] != "prod" || claimed[claimTimeKey] != strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("withClaim() = %v", claimed)
+	}
+	if len(tags) != 1 {
+		t.Errorf("withClaim() changed the tags: %v", tags)
+	}
+	released := withoutClaim(claimed)
+	if claimedBy(released, "holder") || len(released) != 1 || released["env"] != "prod" {
+		t.Errorf("withoutClaim() = %v", released)
+	}
+}
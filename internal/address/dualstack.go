@@ -0,0 +1,166 @@
+package address
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// addressSeparator separates the addresses reported by a dual-stack or multi-address assigner
+const addressSeparator = ","
+
+// SplitAddresses returns the addresses reported by an assigner: a single address, the IPv4 and IPv6 addresses of a
+// dual-stack assigner, or the addresses of a multi-address assigner.
+func SplitAddresses(address string) []string {
+	if address == "" {
+		return nil
+	}
+	return strings.Split(address, addressSeparator)
+}
+
+// Reported returns true if the external IPs of the node include every address reported by an assigner.
+func Reported(externalIPs []net.IP, address string) bool {
+	addresses := SplitAddresses(address)
+	if len(addresses) == 0 {
+		return false
+	}
+	for _, a := range addresses {
+		ip := net.ParseIP(a)
+		found := false
+		for _, externalIP := range externalIPs {
+			if externalIP.Equal(ip) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// dualStackAssigner assigns a static public IPv4 and IPv6 address to the instance, using one assigner per IP family
+type dualStackAssigner struct {
+	ipv4   Assigner
+	ipv6   Assigner
+	logger *logrus.Entry
+}
+
+func newDualStackAssigner(ctx context.Context, logger *logrus.Entry, provider types.CloudProvider, cfg *config.Config) (Assigner, error) {
+	if provider != types.CloudProviderGCP && provider != types.CloudProviderAzure && provider != types.CloudProviderAWS {
+		return nil, errors.Errorf("dual-stack is not supported on %s", provider)
+	}
+	ipv4Cfg, ipv6Cfg := *cfg, *cfg
+	ipv4Cfg.IPv6, ipv6Cfg.IPv6 = false, true
+	ipv4, err := newSingleStackAssigner(ctx, logger, provider, &ipv4Cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create IPv4 assigner")
+	}
+	ipv6, err := newSingleStackAssigner(ctx, logger, provider, &ipv6Cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create IPv6 assigner")
+	}
+	return &dualStackAssigner{ipv4: ipv4, ipv6: ipv6, logger: logger}, nil
+}
+
+// Assign assigns a static public IPv4 and then IPv6 address to the instance; if the IPv6 assignment fails, the IPv4
+// address is released, so the instance holds both families or none. Returns both addresses separated by a comma.
+func (a *dualStackAssigner) Assign(ctx context.Context, instanceID, zone string, filter []string, orderBy string) (string, error) {
+	ipv4Address, err := a.ipv4.Assign(ctx, instanceID, zone, filter, orderBy)
+	ipv4Assigned := errors.Is(err, ErrStaticIPAlreadyAssigned)
+	if err != nil && !ipv4Assigned {
+		return "", errors.Wrap(err, "failed to assign static public IPv4 address")
+	}
+
+	ipv6Address, err := a.ipv6.Assign(ctx, instanceID, zone, filter, orderBy)
+	ipv6Assigned := errors.Is(err, ErrStaticIPAlreadyAssigned)
+	if err != nil && !ipv6Assigned {
+		// roll back the IPv4 assignment, unless the instance already held the IPv4 address before this call
+		if !ipv4Assigned {
+			if rollbackErr := a.ipv4.Unassign(ctx, instanceID, zone); rollbackErr != nil {
+				a.logger.WithError(rollbackErr).WithField("address", ipv4Address).Error("failed to release static public IPv4 address after IPv6 assignment failure")
+			}
+		}
+		return "", errors.Wrap(err, "failed to assign static public IPv6 address")
+	}
+
+	addresses := ipv4Address + addressSeparator + ipv6Address
+	if ipv4Assigned && ipv6Assigned {
+		return addresses, ErrStaticIPAlreadyAssigned
+	}
+	return addresses, nil
+}
+
+// Unassign releases both the static public IPv4 and IPv6 addresses of the instance.
+func (a *dualStackAssigner) Unassign(ctx context.Context, instanceID, zone string) error {
+	ipv4Err := a.ipv4.Unassign(ctx, instanceID, zone)
+	ipv6Err := a.ipv6.Unassign(ctx, instanceID, zone)
+	if errors.Is(ipv4Err, ErrNoStaticIPAssigned) && errors.Is(ipv6Err, ErrNoStaticIPAssigned) {
+		return ErrNoStaticIPAssigned
+	}
+	if ipv4Err != nil && !errors.Is(ipv4Err, ErrNoStaticIPAssigned) {
+		return errors.Wrap(ipv4Err, "failed to release static public IPv4 address")
+	}
+	if ipv6Err != nil && !errors.Is(ipv6Err, ErrNoStaticIPAssigned) {
+		return errors.Wrap(ipv6Err, "failed to release static public IPv6 address")
+	}
+	return nil
+}
+
+// GetResourceID returns the resource IDs of the IPv4 and IPv6 addresses, separated by a comma.
+func (a *dualStackAssigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	addresses := SplitAddresses(address)
+	resourceIDs := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		assigner := a.ipv4
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+			assigner = a.ipv6
+		}
+		resourceID, err := LookupResourceID(ctx, assigner, addr)
+		if err != nil {
+			return "", err
+		}
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	return strings.Join(resourceIDs, addressSeparator), nil
+}
+
+// MatchFilter returns true if both the IPv4 and IPv6 addresses match the filter.
+func (a *dualStackAssigner) MatchFilter(ctx context.Context, address string, filter []string) (bool, error) {
+	for _, addr := range SplitAddresses(address) {
+		assigner := a.ipv4
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+			assigner = a.ipv6
+		}
+		ok, err := MatchFilter(ctx, assigner, addr, filter)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// IsAssigned returns true if both the IPv4 and IPv6 addresses are still assigned to the instance.
+func (a *dualStackAssigner) IsAssigned(ctx context.Context, instanceID, zone, address string) (bool, error) {
+	addresses := SplitAddresses(address)
+	if len(addresses) == 0 {
+		return false, nil
+	}
+	for _, addr := range addresses {
+		assigner := a.ipv4
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+			assigner = a.ipv6
+		}
+		ok, err := IsAssigned(ctx, assigner, instanceID, zone, addr)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
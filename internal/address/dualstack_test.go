@@ -0,0 +1,220 @@
+package address
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	amock "github.com/doitintl/kubeip/mocks/address"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func Test_dualStackAssigner_Assign(t *testing.T) {
+	type args struct {
+		ipv4Fn func(t *testing.T) Assigner
+		ipv6Fn func(t *testing.T) Assigner
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr error
+	}{
+		{
+			name: "assign IPv4 and IPv6 addresses",
+			args: args{
+				ipv4Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("100.0.0.1", nil)
+					return mock
+				},
+				ipv6Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("2600:1900::1", nil)
+					return mock
+				},
+			},
+			want: "100.0.0.1,2600:1900::1",
+		},
+		{
+			name: "IPv4 and IPv6 addresses already assigned",
+			args: args{
+				ipv4Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("100.0.0.1", ErrStaticIPAlreadyAssigned)
+					return mock
+				},
+				ipv6Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("2600:1900::1", ErrStaticIPAlreadyAssigned)
+					return mock
+				},
+			},
+			want:    "100.0.0.1,2600:1900::1",
+			wantErr: ErrStaticIPAlreadyAssigned,
+		},
+		{
+			name: "only IPv4 address already assigned",
+			args: args{
+				ipv4Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("100.0.0.1", ErrStaticIPAlreadyAssigned)
+					return mock
+				},
+				ipv6Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("2600:1900::1", nil)
+					return mock
+				},
+			},
+			want: "100.0.0.1,2600:1900::1",
+		},
+		{
+			name: "fail to assign IPv4 address",
+			args: args{
+				ipv4Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("", ErrNoAvailableStaticIP)
+					return mock
+				},
+				ipv6Fn: func(t *testing.T) Assigner {
+					return amock.NewAssigner(t)
+				},
+			},
+			wantErr: ErrNoAvailableStaticIP,
+		},
+		{
+			name: "roll back IPv4 address when failing to assign IPv6 address",
+			args: args{
+				ipv4Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("100.0.0.1", nil)
+					mock.EXPECT().Unassign(context.TODO(), "instance-1", "zone-1").Return(nil)
+					return mock
+				},
+				ipv6Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("", ErrNoAvailableStaticIP)
+					return mock
+				},
+			},
+			wantErr: ErrNoAvailableStaticIP,
+		},
+		{
+			name: "keep IPv4 address already assigned when failing to assign IPv6 address",
+			args: args{
+				ipv4Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("100.0.0.1", ErrStaticIPAlreadyAssigned)
+					return mock
+				},
+				ipv6Fn: func(t *testing.T) Assigner {
+					mock := amock.NewAssigner(t)
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return("", ErrNoAvailableStaticIP)
+					return mock
+				},
+			},
+			wantErr: ErrNoAvailableStaticIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &dualStackAssigner{
+				ipv4:   tt.args.ipv4Fn(t),
+				ipv6:   tt.args.ipv6Fn(t),
+				logger: logrus.NewEntry(logrus.New()),
+			}
+			got, err := a.Assign(context.TODO(), "instance-1", "zone-1", nil, "")
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Assign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dualStackAssigner_Unassign(t *testing.T) {
+	tests := []struct {
+		name    string
+		ipv4Err error
+		ipv6Err error
+		wantErr error
+	}{
+		{
+			name: "release IPv4 and IPv6 addresses",
+		},
+		{
+			name:    "no address assigned",
+			ipv4Err: ErrNoStaticIPAssigned,
+			ipv6Err: ErrNoStaticIPAssigned,
+			wantErr: ErrNoStaticIPAssigned,
+		},
+		{
+			name:    "only IPv6 address assigned",
+			ipv4Err: ErrNoStaticIPAssigned,
+		},
+		{
+			name:    "fail to release IPv6 address",
+			ipv6Err: errors.New("error"),
+			wantErr: errors.New("failed to release static public IPv6 address: error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ipv4 := amock.NewAssigner(t)
+			ipv4.EXPECT().Unassign(context.TODO(), "instance-1", "zone-1").Return(tt.ipv4Err)
+			ipv6 := amock.NewAssigner(t)
+			ipv6.EXPECT().Unassign(context.TODO(), "instance-1", "zone-1").Return(tt.ipv6Err)
+			a := &dualStackAssigner{ipv4: ipv4, ipv6: ipv6, logger: logrus.NewEntry(logrus.New())}
+			err := a.Unassign(context.TODO(), "instance-1", "zone-1")
+			if (err != nil) != (tt.wantErr != nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("Unassign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReported(t *testing.T) {
+	externalIPs := []net.IP{net.ParseIP("100.0.0.1"), net.ParseIP("2600:1900::1")}
+	tests := []struct {
+		name    string
+		address string
+		want    []string
+		ok      bool
+	}{
+		{
+			name:    "single address reported",
+			address: "100.0.0.1",
+			want:    []string{"100.0.0.1"},
+			ok:      true,
+		},
+		{
+			name:    "dual-stack addresses reported",
+			address: "100.0.0.1,2600:1900::1",
+			want:    []string{"100.0.0.1", "2600:1900::1"},
+			ok:      true,
+		},
+		{
+			name:    "IPv6 address not reported",
+			address: "100.0.0.1,2600:1900::2",
+			want:    []string{"100.0.0.1", "2600:1900::2"},
+		},
+		{
+			name: "no address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitAddresses(tt.address); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitAddresses() = %v, want %v", got, tt.want)
+			}
+			if got := Reported(externalIPs, tt.address); got != tt.ok {
+				t.Errorf("Reported() = %v, want %v", got, tt.ok)
+			}
+		})
+	}
+}
@@ -3,14 +3,23 @@ package address
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/compute/metadata"
 	"github.com/doitintl/kubeip/internal/cloud"
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
+	"github.com/doitintl/kubeip/internal/types"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -46,7 +55,11 @@ type gcpAssigner struct {
 	project        string
 	region         string
 	ipv6           bool
-	logger         *logrus.Entry
+	// networkInterface selects the network interface of the instance receiving the static public IP address
+	networkInterface types.NetworkInterfaceSelector
+	// cloudClaims claims each address with its labels before assigning it, instead of relying on a lease lock
+	cloudClaims bool
+	logger      *logrus.Entry
 }
 
 type operationError struct {
@@ -81,7 +94,12 @@ func (e *operationError) Error() string {
 	return fmt.Sprintf("operation %s failed with error %v", e.name, joinErrorMessages(e.err))
 }
 
-func NewGCPAssigner(ctx context.Context, logger *logrus.Entry, project, region string, ipv6 bool) (Assigner, error) {
+func NewGCPAssigner(ctx context.Context, logger *logrus.Entry, cfg *config.Config) (Assigner, error) {
+	// GCP network interfaces have no tags
+	if cfg.NetworkInterface.Kind == types.NetworkInterfaceByTag {
+		return nil, errors.New("network interface tag selector is not supported on GCP")
+	}
+
 	// initialize Google Cloud client
 	client, err := compute.NewService(ctx)
 	if err != nil {
@@ -89,6 +107,7 @@ func NewGCPAssigner(ctx context.Context, logger *logrus.Entry, project, region s
 	}
 
 	// get project ID from metadata server
+	project := cfg.Project
 	if project == "" {
 		project, err = metadata.ProjectID()
 		if err != nil {
@@ -97,6 +116,7 @@ func NewGCPAssigner(ctx context.Context, logger *logrus.Entry, project, region s
 	}
 
 	// get region from metadata server
+	region := cfg.Region
 	if region == "" {
 		region, err = metadata.InstanceAttributeValue("cluster-location")
 		if err != nil {
@@ -109,14 +129,16 @@ func NewGCPAssigner(ctx context.Context, logger *logrus.Entry, project, region s
 	}
 
 	return &gcpAssigner{
-		lister:         cloud.NewLister(client),
-		waiter:         cloud.NewZoneWaiter(client),
-		addressManager: cloud.NewAddressManager(client, ipv6),
-		instanceGetter: cloud.NewInstanceGetter(client),
-		project:        project,
-		region:         region,
-		ipv6:           ipv6,
-		logger:         logger,
+		lister:           cloud.NewLister(client),
+		waiter:           cloud.NewZoneWaiter(client),
+		addressManager:   cloud.NewAddressManager(client, cfg.IPv6),
+		instanceGetter:   cloud.NewInstanceGetter(client),
+		project:          project,
+		region:           region,
+		ipv6:             cfg.IPv6,
+		networkInterface: cfg.NetworkInterface,
+		cloudClaims:      cfg.LockScope == types.LockScopeCloud,
+		logger:           logger,
 	}, nil
 }
 
@@ -151,7 +173,7 @@ func (a *gcpAssigner) waitForOperation(c context.Context, op *compute.Operation,
 
 func (a *gcpAssigner) DeleteInstanceAddress(ctx context.Context, instance *compute.Instance, zone string) error {
 	// get instance network interface
-	networkInterface, err := getNetworkInterface(instance)
+	networkInterface, err := getNetworkInterface(instance, a.networkInterface)
 	if err != nil {
 		return errors.Wrap(err, "failed to get instance network interface")
 	}
@@ -182,7 +204,7 @@ func (a *gcpAssigner) DeleteInstanceAddress(ctx context.Context, instance *compu
 
 func (a *gcpAssigner) AddInstanceAddress(ctx context.Context, instance *compute.Instance, zone string, address *compute.Address) error {
 	// get instance network interface
-	networkInterface, err := getNetworkInterface(instance)
+	networkInterface, err := getNetworkInterface(instance, a.networkInterface)
 	if err != nil {
 		return errors.Wrap(err, "failed to get instance network interface")
 	}
@@ -219,7 +241,7 @@ func (a *gcpAssigner) Assign(ctx context.Context, instanceID, zone string, filte
 	instance, address, err := a.checkStaticIPAssigned(zone, instanceID)
 	if err != nil {
 		if errors.Is(err, ErrStaticIPAlreadyAssigned) {
-			return address, nil
+			return address, ErrStaticIPAlreadyAssigned
 		}
 		return "", errors.Wrapf(err, "check if static public IP is already assigned to instance %s", instanceID)
 	}
@@ -229,8 +251,9 @@ func (a *gcpAssigner) Assign(ctx context.Context, instanceID, zone string, filte
 	if err != nil {
 		return "", errors.Wrap(err, "failed to list available addresses")
 	}
+	metrics.FreeAddresses.WithLabelValues(string(types.CloudProviderGCP)).Set(float64(len(addresses)))
 	if len(addresses) == 0 {
-		return "", errors.Errorf("no available addresses")
+		return "", noAvailableStaticIPError("no available addresses")
 	}
 	// log available addresses IPs
 	ips := make([]string, 0, len(addresses))
@@ -239,13 +262,32 @@ func (a *gcpAssigner) Assign(ctx context.Context, instanceID, zone string, filte
 	}
 	a.logger.WithField("addresses", ips).Debugf("found %d available addresses", len(addresses))
 
+	// check the lock is still held before changing the instance addresses
+	if err = lease.CheckHeld(ctx); err != nil {
+		return "", errors.Wrap(err, "failed to check lock before assigning address")
+	}
+
 	// delete current ephemeral public IP address
-	if err = a.DeleteInstanceAddress(ctx, instance, zone); err != nil && !errors.Is(err, ErrNoPublicIPAssigned) {
+	err = a.DeleteInstanceAddress(ctx, instance, zone)
+	if err != nil && !errors.Is(err, ErrNoPublicIPAssigned) {
 		return "", errors.Wrap(err, "failed to delete current public IP address")
 	}
+	deleted := err == nil
+
+	assignedAddress, err := a.assignAvailableAddress(ctx, instanceID, zone, addresses)
+	if err != nil && deleted {
+		// do not leave the instance without public IP address: restore the deleted ephemeral public IP address
+		if restoreErr := a.restoreEphemeralAddress(ctx, instanceID, zone); restoreErr != nil {
+			return "", errors.Wrapf(err, "failed to restore ephemeral public IP address (%v)", restoreErr)
+		}
+	}
+	return assignedAddress, err
+}
 
+// assignAvailableAddress assigns the first available address that succeeds to the instance without public IP address
+func (a *gcpAssigner) assignAvailableAddress(ctx context.Context, instanceID, zone string, addresses []*compute.Address) (string, error) {
 	// get instance details again to refresh the network interface fingerprint (required for adding a new ipv6 address)
-	instance, err = a.instanceGetter.Get(a.project, zone, instanceID)
+	instance, err := a.instanceGetter.Get(a.project, zone, instanceID)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed refresh network interface fingerprint for instance %s", instanceID)
 	}
@@ -258,7 +300,25 @@ func (a *gcpAssigner) Assign(ctx context.Context, instanceID, zone string, filte
 		if ctx.Err() != nil {
 			return "", errors.Wrap(ctx.Err(), "context cancelled while assigning addresses")
 		}
-		if err = tryAssignAddress(ctx, a, instance, a.region, zone, address); err != nil {
+		// claim the address when the lock scope is address; skip the addresses claimed by other nodes
+		claimCtx, release, claimErr := lease.ClaimAddress(ctx, address.Address)
+		if claimErr != nil {
+			err = claimErr
+			a.logger.WithError(err).WithField("address", address.Address).Debug("skipping static public IP address")
+			continue
+		}
+		events.Eventf(claimCtx, corev1.EventTypeNormal, events.ReasonAddressSelected, "Selected static public IP address %s", address.Address)
+		if err = lease.CheckHeld(claimCtx); err != nil {
+			release()
+			return "", errors.Wrap(err, "failed to check lock before assigning address")
+		}
+		if a.cloudClaims {
+			err = a.tryClaimAndAssignAddress(claimCtx, instance, zone, address)
+		} else {
+			err = tryAssignAddress(claimCtx, a, instance, a.region, zone, address)
+		}
+		release()
+		if err != nil {
 			a.logger.WithError(err).WithField("address", address.Address).Error("failed to assign static public IP address")
 			continue
 		}
@@ -272,6 +332,32 @@ func (a *gcpAssigner) Assign(ctx context.Context, instanceID, zone string, filte
 	return assignedAddress, nil
 }
 
+// restoreEphemeralAddress assigns an ephemeral public IP address to the instance after a failed assignment, unless
+// the instance has a public IP address; the address is restored even if the assignment context is cancelled.
+func (a *gcpAssigner) restoreEphemeralAddress(ctx context.Context, instanceID, zone string) error {
+	ctx = context.WithoutCancel(ctx)
+	// get instance details again to refresh the network interface fingerprint
+	instance, err := a.instanceGetter.Get(a.project, zone, instanceID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get instance %s", instanceID)
+	}
+	networkInterface, err := getNetworkInterface(instance, a.networkInterface)
+	if err != nil {
+		return errors.Wrap(err, "failed to get instance network interface")
+	}
+	if _, err = getAccessConfig(networkInterface, a.ipv6); err == nil {
+		// an address was assigned despite the failure
+		return nil
+	}
+	a.logger.WithField("instance", instance.Name).Warn("no static public IP address assigned, restoring ephemeral public IP address")
+	if err = retryAddEphemeralAddress(ctx, a.logger, a, instance, zone); err != nil {
+		return errors.Wrap(err, "failed to assign ephemeral public IP address")
+	}
+	return nil
+}
+
+// checkStaticIPAssigned returns the instance and, if the selected network interface holds a static public IP address,
+// this address with ErrStaticIPAlreadyAssigned; the static addresses of the other network interfaces are not checked.
 func (a *gcpAssigner) checkStaticIPAssigned(zone, instanceID string) (*compute.Instance, string, error) {
 	instance, err := a.instanceGetter.Get(a.project, zone, instanceID)
 	if err != nil {
@@ -281,11 +367,24 @@ func (a *gcpAssigner) checkStaticIPAssigned(zone, instanceID string) (*compute.I
 	if err != nil {
 		return nil, "", errors.Wrap(err, "failed to list assigned addresses")
 	}
-	// create a map of users for quick lookup
-	users := a.createUserMap(assigned)
-	// check if the instance's self link is in the list of users
-	if address, ok := users[instance.SelfLink]; ok {
-		return nil, address, ErrStaticIPAlreadyAssigned
+	networkInterface, err := getNetworkInterface(instance, a.networkInterface)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to get instance network interface")
+	}
+	accessConfig, err := getAccessConfig(networkInterface, a.ipv6)
+	if err != nil {
+		// no public IP address on the network interface
+		return instance, "", nil //nolint:nilerr
+	}
+	current := accessConfig.NatIP
+	if a.ipv6 {
+		current = accessConfig.ExternalIpv6
+	}
+	// check if the public IP address of the network interface is a static address used by the instance
+	for _, address := range assigned {
+		if current != "" && address.Address == current && slices.Contains(address.Users, instance.SelfLink) {
+			return instance, current, ErrStaticIPAlreadyAssigned
+		}
 	}
 	return instance, "", nil
 }
@@ -329,38 +428,26 @@ func (a *gcpAssigner) listAddresses(filter []string, orderBy, status string) ([]
 }
 
 func (a *gcpAssigner) Unassign(ctx context.Context, instanceID, zone string) error {
-	// get the instance details
-	instance, err := a.instanceGetter.Get(a.project, zone, instanceID)
-	if err != nil {
-		return errors.Wrapf(err, "failed to get instance %s", instanceID)
+	// check if the selected network interface of the instance holds a static public IP address
+	instance, _, err := a.checkStaticIPAssigned(zone, instanceID)
+	if err == nil {
+		return ErrNoStaticIPAssigned
 	}
-	// list all assigned addresses
-	assigned, err := a.listAddresses(nil, "", inUseStatus)
-	if err != nil {
-		return errors.Wrap(err, "failed to list assigned addresses")
+	if !errors.Is(err, ErrStaticIPAlreadyAssigned) {
+		return errors.Wrap(err, "failed to check assigned address")
 	}
-	if len(assigned) == 0 {
-		return ErrNoStaticIPAssigned
+	// release/remove current static public IP address
+	if err = a.DeleteInstanceAddress(ctx, instance, zone); err != nil {
+		return errors.Wrap(err, "failed to delete current public IP address")
 	}
-
-	// create a map of users for quick lookup
-	users := a.createUserMap(assigned)
-
-	// check if the instance's self link is in the list of users
-	if _, ok := users[instance.SelfLink]; ok {
-		// release/remove current static public IP address
-		if err = a.DeleteInstanceAddress(ctx, instance, zone); err != nil {
-			return errors.Wrap(err, "failed to delete current public IP address")
-		}
-		// get instance details again to refresh the network interface fingerprint (required for adding a new ipv6 address)
-		instance, err = a.instanceGetter.Get(a.project, zone, instanceID)
-		if err != nil {
-			return errors.Wrapf(err, "failed refresh network interface fingerprint for instance %s", instanceID)
-		}
-		// assign ephemeral public IP address to the instance (pass nil address)
-		if err = retryAddEphemeralAddress(ctx, a.logger, a, instance, zone); err != nil {
-			return errors.Wrap(err, "failed to assign ephemeral public IP address")
-		}
+	// get instance details again to refresh the network interface fingerprint (required for adding a new ipv6 address)
+	instance, err = a.instanceGetter.Get(a.project, zone, instanceID)
+	if err != nil {
+		return errors.Wrapf(err, "failed refresh network interface fingerprint for instance %s", instanceID)
+	}
+	// assign ephemeral public IP address to the instance (pass nil address)
+	if err = retryAddEphemeralAddress(ctx, a.logger, a, instance, zone); err != nil {
+		return errors.Wrap(err, "failed to assign ephemeral public IP address")
 	}
 	return nil
 }
@@ -378,11 +465,17 @@ func getAccessConfig(networkInterface *compute.NetworkInterface, ipv6 bool) (*co
 	return networkInterface.AccessConfigs[0], nil
 }
 
-func getNetworkInterface(instance *compute.Instance) (*compute.NetworkInterface, error) {
+// getNetworkInterface returns the network interface of the instance matching the selector: by position, name or subnetwork
+func getNetworkInterface(instance *compute.Instance, selector types.NetworkInterfaceSelector) (*compute.NetworkInterface, error) {
 	if len(instance.NetworkInterfaces) == 0 {
 		return nil, errors.New("instance has no network interfaces")
 	}
-	return instance.NetworkInterfaces[0], nil
+	for i, networkInterface := range instance.NetworkInterfaces {
+		if selector.Match(i, networkInterface.Name, networkInterface.Subnetwork, nil) {
+			return networkInterface, nil
+		}
+	}
+	return nil, errors.Errorf("no network interface matching %s", selector)
 }
 
 func tryAssignAddress(ctx context.Context, as internalAssigner, instance *compute.Instance, region, zone string, address *compute.Address) error {
@@ -401,14 +494,60 @@ func tryAssignAddress(ctx context.Context, as internalAssigner, instance *comput
 	return nil
 }
 
-func (a *gcpAssigner) createUserMap(assigned []*compute.Address) map[string]string {
-	users := make(map[string]string)
-	for _, address := range assigned {
-		for _, user := range address.Users {
-			users[user] = address.Address
+// tryClaimAndAssignAddress claims the address with its labels, using the label fingerprint as compare-and-swap, before
+// assigning it; the assignment is verified and the claim removed once the address is assigned or failed to assign.
+func (a *gcpAssigner) tryClaimAndAssignAddress(ctx context.Context, instance *compute.Instance, zone string, address *compute.Address) error {
+	latest, err := a.addressManager.GetAddress(a.project, a.region, address.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get address %s", address.Name)
+	}
+	if latest.Status != reservedStatus {
+		return errors.New("address is already assigned")
+	}
+	now := time.Now()
+	if !claimable(latest.Labels, instance.Name, now) {
+		return errors.Wrapf(lease.ErrAddressClaimed, "address %s is claimed by %s", address.Address, latest.Labels[claimKey])
+	}
+	err = a.addressManager.SetAddressLabels(a.project, a.region, address.Name, latest.LabelFingerprint, withClaim(latest.Labels, instance.Name, now))
+	if isPreconditionFailed(err) {
+		return errors.Wrapf(lease.ErrAddressClaimed, "address %s was claimed concurrently", address.Address)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to claim address %s", address.Address)
+	}
+	defer a.releaseClaim(address, instance.Name)
+
+	if err = a.AddInstanceAddress(ctx, instance, zone, address); err != nil {
+		return errors.Wrap(err, "failed to assign static public IP address")
+	}
+
+	// verify the instance is the user of the claimed address
+	latest, err = a.addressManager.GetAddress(a.project, a.region, address.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify address %s", address.Address)
+	}
+	for _, user := range latest.Users {
+		if user == instance.SelfLink {
+			return nil
 		}
 	}
-	return users
+	return errors.Errorf("claimed address %s is not assigned to instance %s", address.Address, instance.Name)
+}
+
+// releaseClaim removes the claim of the holder from the address labels; a claim left behind goes stale after claimTTL
+func (a *gcpAssigner) releaseClaim(address *compute.Address, holder string) {
+	latest, err := a.addressManager.GetAddress(a.project, a.region, address.Name)
+	if err == nil && claimedBy(latest.Labels, holder) {
+		err = a.addressManager.SetAddressLabels(a.project, a.region, address.Name, latest.LabelFingerprint, withoutClaim(latest.Labels))
+	}
+	if err != nil {
+		a.logger.WithError(err).WithField("address", address.Address).Warn("failed to release address claim")
+	}
+}
+
+func isPreconditionFailed(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed
 }
 
 func retryAddEphemeralAddress(ctx context.Context, logger *logrus.Entry, as internalAssigner, instance *compute.Instance, zone string) error {
@@ -466,3 +605,34 @@ func addressPrefixLengthOrDefault(address *compute.Address) int64 {
 	}
 	return address.PrefixLength
 }
+
+// GetResourceID returns the self link of the static address.
+func (a *gcpAssigner) GetResourceID(_ context.Context, address string) (string, error) {
+	addresses, err := a.listAddresses([]string{fmt.Sprintf("address=%q", address)}, "", inUseStatus)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list address %s", address)
+	}
+	if len(addresses) == 0 {
+		return "", ErrStaticIPNotFound
+	}
+	return addresses[0].SelfLink, nil
+}
+
+// MatchFilter returns true if the static address matches the filter.
+func (a *gcpAssigner) MatchFilter(_ context.Context, address string, filter []string) (bool, error) {
+	filters := append([]string{fmt.Sprintf("address=%q", address)}, filter...)
+	addresses, err := a.listAddresses(filters, "", inUseStatus)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list address %s", address)
+	}
+	return len(addresses) > 0, nil
+}
+
+// IsAssigned returns true if the static address is still assigned to the instance.
+func (a *gcpAssigner) IsAssigned(_ context.Context, instanceID, zone, address string) (bool, error) {
+	_, assigned, err := a.checkStaticIPAssigned(zone, instanceID)
+	if errors.Is(err, ErrStaticIPAlreadyAssigned) {
+		return assigned == address, nil
+	}
+	return false, err
+}
@@ -2,17 +2,22 @@ package address
 
 import (
 	"context"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/doitintl/kubeip/internal/cloud"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/types"
 	amock "github.com/doitintl/kubeip/mocks/address"
 	mocks "github.com/doitintl/kubeip/mocks/cloud"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	tmock "github.com/stretchr/testify/mock"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 func Test_gcpAssigner_listAddresses(t *testing.T) {
@@ -330,6 +335,7 @@ func Test_gcpAssigner_Assign(t *testing.T) {
 		project          string
 		region           string
 		address          string
+		selector         types.NetworkInterfaceSelector
 	}
 	type args struct {
 		ctx        context.Context
@@ -343,6 +349,8 @@ func Test_gcpAssigner_Assign(t *testing.T) {
 		fields  fields
 		args    args
 		wantErr bool
+		// wantAssigned expects ErrStaticIPAlreadyAssigned with the current address
+		wantAssigned bool
 	}{
 		{
 			name: "assign static IP address successfully",
@@ -438,7 +446,7 @@ func Test_gcpAssigner_Assign(t *testing.T) {
 							{
 								Name: "test-network-interface",
 								AccessConfigs: []*compute.AccessConfig{
-									{Name: "test-access-config", NatIP: "200.0.0.1", Type: defaultAccessConfigType, Kind: accessConfigKind},
+									{Name: "test-access-config", NatIP: "100.0.0.2", Type: defaultAccessConfigType, Kind: accessConfigKind},
 								},
 								Fingerprint: "test-fingerprint",
 							},
@@ -457,20 +465,99 @@ func Test_gcpAssigner_Assign(t *testing.T) {
 				filter:     []string{"test-filter-1", "test-filter-2"},
 				orderBy:    "test-order-by",
 			},
+			wantAssigned: true,
+		},
+		{
+			name: "assign static IP address to second network interface when first one already has static IP address",
+			fields: fields{
+				project:  "test-project",
+				region:   "test-region",
+				address:  "100.0.0.3",
+				selector: types.NetworkInterfaceSelector{Kind: types.NetworkInterfaceByIndex, Value: "1"},
+				listerFn: func(t *testing.T) cloud.Lister {
+					mock := mocks.NewLister(t)
+					mockCall := mocks.NewListCall(t)
+					mock.EXPECT().List("test-project", "test-region").Return(mockCall)
+					mockCall.EXPECT().Filter("(status=IN_USE) (addressType=EXTERNAL) (ipVersion!=IPV6)").Return(mockCall).Once()
+					mockCall.EXPECT().Do().Return(&compute.AddressList{
+						Items: []*compute.Address{
+							{Name: "test-address-2", Status: inUseStatus, Address: "100.0.0.2", NetworkTier: defaultNetworkTier, AddressType: "EXTERNAL", Users: []string{"self-link-test-instance-0"}},
+						},
+					}, nil).Once()
+					mockCall.EXPECT().Filter("(status=RESERVED) (addressType=EXTERNAL) (ipVersion!=IPV6) (test-filter-1) (test-filter-2)").Return(mockCall).Once()
+					mockCall.EXPECT().OrderBy("test-order-by").Return(mockCall).Once()
+					mockCall.EXPECT().Do().Return(&compute.AddressList{
+						Items: []*compute.Address{
+							{Name: "test-address-3", Status: reservedStatus, Address: "100.0.0.3", NetworkTier: defaultNetworkTier, AddressType: "EXTERNAL"},
+						},
+					}, nil).Once()
+					return mock
+				},
+				instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+					mock := mocks.NewInstanceGetter(t)
+					mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(&compute.Instance{
+						Name:     "test-instance-0",
+						Zone:     "test-zone",
+						SelfLink: "self-link-test-instance-0",
+						NetworkInterfaces: []*compute.NetworkInterface{
+							{
+								Name: "test-network-interface-0",
+								AccessConfigs: []*compute.AccessConfig{
+									{Name: "test-access-config", NatIP: "100.0.0.2", Type: defaultAccessConfigType, Kind: accessConfigKind},
+								},
+								Fingerprint: "test-fingerprint-0",
+							},
+							{
+								Name: "test-network-interface-1",
+								AccessConfigs: []*compute.AccessConfig{
+									{Name: "test-access-config", NatIP: "200.0.0.1", Type: defaultAccessConfigType, Kind: accessConfigKind},
+								},
+								Fingerprint: "test-fingerprint-1",
+							},
+						},
+					}, nil)
+					return mock
+				},
+				addressManagerFn: func(t *testing.T) cloud.AddressManager {
+					mock := mocks.NewAddressManager(t)
+					mock.EXPECT().DeleteAccessConfig("test-project", "test-zone", "test-instance-0", "test-access-config", "test-network-interface-1", "test-fingerprint-1").Return(&compute.Operation{Name: "test-operation", Status: "DONE"}, nil)
+					mock.EXPECT().AddAccessConfig("test-project", "test-zone", "test-instance-0", "test-network-interface-1", "test-fingerprint-1", &compute.AccessConfig{
+						Name:  defaultNetworkName,
+						Type:  defaultAccessConfigType,
+						Kind:  accessConfigKind,
+						NatIP: "100.0.0.3",
+					}).Return(&compute.Operation{Name: "test-operation", Status: "DONE"}, nil)
+					mock.EXPECT().GetAddress("test-project", "test-region", "test-address-3").Return(&compute.Address{Name: "test-address-3", Status: reservedStatus}, nil)
+					return mock
+				},
+			},
+			args: args{
+				ctx:        context.TODO(),
+				instanceID: "test-instance-0",
+				zone:       "test-zone",
+				filter:     []string{"test-filter-1", "test-filter-2"},
+				orderBy:    "test-order-by",
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := logrus.NewEntry(logrus.New())
 			a := &gcpAssigner{
-				lister:         tt.fields.listerFn(t),
-				addressManager: tt.fields.addressManagerFn(t),
-				instanceGetter: tt.fields.instanceGetterFn(t),
-				project:        tt.fields.project,
-				region:         tt.fields.region,
-				logger:         logger,
+				lister:           tt.fields.listerFn(t),
+				addressManager:   tt.fields.addressManagerFn(t),
+				instanceGetter:   tt.fields.instanceGetterFn(t),
+				project:          tt.fields.project,
+				region:           tt.fields.region,
+				networkInterface: tt.fields.selector,
+				logger:           logger,
 			}
 			address, err := a.Assign(tt.args.ctx, tt.args.instanceID, tt.args.zone, tt.args.filter, tt.args.orderBy)
+			if assigned := errors.Is(err, ErrStaticIPAlreadyAssigned); assigned != tt.wantAssigned {
+				t.Errorf("Assign() error = %v, wantAssigned %v", err, tt.wantAssigned)
+			} else if assigned {
+				err = nil
+			}
 			if err != nil != tt.wantErr {
 				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
 			} else if address != tt.fields.address {
@@ -480,6 +567,169 @@ func Test_gcpAssigner_Assign(t *testing.T) {
 	}
 }
 
+func Test_gcpAssigner_Assign_rollback(t *testing.T) {
+	withAccessConfig := &compute.Instance{
+		Name:     "test-instance-0",
+		SelfLink: "self-link-test-instance-0",
+		NetworkInterfaces: []*compute.NetworkInterface{{
+			Name:          "test-network-interface",
+			AccessConfigs: []*compute.AccessConfig{{Name: "test-access-config", NatIP: "200.0.0.1", Type: defaultAccessConfigType, Kind: accessConfigKind}},
+			Fingerprint:   "test-fingerprint",
+		}},
+	}
+	withoutAccessConfig := &compute.Instance{
+		Name:              "test-instance-0",
+		SelfLink:          "self-link-test-instance-0",
+		NetworkInterfaces: []*compute.NetworkInterface{{Name: "test-network-interface", Fingerprint: "test-fingerprint-2"}},
+	}
+	ephemeral := &compute.AccessConfig{Name: defaultNetworkName, Type: defaultAccessConfigType, Kind: accessConfigKind}
+	static := &compute.AccessConfig{Name: defaultNetworkName, Type: defaultAccessConfigType, Kind: accessConfigKind, NatIP: "100.0.0.3"}
+	done := &compute.Operation{Name: "test-operation", Status: "DONE"}
+	tests := []struct {
+		name             string
+		ctx              func() context.Context
+		instanceGetterFn func(t *testing.T) cloud.InstanceGetter
+		addressManagerFn func(t *testing.T) cloud.AddressManager
+		wantRestoreErr   bool
+	}{
+		{
+			name: "restore ephemeral address when refreshing the instance fails",
+			instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+				mock := mocks.NewInstanceGetter(t)
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withAccessConfig, nil).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(nil, errors.New("api error")).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withoutAccessConfig, nil).Once()
+				return mock
+			},
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().DeleteAccessConfig("test-project", "test-zone", "test-instance-0", "test-access-config", "test-network-interface", "test-fingerprint").Return(done, nil).Once()
+				mock.EXPECT().AddAccessConfig("test-project", "test-zone", "test-instance-0", "test-network-interface", "test-fingerprint-2", ephemeral).Return(done, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "restore ephemeral address when all addresses fail",
+			instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+				mock := mocks.NewInstanceGetter(t)
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withAccessConfig, nil).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withoutAccessConfig, nil).Twice()
+				return mock
+			},
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().DeleteAccessConfig("test-project", "test-zone", "test-instance-0", "test-access-config", "test-network-interface", "test-fingerprint").Return(done, nil).Once()
+				// the first address was assigned concurrently, the second exceeds the quota
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address-3").Return(&compute.Address{Status: inUseStatus}, nil).Once()
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address-4").Return(&compute.Address{Status: reservedStatus}, nil).Once()
+				mock.EXPECT().AddAccessConfig("test-project", "test-zone", "test-instance-0", "test-network-interface", "test-fingerprint-2", &compute.AccessConfig{Name: defaultNetworkName, Type: defaultAccessConfigType, Kind: accessConfigKind, NatIP: "100.0.0.4"}).Return(nil, errors.New("quota exceeded")).Once()
+				mock.EXPECT().AddAccessConfig("test-project", "test-zone", "test-instance-0", "test-network-interface", "test-fingerprint-2", ephemeral).Return(done, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "restore ephemeral address when the context is cancelled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+				mock := mocks.NewInstanceGetter(t)
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withAccessConfig, nil).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withoutAccessConfig, nil).Twice()
+				return mock
+			},
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().DeleteAccessConfig("test-project", "test-zone", "test-instance-0", "test-access-config", "test-network-interface", "test-fingerprint").Return(done, nil).Once()
+				mock.EXPECT().AddAccessConfig("test-project", "test-zone", "test-instance-0", "test-network-interface", "test-fingerprint-2", ephemeral).Return(done, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "keep address assigned despite the failure",
+			instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+				mock := mocks.NewInstanceGetter(t)
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withAccessConfig, nil).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withoutAccessConfig, nil).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withAccessConfig, nil).Once()
+				return mock
+			},
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().DeleteAccessConfig("test-project", "test-zone", "test-instance-0", "test-access-config", "test-network-interface", "test-fingerprint").Return(done, nil).Once()
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address-3").Return(&compute.Address{Status: reservedStatus}, nil).Once()
+				mock.EXPECT().AddAccessConfig("test-project", "test-zone", "test-instance-0", "test-network-interface", "test-fingerprint-2", static).Return(nil, errors.New("timeout")).Once()
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address-4").Return(&compute.Address{Status: inUseStatus}, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "no restore without public IP address before the assignment",
+			instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+				mock := mocks.NewInstanceGetter(t)
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withoutAccessConfig, nil).Twice()
+				return mock
+			},
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address-3").Return(&compute.Address{Status: inUseStatus}, nil).Once()
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address-4").Return(&compute.Address{Status: inUseStatus}, nil).Once()
+				return mock
+			},
+		},
+		{
+			name: "restoring ephemeral address fails",
+			instanceGetterFn: func(t *testing.T) cloud.InstanceGetter {
+				mock := mocks.NewInstanceGetter(t)
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(withAccessConfig, nil).Once()
+				mock.EXPECT().Get("test-project", "test-zone", "test-instance-0").Return(nil, errors.New("api error")).Twice()
+				return mock
+			},
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().DeleteAccessConfig("test-project", "test-zone", "test-instance-0", "test-access-config", "test-network-interface", "test-fingerprint").Return(done, nil).Once()
+				return mock
+			},
+			wantRestoreErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lister := mocks.NewLister(t)
+			call := mocks.NewListCall(t)
+			lister.EXPECT().List("test-project", "test-region").Return(call)
+			call.EXPECT().Filter("(status=IN_USE) (addressType=EXTERNAL) (ipVersion!=IPV6)").Return(call).Once()
+			call.EXPECT().Do().Return(&compute.AddressList{}, nil).Once()
+			call.EXPECT().Filter("(status=RESERVED) (addressType=EXTERNAL) (ipVersion!=IPV6)").Return(call).Once()
+			call.EXPECT().Do().Return(&compute.AddressList{Items: []*compute.Address{
+				{Name: "test-address-3", Status: reservedStatus, Address: "100.0.0.3", AddressType: "EXTERNAL"},
+				{Name: "test-address-4", Status: reservedStatus, Address: "100.0.0.4", AddressType: "EXTERNAL"},
+			}}, nil).Once()
+			a := &gcpAssigner{
+				lister:         lister,
+				addressManager: tt.addressManagerFn(t),
+				instanceGetter: tt.instanceGetterFn(t),
+				project:        "test-project",
+				region:         "test-region",
+				logger:         logrus.NewEntry(logrus.New()),
+			}
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+			address, err := a.Assign(ctx, "test-instance-0", "test-zone", nil, "")
+			if err == nil {
+				t.Fatalf("Assign() = %v, want error", address)
+			}
+			if restoreErr := strings.Contains(err.Error(), "failed to restore ephemeral public IP address"); restoreErr != tt.wantRestoreErr {
+				t.Errorf("Assign() error = %v, want restore error %v", err, tt.wantRestoreErr)
+			}
+		})
+	}
+}
+
 func Test_createAccessConfig(t *testing.T) {
 	type args struct {
 		address *compute.Address
@@ -538,6 +788,14 @@ func Test_createAccessConfig(t *testing.T) {
 func Test_getNetworkInterface(t *testing.T) {
 	type args struct {
 		instance *compute.Instance
+		selector types.NetworkInterfaceSelector
+	}
+	multiNIC := &compute.Instance{
+		Name: "test-instance",
+		NetworkInterfaces: []*compute.NetworkInterface{
+			{Name: "nic0", Subnetwork: "https://www.googleapis.com/compute/v1/projects/test-project/regions/test-region/subnetworks/private"},
+			{Name: "nic1", Subnetwork: "https://www.googleapis.com/compute/v1/projects/test-project/regions/test-region/subnetworks/public"},
+		},
 	}
 	tests := []struct {
 		name    string
@@ -579,10 +837,42 @@ func Test_getNetworkInterface(t *testing.T) {
 			},
 			want: &compute.NetworkInterface{Name: "test-network-interface-1"},
 		},
+		{
+			name: "get network interface by index",
+			args: args{
+				instance: multiNIC,
+				selector: types.NetworkInterfaceSelector{Kind: types.NetworkInterfaceByIndex, Value: "1"},
+			},
+			want: multiNIC.NetworkInterfaces[1],
+		},
+		{
+			name: "get network interface by name",
+			args: args{
+				instance: multiNIC,
+				selector: types.NetworkInterfaceSelector{Kind: types.NetworkInterfaceByName, Value: "nic1"},
+			},
+			want: multiNIC.NetworkInterfaces[1],
+		},
+		{
+			name: "get network interface by subnetwork",
+			args: args{
+				instance: multiNIC,
+				selector: types.NetworkInterfaceSelector{Kind: types.NetworkInterfaceBySubnet, Value: "public"},
+			},
+			want: multiNIC.NetworkInterfaces[1],
+		},
+		{
+			name: "no network interface matching selector",
+			args: args{
+				instance: multiNIC,
+				selector: types.NetworkInterfaceSelector{Kind: types.NetworkInterfaceByIndex, Value: "2"},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getNetworkInterface(tt.args.instance)
+			got, err := getNetworkInterface(tt.args.instance, tt.args.selector)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getNetworkInterface() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -814,3 +1104,71 @@ func Test_tryAssignAddress(t *testing.T) {
 		})
 	}
 }
+
+func Test_gcpAssigner_tryClaimAndAssignAddress(t *testing.T) {
+	instance := &compute.Instance{Name: "test-instance", SelfLink: "test-instance-link"}
+	address := &compute.Address{Name: "test-address", Address: "100.0.0.1"}
+	tests := []struct {
+		name             string
+		addressManagerFn func(t *testing.T) cloud.AddressManager
+		wantClaimed      bool
+		wantErr          bool
+	}{
+		{
+			name: "address already assigned",
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address").Return(&compute.Address{Status: inUseStatus}, nil).Once()
+				return mock
+			},
+			wantErr: true,
+		},
+		{
+			name: "address claimed by another instance",
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address").Return(&compute.Address{
+					Status: reservedStatus,
+					Labels: claimTags("another-instance", time.Now()),
+				}, nil).Once()
+				return mock
+			},
+			wantClaimed: true,
+			wantErr:     true,
+		},
+		{
+			name: "address labels changed concurrently",
+			addressManagerFn: func(t *testing.T) cloud.AddressManager {
+				mock := mocks.NewAddressManager(t)
+				mock.EXPECT().GetAddress("test-project", "test-region", "test-address").Return(&compute.Address{
+					Status:           reservedStatus,
+					LabelFingerprint: "fingerprint",
+				}, nil).Once()
+				mock.EXPECT().SetAddressLabels("test-project", "test-region", "test-address", "fingerprint", tmock.MatchedBy(func(labels map[string]string) bool {
+					return claimedBy(labels, "test-instance")
+				})).Return(&googleapi.Error{Code: http.StatusPreconditionFailed}).Once()
+				return mock
+			},
+			wantClaimed: true,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &gcpAssigner{
+				addressManager: tt.addressManagerFn(t),
+				project:        "test-project",
+				region:         "test-region",
+				cloudClaims:    true,
+				logger:         logrus.NewEntry(logrus.New()),
+			}
+			err := a.tryClaimAndAssignAddress(context.TODO(), instance, "test-zone", address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tryClaimAndAssignAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, lease.ErrAddressClaimed) != tt.wantClaimed {
+				t.Errorf("tryClaimAndAssignAddress() error = %v, want claimed %v", err, tt.wantClaimed)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package address
+
+import (
+	"context"
+	"errors"
+
+	"github.com/doitintl/kubeip/internal/metrics"
+	"github.com/doitintl/kubeip/internal/types"
+)
+
+// instrumentedAssigner counts the assign and unassign attempts of the wrapped assigner by outcome and reason
+type instrumentedAssigner struct {
+	Assigner
+	cloud string
+}
+
+func newInstrumentedAssigner(assigner Assigner, provider types.CloudProvider) Assigner {
+	return &instrumentedAssigner{Assigner: assigner, cloud: string(provider)}
+}
+
+func (a *instrumentedAssigner) Assign(ctx context.Context, instanceID, zone string, filter []string, orderBy string) (string, error) {
+	assignedAddress, err := a.Assigner.Assign(ctx, instanceID, zone, filter, orderBy)
+	outcome := metrics.OutcomeSuccess
+	if err != nil && !errors.Is(err, ErrStaticIPAlreadyAssigned) {
+		outcome = metrics.OutcomeFailure
+	}
+	metrics.AssignAttempts.WithLabelValues(a.cloud, outcome, reason(err, "assigned")).Inc()
+	return assignedAddress, err //nolint:wrapcheck
+}
+
+func (a *instrumentedAssigner) Unassign(ctx context.Context, instanceID, zone string) error {
+	err := a.Assigner.Unassign(ctx, instanceID, zone)
+	outcome := metrics.OutcomeSuccess
+	if err != nil {
+		outcome = metrics.OutcomeFailure
+	}
+	metrics.UnassignAttempts.WithLabelValues(a.cloud, outcome, reason(err, "unassigned")).Inc()
+	return err //nolint:wrapcheck
+}
+
+// GetResourceID keeps the resource ID lookup of the wrapped assigner available.
+func (a *instrumentedAssigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	return LookupResourceID(ctx, a.Assigner, address)
+}
+
+// MatchFilter keeps the filter check of the wrapped assigner available.
+func (a *instrumentedAssigner) MatchFilter(ctx context.Context, address string, filter []string) (bool, error) {
+	return MatchFilter(ctx, a.Assigner, address, filter)
+}
+
+// IsAssigned keeps the assignment check of the wrapped assigner available.
+func (a *instrumentedAssigner) IsAssigned(ctx context.Context, instanceID, zone, address string) (bool, error) {
+	return IsAssigned(ctx, a.Assigner, instanceID, zone, address)
+}
+
+// reason returns the reason label of an assign or unassign attempt
+func reason(err error, success string) string {
+	switch {
+	case err == nil:
+		return success
+	case errors.Is(err, ErrStaticIPAlreadyAssigned):
+		return "already_assigned"
+	case errors.Is(err, ErrNoStaticIPAssigned):
+		return "not_assigned"
+	case errors.Is(err, ErrNoAvailableStaticIP):
+		return "no_available_addresses"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
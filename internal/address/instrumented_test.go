@@ -0,0 +1,105 @@
+package address
+
+import (
+	"context"
+	"testing"
+
+	"github.com/doitintl/kubeip/internal/metrics"
+	"github.com/doitintl/kubeip/internal/types"
+	amock "github.com/doitintl/kubeip/mocks/address"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_instrumentedAssigner_Assign(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		outcome string
+		reason  string
+	}{
+		{
+			name:    "assigned",
+			outcome: metrics.OutcomeSuccess,
+			reason:  "assigned",
+		},
+		{
+			name:    "already assigned",
+			err:     ErrStaticIPAlreadyAssigned,
+			outcome: metrics.OutcomeSuccess,
+			reason:  "already_assigned",
+		},
+		{
+			name:    "no available addresses",
+			err:     errors.Wrap(noAvailableStaticIPError("no available elastic IPs"), "failed to get available elastic IPs"),
+			outcome: metrics.OutcomeFailure,
+			reason:  "no_available_addresses",
+		},
+		{
+			name:    "cloud error",
+			err:     errors.New("error"),
+			outcome: metrics.OutcomeFailure,
+			reason:  "error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.AssignAttempts.WithLabelValues(string(types.CloudProviderAWS), tt.outcome, tt.reason)
+			before := testutil.ToFloat64(counter)
+
+			mock := amock.NewAssigner(t)
+			mock.EXPECT().Assign(context.TODO(), "i-1234567890abcdef0", "", []string(nil), "").Return("100.0.0.1", tt.err).Once()
+			a := newInstrumentedAssigner(mock, types.CloudProviderAWS)
+			_, err := a.Assign(context.TODO(), "i-1234567890abcdef0", "", nil, "")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Assign() error = %v, wantErr %v", err, tt.err)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("assign attempts{outcome=%s,reason=%s} increased by %v, want 1", tt.outcome, tt.reason, got)
+			}
+		})
+	}
+}
+
+func Test_instrumentedAssigner_Unassign(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		outcome string
+		reason  string
+	}{
+		{
+			name:    "unassigned",
+			outcome: metrics.OutcomeSuccess,
+			reason:  "unassigned",
+		},
+		{
+			name:    "no static IP assigned",
+			err:     ErrNoStaticIPAssigned,
+			outcome: metrics.OutcomeFailure,
+			reason:  "not_assigned",
+		},
+		{
+			name:    "timeout",
+			err:     errors.Wrap(context.DeadlineExceeded, "failed to disassociate elastic IP"),
+			outcome: metrics.OutcomeFailure,
+			reason:  "timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.UnassignAttempts.WithLabelValues(string(types.CloudProviderGCP), tt.outcome, tt.reason)
+			before := testutil.ToFloat64(counter)
+
+			mock := amock.NewAssigner(t)
+			mock.EXPECT().Unassign(context.TODO(), "instance", "zone").Return(tt.err).Once()
+			a := newInstrumentedAssigner(mock, types.CloudProviderGCP)
+			if err := a.Unassign(context.TODO(), "instance", "zone"); !errors.Is(err, tt.err) {
+				t.Errorf("Unassign() error = %v, wantErr %v", err, tt.err)
+			}
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("unassign attempts{outcome=%s,reason=%s} increased by %v, want 1", tt.outcome, tt.reason, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package address
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// multiAddressAssigner assigns several static public IP addresses to the instance, using one assigner per address slot
+type multiAddressAssigner struct {
+	slots  []Assigner
+	logger *logrus.Entry
+}
+
+func newMultiAddressAssigner(ctx context.Context, logger *logrus.Entry, provider types.CloudProvider, cfg *config.Config) (Assigner, error) {
+	switch provider {
+	case types.CloudProviderGCP, types.CloudProviderAWS:
+		if !cfg.NetworkInterface.IsPrimary() {
+			return nil, errors.Errorf("network interface selector is not supported with several addresses per node on %s", provider)
+		}
+	case types.CloudProviderOCI:
+	default:
+		return nil, errors.Errorf("several addresses per node are not supported on %s", provider)
+	}
+	slots := make([]Assigner, 0, cfg.AddressesPerNode)
+	for slot := 0; slot < cfg.AddressesPerNode; slot++ {
+		assigner, err := newSlotAssigner(ctx, logger.WithField("slot", slot), provider, cfg, slot)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create assigner of address %d", slot)
+		}
+		slots = append(slots, assigner)
+	}
+	return &multiAddressAssigner{slots: slots, logger: logger}, nil
+}
+
+// newSlotAssigner creates the assigner of the address slot of the instance: the network interface at the slot index on
+// GCP and AWS, the private IP of the VNIC at the slot index on OCI. The first slot is the primary network interface.
+func newSlotAssigner(ctx context.Context, logger *logrus.Entry, provider types.CloudProvider, cfg *config.Config, slot int) (Assigner, error) {
+	if slot == 0 {
+		return newSingleStackAssigner(ctx, logger, provider, cfg)
+	}
+	if provider == types.CloudProviderOCI {
+		return newOCIAssigner(ctx, logger, cfg, slot)
+	}
+	slotCfg := *cfg
+	slotCfg.NetworkInterface = types.NetworkInterfaceSelector{Kind: types.NetworkInterfaceByIndex, Value: strconv.Itoa(slot)}
+	return newSingleStackAssigner(ctx, logger, provider, &slotCfg)
+}
+
+// Assign assigns a static public IP address to each slot of the instance; if a slot fails, the addresses assigned to
+// the previous slots by this call are released, while the addresses the instance already held are kept. Returns the
+// addresses separated by a comma.
+func (a *multiAddressAssigner) Assign(ctx context.Context, instanceID, zone string, filter []string, orderBy string) (string, error) {
+	addresses := make([]string, 0, len(a.slots))
+	// newlyAssigned maps the slots assigned by this call to their addresses
+	newlyAssigned := make(map[int]string, len(a.slots))
+	alreadyAssigned := true
+	for slot, assigner := range a.slots {
+		address, err := assigner.Assign(ctx, instanceID, zone, filter, orderBy)
+		assigned := errors.Is(err, ErrStaticIPAlreadyAssigned)
+		if err != nil && !assigned {
+			a.rollback(ctx, instanceID, zone, newlyAssigned)
+			return "", errors.Wrapf(err, "failed to assign static public IP address %d", slot)
+		}
+		if !assigned {
+			newlyAssigned[slot] = address
+		}
+		alreadyAssigned = alreadyAssigned && assigned
+		addresses = append(addresses, address)
+	}
+	if alreadyAssigned {
+		return strings.Join(addresses, addressSeparator), ErrStaticIPAlreadyAssigned
+	}
+	return strings.Join(addresses, addressSeparator), nil
+}
+
+// rollback releases the addresses assigned to the slots by the failed assignment
+func (a *multiAddressAssigner) rollback(ctx context.Context, instanceID, zone string, addresses map[int]string) {
+	for slot, address := range addresses {
+		if err := a.slots[slot].Unassign(ctx, instanceID, zone); err != nil {
+			a.logger.WithError(err).WithField("address", address).Error("failed to release static public IP address after assignment failure")
+		}
+	}
+}
+
+// Unassign releases the static public IP addresses of all slots of the instance.
+func (a *multiAddressAssigner) Unassign(ctx context.Context, instanceID, zone string) error {
+	released := false
+	var unassignErr error
+	for slot, assigner := range a.slots {
+		err := assigner.Unassign(ctx, instanceID, zone)
+		switch {
+		case err == nil:
+			released = true
+		case isNoStaticIPAssigned(err):
+		case unassignErr == nil:
+			unassignErr = errors.Wrapf(err, "failed to release static public IP address %d", slot)
+		}
+	}
+	if unassignErr != nil {
+		return unassignErr
+	}
+	if !released {
+		return ErrNoStaticIPAssigned
+	}
+	return nil
+}
+
+// isNoStaticIPAssigned returns true if the slot holds no static public IP address
+func isNoStaticIPAssigned(err error) bool {
+	return errors.Is(err, ErrNoStaticIPAssigned) || errors.Is(err, ErrNoPublicIPAssigned)
+}
+
+// GetResourceID returns the resource IDs of the addresses, separated by a comma.
+func (a *multiAddressAssigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	addresses := SplitAddresses(address)
+	resourceIDs := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		resourceID, err := LookupResourceID(ctx, a.slots[0], addr)
+		if err != nil {
+			return "", err
+		}
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	return strings.Join(resourceIDs, addressSeparator), nil
+}
+
+// MatchFilter returns true if all addresses match the filter.
+func (a *multiAddressAssigner) MatchFilter(ctx context.Context, address string, filter []string) (bool, error) {
+	for _, addr := range SplitAddresses(address) {
+		ok, err := MatchFilter(ctx, a.slots[0], addr, filter)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// IsAssigned returns true if each slot of the instance still holds its address.
+func (a *multiAddressAssigner) IsAssigned(ctx context.Context, instanceID, zone, address string) (bool, error) {
+	addresses := SplitAddresses(address)
+	if len(addresses) != len(a.slots) {
+		return false, nil
+	}
+	for slot, addr := range addresses {
+		ok, err := IsAssigned(ctx, a.slots[slot], instanceID, zone, addr)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,141 @@
+package address
+
+import (
+	"context"
+	"testing"
+
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/types"
+	amock "github.com/doitintl/kubeip/mocks/address"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func Test_multiAddressAssigner_Assign(t *testing.T) {
+	type result struct {
+		address string
+		err     error
+	}
+	tests := []struct {
+		name         string
+		results      []result
+		wantReleased []bool
+		want         string
+		wantErr      error
+	}{
+		{
+			name:    "assign all addresses",
+			results: []result{{address: "100.0.0.1"}, {address: "100.0.0.2"}, {address: "100.0.0.3"}},
+			want:    "100.0.0.1,100.0.0.2,100.0.0.3",
+		},
+		{
+			name:    "all addresses already assigned",
+			results: []result{{"100.0.0.1", ErrStaticIPAlreadyAssigned}, {"100.0.0.2", ErrStaticIPAlreadyAssigned}},
+			want:    "100.0.0.1,100.0.0.2",
+			wantErr: ErrStaticIPAlreadyAssigned,
+		},
+		{
+			name:    "only first address already assigned",
+			results: []result{{"100.0.0.1", ErrStaticIPAlreadyAssigned}, {address: "100.0.0.2"}},
+			want:    "100.0.0.1,100.0.0.2",
+		},
+		{
+			name:         "release previous addresses when an address is not available",
+			results:      []result{{address: "100.0.0.1"}, {address: "100.0.0.2"}, {err: ErrNoAvailableStaticIP}},
+			wantReleased: []bool{true, true, false},
+			wantErr:      ErrNoAvailableStaticIP,
+		},
+		{
+			name:         "keep already assigned address when an address is not available",
+			results:      []result{{"100.0.0.1", ErrStaticIPAlreadyAssigned}, {err: ErrNoAvailableStaticIP}},
+			wantReleased: []bool{false, false},
+			wantErr:      ErrNoAvailableStaticIP,
+		},
+		{
+			name:         "release only newly assigned addresses",
+			results:      []result{{"100.0.0.1", ErrStaticIPAlreadyAssigned}, {address: "100.0.0.2"}, {err: ErrNoAvailableStaticIP}},
+			wantReleased: []bool{false, true, false},
+			wantErr:      ErrNoAvailableStaticIP,
+		},
+		{
+			name:         "first address not available",
+			results:      []result{{err: ErrNoAvailableStaticIP}, {}},
+			wantReleased: []bool{false, false},
+			wantErr:      ErrNoAvailableStaticIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := make([]Assigner, 0, len(tt.results))
+			failed := false
+			for i, r := range tt.results {
+				mock := amock.NewAssigner(t)
+				if !failed {
+					mock.EXPECT().Assign(context.TODO(), "instance-1", "zone-1", []string(nil), "").Return(r.address, r.err).Once()
+					failed = r.err != nil && !errors.Is(r.err, ErrStaticIPAlreadyAssigned)
+				}
+				if len(tt.wantReleased) > i && tt.wantReleased[i] {
+					mock.EXPECT().Unassign(context.TODO(), "instance-1", "zone-1").Return(nil).Once()
+				}
+				slots = append(slots, mock)
+			}
+			a := &multiAddressAssigner{slots: slots, logger: logrus.NewEntry(logrus.New())}
+			got, err := a.Assign(context.TODO(), "instance-1", "zone-1", nil, "")
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Assign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Assign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_multiAddressAssigner_Unassign(t *testing.T) {
+	tests := []struct {
+		name    string
+		errs    []error
+		wantErr error
+	}{
+		{
+			name: "release all addresses",
+			errs: []error{nil, nil},
+		},
+		{
+			name:    "no address assigned",
+			errs:    []error{ErrNoStaticIPAssigned, ErrNoPublicIPAssigned},
+			wantErr: ErrNoStaticIPAssigned,
+		},
+		{
+			name: "only first address assigned",
+			errs: []error{nil, ErrNoStaticIPAssigned},
+		},
+		{
+			name:    "fail to release second address",
+			errs:    []error{nil, errors.New("error"), nil},
+			wantErr: errors.New("failed to release static public IP address 1: error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := make([]Assigner, 0, len(tt.errs))
+			for _, err := range tt.errs {
+				mock := amock.NewAssigner(t)
+				mock.EXPECT().Unassign(context.TODO(), "instance-1", "zone-1").Return(err).Once()
+				slots = append(slots, mock)
+			}
+			a := &multiAddressAssigner{slots: slots, logger: logrus.NewEntry(logrus.New())}
+			err := a.Unassign(context.TODO(), "instance-1", "zone-1")
+			if (err != nil) != (tt.wantErr != nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("Unassign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_newMultiAddressAssigner_unsupported(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+	if _, err := newMultiAddressAssigner(context.TODO(), logger, types.CloudProviderAzure, &config.Config{AddressesPerNode: 2}); err == nil {
+		t.Error("newMultiAddressAssigner() on Azure error = nil, want error")
+	}
+}
@@ -2,15 +2,23 @@ package address
 
 import (
 	"context"
+	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/doitintl/kubeip/internal/cloud"
 	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
 	"github.com/doitintl/kubeip/internal/types"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/core"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
 )
 
 // ociAssigner is an Assigner implementation for Oracle Cloud Infrastructure.
@@ -20,10 +28,22 @@ type ociAssigner struct {
 	compartmentOCID string
 	instanceSvc     cloud.OCIInstanceService
 	networkSvc      cloud.OCINetworkService
+	// cloudClaims claims each public IP with its freeform tags before assigning it, instead of relying on a lease lock
+	cloudClaims bool
+	// networkInterface selects the VNIC receiving the reserved public IP
+	networkInterface types.NetworkInterfaceSelector
+	// privateIPIndex selects the secondary private IP of the VNIC receiving the reserved public IP, the primary one if zero
+	privateIPIndex int
 }
 
 // NewOCIAssigner creates a new Assigner for Oracle Cloud Infrastructure.
-func NewOCIAssigner(_ context.Context, logger *logrus.Entry, cfg *config.Config) (Assigner, error) {
+func NewOCIAssigner(ctx context.Context, logger *logrus.Entry, cfg *config.Config) (Assigner, error) {
+	return newOCIAssigner(ctx, logger, cfg, 0)
+}
+
+// newOCIAssigner creates an Assigner for Oracle Cloud Infrastructure assigning the reserved public IP to the private IP
+// of the VNIC at privateIPIndex: the primary private IP, then the secondary private IPs in creation order.
+func newOCIAssigner(_ context.Context, logger *logrus.Entry, cfg *config.Config, privateIPIndex int) (*ociAssigner, error) {
 	logger.WithFields(
 		logrus.Fields{
 			"compartmentOCID": cfg.Project,
@@ -32,7 +52,7 @@ func NewOCIAssigner(_ context.Context, logger *logrus.Entry, cfg *config.Config)
 	).Info("creating new OCI assigner with given config")
 
 	// Parse the filters
-	filters, err := parseOCIFilters(cfg)
+	filters, err := parseOCIFilters(cfg.Filter)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse OCI filters")
 	}
@@ -53,29 +73,41 @@ func NewOCIAssigner(_ context.Context, logger *logrus.Entry, cfg *config.Config)
 	}
 
 	return &ociAssigner{
-		logger:          logger,
-		filters:         filters,
-		instanceSvc:     computeSvc,
-		networkSvc:      networkSvc,
-		compartmentOCID: cfg.Project,
+		logger:           logger,
+		filters:          filters,
+		instanceSvc:      computeSvc,
+		networkSvc:       networkSvc,
+		compartmentOCID:  cfg.Project,
+		cloudClaims:      cfg.LockScope == types.LockScopeCloud,
+		networkInterface: cfg.NetworkInterface,
+		privateIPIndex:   privateIPIndex,
 	}, nil
 }
 
 // Assign assigns reserved Public IP to the instance.
 // If the instance already has a public IP assigned, and it is from the reserved list, it returns the same IP.
 // Else it assigns a new public IP from the reserved list.
-func (a *ociAssigner) Assign(ctx context.Context, instanceOCID, _ string, _ []string, _ string) (string, error) {
+// The reserved list is selected by the filter, for example the one of a static IP pool, or by the configured filter if empty.
+func (a *ociAssigner) Assign(ctx context.Context, instanceOCID, _ string, filter []string, _ string) (string, error) {
 	a.logger.WithField("instanceOCID", instanceOCID).Debug("starting process to assign reserved public IP to instance")
 
+	filters := a.filters
+	if len(filter) > 0 {
+		var err error
+		if filters, err = parseOCIFilters(filter); err != nil {
+			return "", errors.Wrap(err, "failed to parse OCI filters")
+		}
+	}
+
 	// Get the primary VNIC
-	vnic, err := a.getPrimaryVnicOfInstance(ctx, instanceOCID)
+	vnic, secondaryIP, err := a.getTargetOfInstance(ctx, instanceOCID)
 	if err != nil {
 		return "", err
 	}
 	a.logger.WithField("primaryVnicOCID", *vnic.Id).Debugf("got primary VNIC of the instance %s", instanceOCID)
 
 	// Handle already assigned public IP case
-	alreadyAssigned, err := a.handlePublicIPAlreadyAssignedCase(ctx, vnic)
+	alreadyAssigned, err := a.handlePublicIPAlreadyAssignedCase(ctx, vnic, filters)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to check if public ip is already assigned or not")
 	}
@@ -84,26 +116,48 @@ func (a *ociAssigner) Assign(ctx context.Context, instanceOCID, _ string, _ []st
 		return *vnic.PublicIp, ErrStaticIPAlreadyAssigned
 	}
 
-	// Get primary VNIC private IP
-	privateIP, err := a.networkSvc.GetPrimaryPrivateIPOfVnic(ctx, *vnic.Id)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to get primary VNIC private IP")
+	// Get primary VNIC private IP, unless assigning to a secondary private IP
+	privateIP := secondaryIP
+	if privateIP == nil {
+		privateIP, err = a.networkSvc.GetPrimaryPrivateIPOfVnic(ctx, *vnic.Id)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get primary VNIC private IP")
+		}
 	}
 	a.logger.WithField("privateIPOCID", *privateIP.Id).Debugf("got primary VNIC private IP of the instance %s", instanceOCID)
 
 	// Fetch all available reserved Public IPs that will be used for assignment
-	reservedPublicIPList, err := a.fetchPublicIps(ctx, true, false)
+	reservedPublicIPList, err := a.fetchPublicIps(ctx, filters, false)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to get list of reserved public IPs")
 	}
+	metrics.FreeAddresses.WithLabelValues(string(types.CloudProviderOCI)).Set(float64(len(reservedPublicIPList)))
 	if len(reservedPublicIPList) == 0 {
-		return "", errors.New("no reserved public IPs available")
+		return "", noAvailableStaticIPError("no reserved public IPs available")
 	}
 	a.logger.WithField("reservedPublicIpList", reservedPublicIPList).Debug("got list of available reserved public IPs")
 
 	// Try to assign an IP from the reserved public IP list
 	for _, publicIP := range reservedPublicIPList {
-		if err = a.tryAssignAddress(ctx, *privateIP.Id, *publicIP.Id); err == nil {
+		// claim the address when the lock scope is address; skip the addresses claimed by other nodes
+		claimCtx, release, claimErr := lease.ClaimAddress(ctx, *publicIP.IpAddress)
+		if claimErr != nil {
+			a.logger.WithError(claimErr).Debug("skipping reserved public IP")
+			continue
+		}
+		events.Eventf(claimCtx, corev1.EventTypeNormal, events.ReasonAddressSelected, "Selected reserved public IP %s", *publicIP.IpAddress)
+		// check the lock is still held before assigning the reserved public IP
+		if err = lease.CheckHeld(claimCtx); err != nil {
+			release()
+			return "", errors.Wrap(err, "failed to check lock before assigning reserved public IP")
+		}
+		if a.cloudClaims {
+			err = a.tryClaimAndAssignAddress(claimCtx, instanceOCID, *privateIP.Id, *publicIP.Id)
+		} else {
+			err = a.tryAssignAddress(claimCtx, *privateIP.Id, *publicIP.Id)
+		}
+		release()
+		if err == nil {
 			a.logger.WithField("assignedIP", *publicIP.IpAddress).Infof("assigned IP %s to instance %s", *publicIP.IpAddress, instanceOCID)
 			return *publicIP.IpAddress, nil
 		}
@@ -114,13 +168,14 @@ func (a *ociAssigner) Assign(ctx context.Context, instanceOCID, _ string, _ []st
 }
 
 // Unassign unassigns the public IP from the instance.
-// If assigned public IP is from the reserved public IP list, it unassigns the public IP.
+// If assigned public IP is a reserved public IP, it unassigns the public IP; the filter is not applied, since the public
+// IP may have been selected by the filter of a static IP pool.
 // Else it does nothing.
 func (a *ociAssigner) Unassign(ctx context.Context, instanceOCID, _ string) error {
 	a.logger.WithField("instanceOCID", instanceOCID).Debug("starting process to unassign public IP from the instance")
 
 	// Get the primary VNIC
-	vnic, err := a.getPrimaryVnicOfInstance(ctx, instanceOCID)
+	vnic, _, err := a.getTargetOfInstance(ctx, instanceOCID)
 	if err != nil {
 		return err
 	}
@@ -133,7 +188,7 @@ func (a *ociAssigner) Unassign(ctx context.Context, instanceOCID, _ string) erro
 	publicIP := vnic.PublicIp
 
 	// Fetch assigned public IPs
-	reservedPublicIPList, err := a.fetchPublicIps(ctx, true, true)
+	reservedPublicIPList, err := a.fetchPublicIps(ctx, nil, true)
 	if err != nil {
 		return errors.Wrap(err, "failed to get list of reserved public IPs")
 	}
@@ -170,6 +225,85 @@ func (a *ociAssigner) getPrimaryVnicOfInstance(ctx context.Context, instanceOCID
 	return vnic, nil
 }
 
+// getVnicOfInstance returns the VNIC of the instance receiving the reserved public IP: the primary VNIC, or the VNIC
+// selected by the network interface selector. The VNIC index is its attachment order, the primary VNIC first.
+func (a *ociAssigner) getVnicOfInstance(ctx context.Context, instanceOCID string) (*core.Vnic, error) {
+	if a.networkInterface.IsPrimary() {
+		return a.getPrimaryVnicOfInstance(ctx, instanceOCID)
+	}
+
+	vnicAttachments, err := a.instanceSvc.ListVnicAttachments(ctx, a.compartmentOCID, instanceOCID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list VNIC attachments")
+	}
+	attachments := make([]core.VnicAttachment, 0, len(vnicAttachments))
+	for _, attachment := range vnicAttachments {
+		if attachment.VnicId != nil {
+			attachments = append(attachments, attachment)
+		}
+	}
+	sort.SliceStable(attachments, func(i, j int) bool {
+		if attachments[i].TimeCreated == nil || attachments[j].TimeCreated == nil {
+			return attachments[j].TimeCreated != nil
+		}
+		return attachments[i].TimeCreated.Before(attachments[j].TimeCreated.Time)
+	})
+
+	for index, attachment := range attachments {
+		vnic, err := a.networkSvc.GetVnic(ctx, *attachment.VnicId)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get VNIC")
+		}
+		if a.networkInterface.Match(index, ptr.Deref(vnic.DisplayName, ""), ptr.Deref(vnic.SubnetId, ""), vnic.FreeformTags) {
+			return vnic, nil
+		}
+	}
+
+	return nil, errors.Errorf("no VNIC matching %s found on instance %s", a.networkInterface, instanceOCID)
+}
+
+// getTargetOfInstance returns the VNIC of the instance receiving the reserved public IP and, if privateIPIndex is set,
+// its secondary private IP; the returned VNIC then carries the public IP of the secondary private IP instead of the
+// public IP of the primary private IP.
+func (a *ociAssigner) getTargetOfInstance(ctx context.Context, instanceOCID string) (*core.Vnic, *core.PrivateIp, error) {
+	vnic, err := a.getVnicOfInstance(ctx, instanceOCID)
+	if err != nil || a.privateIPIndex == 0 {
+		return vnic, nil, err
+	}
+
+	privateIPs, err := a.networkSvc.ListPrivateIPsOfVnic(ctx, *vnic.Id)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list VNIC private IPs")
+	}
+	secondaryIPs := make([]core.PrivateIp, 0, len(privateIPs))
+	for _, privateIP := range privateIPs {
+		if !ptr.Deref(privateIP.IsPrimary, false) {
+			secondaryIPs = append(secondaryIPs, privateIP)
+		}
+	}
+	if len(secondaryIPs) < a.privateIPIndex {
+		return nil, nil, errors.Errorf("VNIC %s has %d secondary private IPs, at least %d needed", *vnic.Id, len(secondaryIPs), a.privateIPIndex)
+	}
+	sort.SliceStable(secondaryIPs, func(i, j int) bool {
+		if secondaryIPs[i].TimeCreated == nil || secondaryIPs[j].TimeCreated == nil {
+			return secondaryIPs[j].TimeCreated != nil
+		}
+		return secondaryIPs[i].TimeCreated.Before(secondaryIPs[j].TimeCreated.Time)
+	})
+	secondaryIP := secondaryIPs[a.privateIPIndex-1]
+
+	publicIP, err := a.networkSvc.GetPublicIPOfPrivateIP(ctx, *secondaryIP.Id)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get public IP of secondary private IP")
+	}
+	target := *vnic
+	target.PublicIp = nil
+	if publicIP != nil {
+		target.PublicIp = publicIP.IpAddress
+	}
+	return &target, &secondaryIP, nil
+}
+
 // handlePublicIPAlreadyAssignedCase handles the case when the public IP is already assigned to the instance.
 // It returns true if the public IP is already assigned to the instance from the reserved IP list. In this case, do nothing.
 // It returns false in all other cases with error(if any). In this case, if err is nil, try to assign a new public IP.
@@ -180,27 +314,21 @@ func (a *ociAssigner) getPrimaryVnicOfInstance(ctx context.Context, instanceOCID
 //   - Case4: Unhandled case: Return error
 //
 //nolint:gocognit
-func (a *ociAssigner) handlePublicIPAlreadyAssignedCase(ctx context.Context, vnic *core.Vnic) (bool, error) {
+func (a *ociAssigner) handlePublicIPAlreadyAssignedCase(ctx context.Context, vnic *core.Vnic, filters *types.OCIFilters) (bool, error) {
 	if vnic == nil {
 		return false, nil
 	}
 	publicIP := vnic.PublicIp
 	if publicIP != nil {
 		// Case1
-		// Fetch all reserved public IPs that are assigned to the private IPs
-		list, err := a.fetchPublicIps(ctx, true, true)
-		if err != nil {
-			return false, errors.Wrap(err, "failed to list reserved public IPs assigned to private IP")
-		}
-		for _, ip := range list {
-			if *ip.IpAddress == *publicIP {
-				return true, nil
-			}
+		reserved, err := a.isReservedPublicIPAssigned(ctx, *publicIP, filters)
+		if err != nil || reserved {
+			return reserved, err
 		}
 
 		// Case2
 		// Fetch all public IPs that are assigned to the private IPs
-		list, err = a.fetchPublicIps(ctx, false, true)
+		list, err := a.fetchPublicIps(ctx, nil, true)
 		if err != nil {
 			return false, errors.Wrap(err, "failed to list public IPs assigned to private IP")
 		}
@@ -241,16 +369,26 @@ func (a *ociAssigner) handlePublicIPAlreadyAssignedCase(ctx context.Context, vni
 	return false, nil
 }
 
+// isReservedPublicIPAssigned returns true if the public IP is a reserved public IP matching the filters assigned to a private IP.
+func (a *ociAssigner) isReservedPublicIPAssigned(ctx context.Context, publicIP string, filters *types.OCIFilters) (bool, error) {
+	// Fetch all reserved public IPs that are assigned to the private IPs
+	list, err := a.fetchPublicIps(ctx, filters, true)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list reserved public IPs assigned to private IP")
+	}
+	for _, ip := range list {
+		if *ip.IpAddress == publicIP {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // fetchPublicIps returns the list of public IPs.
-// If useFilter is set to true, it applies the filters.
+// It applies the filters unless nil.
 // It returns only available public IPs if inUse is set to false.
 // It returns only assigned public IPs if inUse is set to true.
-func (a *ociAssigner) fetchPublicIps(ctx context.Context, useFilter, inUse bool) ([]core.PublicIp, error) {
-	filters := a.filters
-	// If useFilter is set to false, do not apply the filters
-	if !useFilter {
-		filters = nil
-	}
+func (a *ociAssigner) fetchPublicIps(ctx context.Context, filters *types.OCIFilters, inUse bool) ([]core.PublicIp, error) {
 	list, err := a.networkSvc.ListPublicIps(ctx, &core.ListPublicIpsRequest{
 		Scope:         core.ListPublicIpsScopeRegion,
 		CompartmentId: common.String(a.compartmentOCID),
@@ -314,20 +452,72 @@ func (a *ociAssigner) tryAssignAddress(ctx context.Context, privateIPOCID, publi
 	return nil
 }
 
-// ParseOCIFilters parses the filters for OCI from the config.
+// tryClaimAndAssignAddress claims the public IP with its freeform tags, using the ETag as compare-and-swap, and assigns
+// it only if the ETag of the claim still matches; the assignment is verified and the claim removed once the public IP
+// is assigned or failed to assign.
+func (a *ociAssigner) tryClaimAndAssignAddress(ctx context.Context, instanceOCID, privateIPOCID, publicIPOCID string) error {
+	publicIP, etag, err := a.networkSvc.GetPublicIPWithETag(ctx, publicIPOCID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get public IP details")
+	}
+	if publicIP.LifecycleState != core.PublicIpLifecycleStateAvailable {
+		return errors.New("public IP is not available")
+	}
+	now := time.Now()
+	if !claimable(publicIP.FreeformTags, instanceOCID, now) {
+		return errors.Wrapf(lease.ErrAddressClaimed, "public IP is claimed by %s", publicIP.FreeformTags[claimKey])
+	}
+	etag, err = a.networkSvc.UpdatePublicIPTags(ctx, publicIPOCID, etag, withClaim(publicIP.FreeformTags, instanceOCID, now))
+	if isETagMismatch(err) {
+		return errors.Wrap(lease.ErrAddressClaimed, "public IP was claimed concurrently")
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to claim public IP")
+	}
+	defer a.releaseClaim(ctx, publicIPOCID, instanceOCID)
+
+	// the assignment fails if the public IP changed since it was claimed
+	if err = a.networkSvc.UpdatePublicIPIfMatch(ctx, publicIPOCID, privateIPOCID, etag); err != nil {
+		return errors.Wrap(err, "failed to assign public IP")
+	}
+
+	// verify the public IP is assigned to the private IP of the instance
+	publicIP, err = a.networkSvc.GetPublicIP(ctx, publicIPOCID)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify public IP")
+	}
+	if publicIP.AssignedEntityId == nil || *publicIP.AssignedEntityId != privateIPOCID {
+		return errors.Errorf("claimed public IP is not assigned to instance %s", instanceOCID)
+	}
+	return nil
+}
+
+// releaseClaim removes the claim of the holder from the public IP freeform tags; a claim left behind goes stale after claimTTL
+func (a *ociAssigner) releaseClaim(ctx context.Context, publicIPOCID, holder string) {
+	publicIP, etag, err := a.networkSvc.GetPublicIPWithETag(ctx, publicIPOCID)
+	if err == nil && claimedBy(publicIP.FreeformTags, holder) {
+		_, err = a.networkSvc.UpdatePublicIPTags(ctx, publicIPOCID, etag, withoutClaim(publicIP.FreeformTags))
+	}
+	if err != nil {
+		a.logger.WithError(err).WithField("publicIPOCID", publicIPOCID).Warn("failed to release public IP claim")
+	}
+}
+
+func isETagMismatch(err error) bool {
+	var serviceErr common.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusPreconditionFailed
+}
+
+// ParseOCIFilters parses the filters for OCI.
 // All filters of freeformTags are combined with AND condition.
 // All filters of definedTags are combined with AND condition.
 // Filter should be in following format:
 //   - "freeformTags.key1=value1"
 //   - "definedTags.Namespace.key1=value1"
-func parseOCIFilters(cfg *config.Config) (*types.OCIFilters, error) {
-	if cfg == nil {
-		return nil, errors.New("config is nil")
-	}
-
+func parseOCIFilters(filters []string) (*types.OCIFilters, error) {
 	freeformTags := make(map[string]string)
 
-	for _, filter := range cfg.Filter {
+	for _, filter := range filters {
 		if strings.HasPrefix(filter, "freeformTags.") {
 			key, value, err := types.ParseFreeformTagFilter(filter)
 			if err != nil {
@@ -343,3 +533,30 @@ func parseOCIFilters(cfg *config.Config) (*types.OCIFilters, error) {
 		FreeformTags: freeformTags,
 	}, nil
 }
+
+// GetResourceID returns the OCID of the reserved public IP.
+func (a *ociAssigner) GetResourceID(ctx context.Context, address string) (string, error) {
+	list, err := a.fetchPublicIps(ctx, nil, true)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list assigned public IPs")
+	}
+	for _, ip := range list {
+		if ip.IpAddress != nil && *ip.IpAddress == address && ip.Id != nil {
+			return *ip.Id, nil
+		}
+	}
+	return "", ErrStaticIPNotFound
+}
+
+// IsAssigned returns true if the reserved public IP is still assigned to the primary VNIC of the instance; the filter is
+// not applied, since the public IP may have been selected by the filter of a static IP pool.
+func (a *ociAssigner) IsAssigned(ctx context.Context, instanceOCID, _, address string) (bool, error) {
+	vnic, _, err := a.getTargetOfInstance(ctx, instanceOCID)
+	if err != nil {
+		return false, err
+	}
+	if vnic.PublicIp == nil || *vnic.PublicIp != address {
+		return false, nil
+	}
+	return a.isReservedPublicIPAssigned(ctx, address, nil)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/doitintl/kubeip/api/v1alpha1"
 	"github.com/doitintl/kubeip/internal/cloud"
-	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/pool"
 	"github.com/doitintl/kubeip/internal/types"
 	cmocks "github.com/doitintl/kubeip/mocks/cloud"
 	"github.com/oracle/oci-go-sdk/v65/common"
@@ -14,6 +16,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func matchErr(err1 error, err2 error) bool {
@@ -298,6 +303,66 @@ func Test_ociAssigner_Assign(t *testing.T) {
 	}
 }
 
+func Test_ociAssigner_Assign_staticIPPool(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&v1alpha1.StaticIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool-a"},
+		Spec: v1alpha1.StaticIPPoolSpec{
+			Selector: v1alpha1.StaticIPPoolSelector{OCI: &v1alpha1.OCIPoolSelector{FreeformTags: map[string]string{"pool": "a"}}},
+		},
+	}).Build()
+	selection, err := pool.NewResolver(c, []string{"freeformTags.kubeip=reserved"}, "").Resolve(context.TODO(), &types.Node{Cloud: types.CloudProviderOCI})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	instanceSvc := cmocks.NewOCIInstanceService(t)
+	instanceSvc.EXPECT().ListVnicAttachments(mock.Anything, "test-compartment-id", "test-instance-id").Return([]core.VnicAttachment{
+		{VnicId: common.String("test-vnic-id")},
+	}, nil).Once()
+	networkSvc := cmocks.NewOCINetworkService(t)
+	networkSvc.EXPECT().GetPrimaryVnic(mock.Anything, mock.Anything).Return(&core.Vnic{Id: common.String("test-vnic-id")}, nil).Once()
+	networkSvc.EXPECT().GetPrimaryPrivateIPOfVnic(mock.Anything, mock.Anything).Return(&core.PrivateIp{Id: common.String("test-private-ip-id")}, nil).Once()
+	// the network service applies the filters to the reserved public IPs
+	networkSvc.EXPECT().ListPublicIps(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(
+		func(_ context.Context, _ *core.ListPublicIpsRequest, filters *types.OCIFilters) ([]core.PublicIp, error) {
+			var list []core.PublicIp
+			for _, ip := range []core.PublicIp{
+				{Id: common.String("global-ip"), IpAddress: common.String("1.1.1.1"), LifecycleState: core.PublicIpLifecycleStateAvailable,
+					FreeformTags: map[string]string{"kubeip": "reserved"}},
+				{Id: common.String("pool-ip"), IpAddress: common.String("2.2.2.2"), LifecycleState: core.PublicIpLifecycleStateAvailable,
+					FreeformTags: map[string]string{"pool": "a"}},
+			} {
+				if filters.CheckFreeformTagFilter(ip.FreeformTags) {
+					list = append(list, ip)
+				}
+			}
+			return list, nil
+		}).Once()
+	networkSvc.EXPECT().GetPublicIP(mock.Anything, "pool-ip").Return(&core.PublicIp{
+		Id: common.String("pool-ip"), IpAddress: common.String("2.2.2.2"), LifecycleState: core.PublicIpLifecycleStateAvailable,
+	}, nil).Once()
+	networkSvc.EXPECT().UpdatePublicIP(mock.Anything, "pool-ip", "test-private-ip-id").Return(nil).Once()
+
+	a := &ociAssigner{
+		logger:          logrus.NewEntry(logrus.New()),
+		instanceSvc:     instanceSvc,
+		networkSvc:      networkSvc,
+		compartmentOCID: "test-compartment-id",
+		filters:         &types.OCIFilters{FreeformTags: map[string]string{"kubeip": "reserved"}},
+	}
+	got, err := a.Assign(context.TODO(), "test-instance-id", "", selection.Filter, selection.OrderBy)
+	if err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if got != "2.2.2.2" {
+		t.Errorf("Assign() got = %v, want %v", got, "2.2.2.2")
+	}
+}
+
 func Test_ociAssigner_Unassign(t *testing.T) {
 	type args struct {
 		compartmentOCID string
@@ -591,6 +656,179 @@ func Test_ociAssigner_getPrimaryVnicOfInstance(t *testing.T) {
 	}
 }
 
+func Test_ociAssigner_getVnicOfInstance(t *testing.T) {
+	created := func(minute int) *common.SDKTime {
+		return &common.SDKTime{Time: time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC)}
+	}
+	// attachments are listed out of order: the primary VNIC was attached first
+	attachments := []core.VnicAttachment{
+		{VnicId: common.String("secondary-vnic-id"), TimeCreated: created(10)},
+		{VnicId: common.String("primary-vnic-id"), TimeCreated: created(0)},
+		{TimeCreated: created(20)},
+	}
+	vnics := map[string]*core.Vnic{
+		"primary-vnic-id": {
+			Id:          common.String("primary-vnic-id"),
+			DisplayName: common.String("primary"),
+			SubnetId:    common.String("private-subnet-id"),
+			IsPrimary:   common.Bool(true),
+		},
+		"secondary-vnic-id": {
+			Id:           common.String("secondary-vnic-id"),
+			DisplayName:  common.String("public"),
+			SubnetId:     common.String("public-subnet-id"),
+			FreeformTags: map[string]string{"role": "public"},
+		},
+	}
+	tests := []struct {
+		name     string
+		selector string
+		want     *core.Vnic
+		wantErr  bool
+	}{
+		{
+			name:     "primary VNIC",
+			selector: "",
+			want:     vnics["primary-vnic-id"],
+		},
+		{
+			name:     "by attachment order",
+			selector: "index:1",
+			want:     vnics["secondary-vnic-id"],
+		},
+		{
+			name:     "by display name",
+			selector: "name:primary",
+			want:     vnics["primary-vnic-id"],
+		},
+		{
+			name:     "by subnet",
+			selector: "subnet:public-subnet-id",
+			want:     vnics["secondary-vnic-id"],
+		},
+		{
+			name:     "by freeform tag",
+			selector: "tag:role=public",
+			want:     vnics["secondary-vnic-id"],
+		},
+		{
+			name:     "no matching VNIC",
+			selector: "index:2",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := types.ParseNetworkInterfaceSelector(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			instanceSvc := cmocks.NewOCIInstanceService(t)
+			instanceSvc.EXPECT().ListVnicAttachments(mock.Anything, "test-compartment-id", "test-instance-id").Return(attachments, nil).Once()
+			networkSvc := cmocks.NewOCINetworkService(t)
+			if selector.IsPrimary() {
+				networkSvc.EXPECT().GetPrimaryVnic(mock.Anything, attachments).Return(vnics["primary-vnic-id"], nil).Once()
+			} else {
+				networkSvc.EXPECT().GetVnic(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, vnicOCID string) (*core.Vnic, error) {
+					return vnics[vnicOCID], nil
+				}).Maybe()
+			}
+			a := &ociAssigner{
+				instanceSvc:      instanceSvc,
+				networkSvc:       networkSvc,
+				compartmentOCID:  "test-compartment-id",
+				networkInterface: selector,
+			}
+			got, err := a.getVnicOfInstance(context.TODO(), "test-instance-id")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getVnicOfInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getVnicOfInstance() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ociAssigner_getTargetOfInstance(t *testing.T) {
+	created := func(minute int) *common.SDKTime {
+		return &common.SDKTime{Time: time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC)}
+	}
+	vnic := &core.Vnic{Id: common.String("vnic-id"), PublicIp: common.String("100.0.0.1")}
+	privateIPs := []core.PrivateIp{
+		{Id: common.String("secondary-ip-2"), IsPrimary: common.Bool(false), TimeCreated: created(20)},
+		{Id: common.String("primary-ip"), IsPrimary: common.Bool(true), TimeCreated: created(0)},
+		{Id: common.String("secondary-ip-1"), IsPrimary: common.Bool(false), TimeCreated: created(10)},
+	}
+	tests := []struct {
+		name           string
+		privateIPIndex int
+		publicIP       *core.PublicIp
+		wantPrivateIP  string
+		wantPublicIP   *string
+		wantErr        bool
+	}{
+		{
+			name:           "primary private IP",
+			privateIPIndex: 0,
+			wantPublicIP:   common.String("100.0.0.1"),
+		},
+		{
+			name:           "secondary private IP with public IP",
+			privateIPIndex: 1,
+			publicIP:       &core.PublicIp{IpAddress: common.String("100.0.0.2")},
+			wantPrivateIP:  "secondary-ip-1",
+			wantPublicIP:   common.String("100.0.0.2"),
+		},
+		{
+			name:           "secondary private IP without public IP",
+			privateIPIndex: 2,
+			wantPrivateIP:  "secondary-ip-2",
+		},
+		{
+			name:           "missing secondary private IP",
+			privateIPIndex: 3,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceSvc := cmocks.NewOCIInstanceService(t)
+			instanceSvc.EXPECT().ListVnicAttachments(mock.Anything, "test-compartment-id", "test-instance-id").Return([]core.VnicAttachment{{VnicId: vnic.Id}}, nil).Once()
+			networkSvc := cmocks.NewOCINetworkService(t)
+			networkSvc.EXPECT().GetPrimaryVnic(mock.Anything, mock.Anything).Return(vnic, nil).Once()
+			if tt.privateIPIndex > 0 {
+				networkSvc.EXPECT().ListPrivateIPsOfVnic(mock.Anything, "vnic-id").Return(privateIPs, nil).Once()
+			}
+			if tt.wantPrivateIP != "" {
+				networkSvc.EXPECT().GetPublicIPOfPrivateIP(mock.Anything, tt.wantPrivateIP).Return(tt.publicIP, nil).Once()
+			}
+			a := &ociAssigner{
+				instanceSvc:     instanceSvc,
+				networkSvc:      networkSvc,
+				compartmentOCID: "test-compartment-id",
+				privateIPIndex:  tt.privateIPIndex,
+			}
+			got, privateIP, err := a.getTargetOfInstance(context.TODO(), "test-instance-id")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getTargetOfInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantPrivateIP == "" && privateIP != nil || tt.wantPrivateIP != "" && (privateIP == nil || *privateIP.Id != tt.wantPrivateIP) {
+				t.Errorf("getTargetOfInstance() private IP = %v, want %v", privateIP, tt.wantPrivateIP)
+			}
+			if !reflect.DeepEqual(got.PublicIp, tt.wantPublicIP) {
+				t.Errorf("getTargetOfInstance() public IP = %v, want %v", got.PublicIp, tt.wantPublicIP)
+			}
+		})
+	}
+	if *vnic.PublicIp != "100.0.0.1" {
+		t.Errorf("getTargetOfInstance() changed the public IP of the VNIC to %s", *vnic.PublicIp)
+	}
+}
+
 func Test_ociAssigner_handlePublicIPAlreadyAssignedCase(t *testing.T) {
 	type args struct {
 		vnic            *core.Vnic
@@ -880,7 +1118,7 @@ func Test_ociAssigner_handlePublicIPAlreadyAssignedCase(t *testing.T) {
 				compartmentOCID: tt.args.compartmentOCID,
 				filters:         tt.args.filter,
 			}
-			got, err := a.handlePublicIPAlreadyAssignedCase(context.TODO(), tt.args.vnic)
+			got, err := a.handlePublicIPAlreadyAssignedCase(context.TODO(), tt.args.vnic, tt.args.filter)
 			if !matchErr(err, tt.wantErr) {
 				t.Errorf("handlePublicIPAlreadyAssignedCase() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -893,7 +1131,6 @@ func Test_ociAssigner_handlePublicIPAlreadyAssignedCase(t *testing.T) {
 
 func Test_ociAssigner_fetchPublicIps(t *testing.T) {
 	type args struct {
-		useFilter       bool
 		inUSe           bool
 		compartmentOCID string
 		filters         *types.OCIFilters
@@ -923,7 +1160,6 @@ func Test_ociAssigner_fetchPublicIps(t *testing.T) {
 				},
 			},
 			args: args{
-				useFilter:       false,
 				inUSe:           false,
 				compartmentOCID: "test-compartment-id",
 			},
@@ -946,7 +1182,6 @@ func Test_ociAssigner_fetchPublicIps(t *testing.T) {
 				},
 			},
 			args: args{
-				useFilter:       true,
 				inUSe:           false,
 				compartmentOCID: "test-compartment-id",
 				filters: &types.OCIFilters{
@@ -972,7 +1207,6 @@ func Test_ociAssigner_fetchPublicIps(t *testing.T) {
 				},
 			},
 			args: args{
-				useFilter:       false,
 				inUSe:           true,
 				compartmentOCID: "test-compartment-id",
 			},
@@ -993,7 +1227,6 @@ func Test_ociAssigner_fetchPublicIps(t *testing.T) {
 				},
 			},
 			args: args{
-				useFilter:       false,
 				inUSe:           false,
 				compartmentOCID: "test-compartment-id",
 			},
@@ -1006,9 +1239,8 @@ func Test_ociAssigner_fetchPublicIps(t *testing.T) {
 			a := &ociAssigner{
 				networkSvc:      tt.fields.networkSvcFn(t, &tt.args),
 				compartmentOCID: tt.args.compartmentOCID,
-				filters:         tt.args.filters,
 			}
-			got, err := a.fetchPublicIps(context.TODO(), tt.args.useFilter, tt.args.inUSe)
+			got, err := a.fetchPublicIps(context.TODO(), tt.args.filters, tt.args.inUSe)
 			if !matchErr(err, tt.wantErr) {
 				t.Errorf("OCI fetchPublicIps() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -1213,42 +1445,38 @@ func Test_ociAssigner_tryAssignAddress(t *testing.T) {
 func Test_parseOCIFilters(t *testing.T) {
 	tests := []struct {
 		name    string
-		cfg     *config.Config
+		filter  []string
 		want    *types.OCIFilters
 		wantErr error
 	}{
 		{
-			name:    "no config",
-			wantErr: errors.New("config is nil"),
+			name: "no filter",
+			want: &types.OCIFilters{
+				FreeformTags: map[string]string{},
+			},
 		},
 		{
-			name: "valid freeformTags filter",
-			cfg: &config.Config{
-				Filter: []string{"freeformTags.key1=value1"},
-			},
+			name:   "valid freeformTags filter",
+			filter: []string{"freeformTags.key1=value1"},
 			want: &types.OCIFilters{
 				FreeformTags: map[string]string{"key1": "value1"},
 			},
 		},
 		{
-			name: "invalid freeformTags filter",
-			cfg: &config.Config{
-				Filter: []string{"freeformTags.key1value1"},
-			},
+			name:    "invalid freeformTags filter",
+			filter:  []string{"freeformTags.key1value1"},
 			wantErr: errors.New("failed to parse freeform tag filter: invalid filter format for freeform tags, should be in format freeformTags.key=value, found: freeformTags.key1value1"),
 		},
 		{
-			name: "invalid filter format",
-			cfg: &config.Config{
-				Filter: []string{"invalidFilter"},
-			},
+			name:    "invalid filter format",
+			filter:  []string{"invalidFilter"},
 			wantErr: errors.New("invalid filter format for OCI, should be in format freeformTags.key=value or definedTags.Namespace.key=value, found: invalidFilter"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseOCIFilters(tt.cfg)
+			got, err := parseOCIFilters(tt.filter)
 			if !matchErr(err, tt.wantErr) {
 				t.Errorf("parseOCIFilters() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/pkg/errors"
 )
 
@@ -50,3 +51,50 @@ func (a *eipAssigner) Unassign(ctx context.Context, associationID string) error
 
 	return nil
 }
+
+// EipTagger tags elastic IPs.
+type EipTagger interface {
+	// Tag adds or overwrites the tags of the elastic IP allocation
+	Tag(ctx context.Context, allocationID string, tags map[string]string) error
+	// Untag deletes the tags of the elastic IP allocation that still have the given values
+	Untag(ctx context.Context, allocationID string, tags map[string]string) error
+}
+
+type eipTagger struct {
+	client *ec2.Client
+}
+
+func NewEipTagger(client *ec2.Client) EipTagger {
+	return &eipTagger{client: client}
+}
+
+func (t *eipTagger) Tag(ctx context.Context, allocationID string, tags map[string]string) error {
+	_, err := t.client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{allocationID},
+		Tags:      toEc2Tags(tags),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to tag elastic IP")
+	}
+	return nil
+}
+
+func (t *eipTagger) Untag(ctx context.Context, allocationID string, tags map[string]string) error {
+	// tags with a value are only deleted if the tag has that value
+	_, err := t.client.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{allocationID},
+		Tags:      toEc2Tags(tags),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to untag elastic IP")
+	}
+	return nil
+}
+
+func toEc2Tags(tags map[string]string) []types.Tag {
+	ec2Tags := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		ec2Tags = append(ec2Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return ec2Tags
+}
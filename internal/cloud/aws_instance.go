@@ -3,6 +3,7 @@ package cloud
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/pkg/errors"
@@ -38,3 +39,35 @@ func (g *ec2InstanceGetter) Get(ctx context.Context, instanceID, _ string) (*typ
 
 	return &resp.Reservations[0].Instances[0], nil
 }
+
+// Ec2NetworkInterfaceLister lists the network interfaces attached to an instance, with their tags
+type Ec2NetworkInterfaceLister interface {
+	List(ctx context.Context, instanceID string) ([]types.NetworkInterface, error)
+}
+
+type ec2NetworkInterfaceLister struct {
+	client *ec2.Client
+}
+
+func NewEc2NetworkInterfaceLister(client *ec2.Client) Ec2NetworkInterfaceLister {
+	return &ec2NetworkInterfaceLister{client: client}
+}
+
+func (l *ec2NetworkInterfaceLister) List(ctx context.Context, instanceID string) ([]types.NetworkInterface, error) {
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []types.Filter{{
+			Name:   aws.String("attachment.instance-id"),
+			Values: []string{instanceID},
+		}},
+	}
+	var networkInterfaces []types.NetworkInterface
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(l.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list network interfaces of instance %s", instanceID)
+		}
+		networkInterfaces = append(networkInterfaces, page.NetworkInterfaces...)
+	}
+	return networkInterfaces, nil
+}
@@ -0,0 +1,114 @@
+package cloud
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/pkg/errors"
+)
+
+// Ipv6AddressLister lists the IPv6 subnet CIDR reservations and the network interfaces holding IPv6 addresses
+type Ipv6AddressLister interface {
+	// ListReservations lists the explicit IPv6 CIDR reservations of the subnet matching the filter
+	ListReservations(ctx context.Context, subnetID string, filter map[string][]string) ([]types.SubnetCidrReservation, error)
+	// ListNetworkInterfaces lists the network interfaces matching the filter
+	ListNetworkInterfaces(ctx context.Context, filter map[string][]string) ([]types.NetworkInterface, error)
+}
+
+type ipv6AddressLister struct {
+	client *ec2.Client
+}
+
+func NewIpv6AddressLister(client *ec2.Client) Ipv6AddressLister {
+	return &ipv6AddressLister{client: client}
+}
+
+func (l *ipv6AddressLister) ListReservations(ctx context.Context, subnetID string, filter map[string][]string) ([]types.SubnetCidrReservation, error) {
+	input := &ec2.GetSubnetCidrReservationsInput{
+		SubnetId: &subnetID,
+		Filters:  toEc2Filters(filter),
+	}
+	var reservations []types.SubnetCidrReservation
+	for {
+		resp, err := l.client.GetSubnetCidrReservations(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list CIDR reservations of subnet %s", subnetID)
+		}
+		for _, reservation := range resp.SubnetIpv6CidrReservations {
+			// prefix reservations are delegated to network interfaces as prefixes, not as single addresses
+			if reservation.ReservationType == types.SubnetCidrReservationTypeExplicit {
+				reservations = append(reservations, reservation)
+			}
+		}
+		if resp.NextToken == nil {
+			return reservations, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+func (l *ipv6AddressLister) ListNetworkInterfaces(ctx context.Context, filter map[string][]string) ([]types.NetworkInterface, error) {
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: toEc2Filters(filter),
+	}
+	var networkInterfaces []types.NetworkInterface
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(l.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list network interfaces")
+		}
+		networkInterfaces = append(networkInterfaces, page.NetworkInterfaces...)
+	}
+	return networkInterfaces, nil
+}
+
+// Ipv6AddressAssigner assigns IPv6 addresses to network interfaces
+type Ipv6AddressAssigner interface {
+	Assign(ctx context.Context, networkInterfaceID, address string) error
+	Unassign(ctx context.Context, networkInterfaceID, address string) error
+}
+
+type ipv6AddressAssigner struct {
+	client *ec2.Client
+}
+
+func NewIpv6AddressAssigner(client *ec2.Client) Ipv6AddressAssigner {
+	return &ipv6AddressAssigner{client: client}
+}
+
+func (a *ipv6AddressAssigner) Assign(ctx context.Context, networkInterfaceID, address string) error {
+	// the assignment fails if the address is already assigned to another network interface of the subnet
+	_, err := a.client.AssignIpv6Addresses(ctx, &ec2.AssignIpv6AddressesInput{
+		NetworkInterfaceId: &networkInterfaceID,
+		Ipv6Addresses:      []string{address},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to assign IPv6 address to the network interface")
+	}
+	return nil
+}
+
+func (a *ipv6AddressAssigner) Unassign(ctx context.Context, networkInterfaceID, address string) error {
+	_, err := a.client.UnassignIpv6Addresses(ctx, &ec2.UnassignIpv6AddressesInput{
+		NetworkInterfaceId: &networkInterfaceID,
+		Ipv6Addresses:      []string{address},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to unassign IPv6 address from the network interface")
+	}
+	return nil
+}
+
+func toEc2Filters(filter map[string][]string) []types.Filter {
+	filters := make([]types.Filter, 0, len(filter))
+	for k, v := range filter {
+		key := k
+		filters = append(filters, types.Filter{
+			Name:   &key,
+			Values: v,
+		})
+	}
+	return filters
+}
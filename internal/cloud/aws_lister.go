@@ -21,19 +21,9 @@ func NewEipLister(client *ec2.Client) EipLister {
 }
 
 func (l *eipLister) List(ctx context.Context, filter map[string][]string, inUse bool) ([]types.Address, error) {
-	// create filter for DescribeAddressesInput
-	filters := make([]types.Filter, 0, len(filter)+1)
-	for k, v := range filter {
-		key := k
-		filters = append(filters, types.Filter{
-			Name:   &key,
-			Values: v,
-		})
-	}
-
 	// list all elastic IPs in the region matching the filter
 	input := &ec2.DescribeAddressesInput{
-		Filters: filters,
+		Filters: toEc2Filters(filter),
 	}
 	list, err := l.client.DescribeAddresses(ctx, input)
 	if err != nil {
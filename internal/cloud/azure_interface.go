@@ -0,0 +1,119 @@
+package cloud
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	"github.com/pkg/errors"
+)
+
+// ErrAzureScaleSetVM is returned for instances of Uniform virtual machine scale sets: their network interfaces are
+// managed by the scale set model and cannot be updated individually.
+var ErrAzureScaleSetVM = errors.New("uniform virtual machine scale set instances are not supported, use standalone VMs or Flexible orchestration scale sets")
+
+// AzureInterfaceGetter is the interface for looking up the network interface of an Azure VM.
+type AzureInterfaceGetter interface {
+	Get(ctx context.Context, vmID string) (*armnetwork.Interface, error)
+}
+
+// AzureInterfaceUpdater is the interface for updating an Azure network interface.
+type AzureInterfaceUpdater interface {
+	Update(ctx context.Context, nic *armnetwork.Interface) error
+}
+
+// azureInterfaceGetter is the implementation of AzureInterfaceGetter.
+type azureInterfaceGetter struct {
+	client *armnetwork.InterfacesClient
+}
+
+// azureInterfaceUpdater is the implementation of AzureInterfaceUpdater.
+type azureInterfaceUpdater struct {
+	client *armnetwork.InterfacesClient
+}
+
+// NewAzureInterfaceGetter creates a new instance of AzureInterfaceGetter.
+func NewAzureInterfaceGetter(client *armnetwork.InterfacesClient) AzureInterfaceGetter {
+	return &azureInterfaceGetter{client: client}
+}
+
+// NewAzureInterfaceUpdater creates a new instance of AzureInterfaceUpdater.
+func NewAzureInterfaceUpdater(client *armnetwork.InterfacesClient) AzureInterfaceUpdater {
+	return &azureInterfaceUpdater{client: client}
+}
+
+// Get returns the primary network interface attached to the VM with the given resource ID.
+// Network interfaces are looked up in the resource group of the VM.
+func (g *azureInterfaceGetter) Get(ctx context.Context, vmID string) (*armnetwork.Interface, error) {
+	id, err := arm.ParseResourceID(vmID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse VM resource ID %s", vmID)
+	}
+	if IsAzureScaleSetVM(vmID) {
+		return nil, errors.Wrapf(ErrAzureScaleSetVM, "instance %s", vmID)
+	}
+
+	var attached []*armnetwork.Interface
+	pager := g.client.NewListPager(id.ResourceGroupName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list network interfaces in resource group %s", id.ResourceGroupName)
+		}
+		for _, nic := range page.Value {
+			if nic.Properties == nil || nic.Properties.VirtualMachine == nil || nic.Properties.VirtualMachine.ID == nil {
+				continue
+			}
+			if strings.EqualFold(*nic.Properties.VirtualMachine.ID, vmID) {
+				attached = append(attached, nic)
+			}
+		}
+	}
+
+	if len(attached) == 0 {
+		return nil, errors.Errorf("no network interfaces found for VM %s", vmID)
+	}
+	// VM with a single network interface does not always report it as primary
+	if len(attached) == 1 {
+		return attached[0], nil
+	}
+	for _, nic := range attached {
+		if nic.Properties.Primary != nil && *nic.Properties.Primary {
+			return nic, nil
+		}
+	}
+
+	return nil, errors.Errorf("no primary network interface found for VM %s", vmID)
+}
+
+// Update writes the given network interface and waits for the operation to complete.
+func (u *azureInterfaceUpdater) Update(ctx context.Context, nic *armnetwork.Interface) error {
+	if nic == nil || nic.ID == nil {
+		return errors.New("network interface ID is empty")
+	}
+	id, err := arm.ParseResourceID(*nic.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse network interface resource ID %s", *nic.ID)
+	}
+
+	poller, err := u.client.BeginCreateOrUpdate(ctx, id.ResourceGroupName, id.Name, *nic, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update network interface %s", id.Name)
+	}
+	if _, err = poller.PollUntilDone(ctx, nil); err != nil {
+		return errors.Wrapf(err, "failed waiting for network interface %s update", id.Name)
+	}
+
+	return nil
+}
+
+// IsAzureScaleSetVM checks if the resource ID is the ID of a Uniform virtual machine scale set instance.
+// Instances of Flexible orchestration scale sets are standalone VMs and have a virtual machine resource ID.
+func IsAzureScaleSetVM(resourceID string) bool {
+	id, err := arm.ParseResourceID(resourceID)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(id.ResourceType.String(), "Microsoft.Compute/virtualMachineScaleSets/virtualMachines")
+}
@@ -0,0 +1,46 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	azureMetadataURL = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01&format=json"
+)
+
+// AzureInstanceMetadata holds the compute metadata of the Azure VM the agent is running on.
+type AzureInstanceMetadata struct {
+	SubscriptionID    string `json:"subscriptionId"`
+	ResourceGroupName string `json:"resourceGroupName"`
+	Location          string `json:"location"`
+	ResourceID        string `json:"resourceId"`
+}
+
+// GetAzureInstanceMetadata queries the Azure Instance Metadata Service (IMDS) for the compute metadata.
+func GetAzureInstanceMetadata(ctx context.Context) (*AzureInstanceMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, azureMetadataURL, http.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create metadata request")
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query instance metadata service")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("instance metadata service returned status %d", resp.StatusCode)
+	}
+
+	var metadata AzureInstanceMetadata
+	if err = json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, errors.Wrap(err, "failed to decode instance metadata")
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,52 @@
+package cloud
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	"github.com/pkg/errors"
+)
+
+// AzurePublicIPLister is the interface for listing and fetching Azure public IP addresses.
+type AzurePublicIPLister interface {
+	List(ctx context.Context) ([]*armnetwork.PublicIPAddress, error)
+	Get(ctx context.Context, publicIPID string) (*armnetwork.PublicIPAddress, error)
+}
+
+// azurePublicIPLister is the implementation of AzurePublicIPLister.
+type azurePublicIPLister struct {
+	client *armnetwork.PublicIPAddressesClient
+}
+
+// NewAzurePublicIPLister creates a new instance of AzurePublicIPLister.
+func NewAzurePublicIPLister(client *armnetwork.PublicIPAddressesClient) AzurePublicIPLister {
+	return &azurePublicIPLister{client: client}
+}
+
+// List returns all public IP addresses in the subscription.
+func (l *azurePublicIPLister) List(ctx context.Context) ([]*armnetwork.PublicIPAddress, error) {
+	var addresses []*armnetwork.PublicIPAddress
+	pager := l.client.NewListAllPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list public IP addresses")
+		}
+		addresses = append(addresses, page.Value...)
+	}
+	return addresses, nil
+}
+
+// Get returns the public IP address with the given resource ID.
+func (l *azurePublicIPLister) Get(ctx context.Context, publicIPID string) (*armnetwork.PublicIPAddress, error) {
+	id, err := arm.ParseResourceID(publicIPID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public IP resource ID %s", publicIPID)
+	}
+	resp, err := l.client.Get(ctx, id.ResourceGroupName, id.Name, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get public IP address %s", id.Name)
+	}
+	return &resp.PublicIPAddress, nil
+}
@@ -0,0 +1,70 @@
+package cloud
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	"github.com/pkg/errors"
+)
+
+// AzurePublicIPPrefixManager is the interface for allocating Azure public IP addresses from a public IP prefix.
+type AzurePublicIPPrefixManager interface {
+	GetPrefix(ctx context.Context, prefixID string) (*armnetwork.PublicIPPrefix, error)
+	CreatePublicIP(ctx context.Context, resourceGroup, name string, address *armnetwork.PublicIPAddress) (*armnetwork.PublicIPAddress, error)
+	DeletePublicIP(ctx context.Context, publicIPID string) error
+}
+
+// azurePublicIPPrefixManager is the implementation of AzurePublicIPPrefixManager.
+type azurePublicIPPrefixManager struct {
+	prefixClient   *armnetwork.PublicIPPrefixesClient
+	publicIPClient *armnetwork.PublicIPAddressesClient
+}
+
+// NewAzurePublicIPPrefixManager creates a new instance of AzurePublicIPPrefixManager.
+func NewAzurePublicIPPrefixManager(prefixClient *armnetwork.PublicIPPrefixesClient, publicIPClient *armnetwork.PublicIPAddressesClient) AzurePublicIPPrefixManager {
+	return &azurePublicIPPrefixManager{prefixClient: prefixClient, publicIPClient: publicIPClient}
+}
+
+// GetPrefix returns the public IP prefix with the given resource ID.
+func (m *azurePublicIPPrefixManager) GetPrefix(ctx context.Context, prefixID string) (*armnetwork.PublicIPPrefix, error) {
+	id, err := arm.ParseResourceID(prefixID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public IP prefix resource ID %s", prefixID)
+	}
+	resp, err := m.prefixClient.Get(ctx, id.ResourceGroupName, id.Name, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get public IP prefix %s", id.Name)
+	}
+	return &resp.PublicIPPrefix, nil
+}
+
+// CreatePublicIP creates (or updates) the public IP address and waits for the operation to complete.
+// When the public IP references a prefix, Azure allocates the next free address from the prefix.
+func (m *azurePublicIPPrefixManager) CreatePublicIP(ctx context.Context, resourceGroup, name string, address *armnetwork.PublicIPAddress) (*armnetwork.PublicIPAddress, error) {
+	poller, err := m.publicIPClient.BeginCreateOrUpdate(ctx, resourceGroup, name, *address, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create public IP address %s", name)
+	}
+	resp, err := poller.PollUntilDone(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed waiting for public IP address %s creation", name)
+	}
+	return &resp.PublicIPAddress, nil
+}
+
+// DeletePublicIP deletes the public IP address with the given resource ID and waits for the operation to complete.
+func (m *azurePublicIPPrefixManager) DeletePublicIP(ctx context.Context, publicIPID string) error {
+	id, err := arm.ParseResourceID(publicIPID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse public IP resource ID %s", publicIPID)
+	}
+	poller, err := m.publicIPClient.BeginDelete(ctx, id.ResourceGroupName, id.Name, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete public IP address %s", id.Name)
+	}
+	if _, err = poller.PollUntilDone(ctx, nil); err != nil {
+		return errors.Wrapf(err, "failed waiting for public IP address %s deletion", id.Name)
+	}
+	return nil
+}
@@ -1,18 +1,22 @@
 package cloud
 
 import (
+	"github.com/pkg/errors"
 	"google.golang.org/api/compute/v1"
 )
 
 const (
-	ipv4Only = "IPV4_ONLY"
-	ipv4ipv6 = "IPV4_IPV6"
+	ipv4Only      = "IPV4_ONLY"
+	ipv4ipv6      = "IPV4_IPV6"
+	operationDone = "DONE"
 )
 
 type AddressManager interface {
 	AddAccessConfig(project string, zone string, instance string, networkInterface string, fingerprint string, accessconfig *compute.AccessConfig) (*compute.Operation, error)
 	DeleteAccessConfig(project string, zone string, instance string, accessConfig string, networkInterface string, fingerprint string) (*compute.Operation, error)
 	GetAddress(project, region, name string) (*compute.Address, error)
+	// SetAddressLabels replaces the labels of the address if its label fingerprint matches, and waits for the change
+	SetAddressLabels(project, region, name, fingerprint string, labels map[string]string) error
 }
 
 type addressManager struct {
@@ -52,3 +56,23 @@ func (m *addressManager) DeleteAccessConfig(project, zone, instance, accessConfi
 func (m *addressManager) GetAddress(project, region, name string) (*compute.Address, error) {
 	return m.client.Addresses.Get(project, region, name).Do() //nolint:wrapcheck
 }
+
+func (m *addressManager) SetAddressLabels(project, region, name, fingerprint string, labels map[string]string) error {
+	op, err := m.client.Addresses.SetLabels(project, region, name, &compute.RegionSetLabelsRequest{
+		LabelFingerprint: fingerprint, // the request fails with 412 Precondition Failed if the labels changed
+		Labels:           labels,
+	}).Do()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	opName := op.Name
+	for op.Status != operationDone {
+		if op, err = m.client.RegionOperations.Wait(project, region, opName).Do(); err != nil {
+			return errors.Wrapf(err, "failed to wait for operation %s", opName)
+		}
+	}
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return errors.Errorf("operation %s failed with error %s", opName, op.Error.Errors[0].Message)
+	}
+	return nil
+}
@@ -2,11 +2,13 @@ package cloud
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/doitintl/kubeip/internal/types"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/core"
 	"github.com/pkg/errors"
+	"k8s.io/utils/ptr"
 )
 
 // OCINetworkService is the interface for all network related operations in OCI (Virtual Network).
@@ -14,9 +16,21 @@ type OCINetworkService interface {
 	ListPublicIps(ctx context.Context, request *core.ListPublicIpsRequest, filters *types.OCIFilters) ([]core.PublicIp, error)
 	GetPublicIP(ctx context.Context, publicIPOCID string) (*core.PublicIp, error)
 	UpdatePublicIP(ctx context.Context, publicIPOCID, privateIPOCID string) error
+	// GetPublicIPWithETag returns the public IP with the given OCID and its ETag
+	GetPublicIPWithETag(ctx context.Context, publicIPOCID string) (*core.PublicIp, string, error)
+	// UpdatePublicIPTags replaces the freeform tags of the public IP if its ETag matches, and returns the new ETag
+	UpdatePublicIPTags(ctx context.Context, publicIPOCID, etag string, tags map[string]string) (string, error)
+	// UpdatePublicIPIfMatch assigns the public IP to the private IP if its ETag matches
+	UpdatePublicIPIfMatch(ctx context.Context, publicIPOCID, privateIPOCID, etag string) error
 	DeletePublicIP(ctx context.Context, publicIPOCID string) error
 	GetPrimaryPrivateIPOfVnic(ctx context.Context, vnicOCID string) (*core.PrivateIp, error)
 	GetPrimaryVnic(ctx context.Context, vnicAttachments []core.VnicAttachment) (*core.Vnic, error)
+	// GetVnic returns the VNIC with the given OCID
+	GetVnic(ctx context.Context, vnicOCID string) (*core.Vnic, error)
+	// ListPrivateIPsOfVnic returns the primary and secondary private IPs of the VNIC
+	ListPrivateIPsOfVnic(ctx context.Context, vnicOCID string) ([]core.PrivateIp, error)
+	// GetPublicIPOfPrivateIP returns the public IP assigned to the private IP, nil if none
+	GetPublicIPOfPrivateIP(ctx context.Context, privateIPOCID string) (*core.PublicIp, error)
 }
 
 // ociNetworkService is the implementation of OCINetworkService.
@@ -91,6 +105,56 @@ func (svc *ociNetworkService) UpdatePublicIP(ctx context.Context, publicIPOCID,
 	return nil
 }
 
+// GetPublicIPWithETag returns the public IP with the given OCID and its ETag.
+func (svc *ociNetworkService) GetPublicIPWithETag(ctx context.Context, publicIPOCID string) (*core.PublicIp, string, error) {
+	request := core.GetPublicIpRequest{
+		PublicIpId: common.String(publicIPOCID),
+	}
+	response, err := svc.client.GetPublicIp(ctx, request)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get details of public IP OCID: %s", publicIPOCID)
+	}
+
+	if response.PublicIp.Id == nil {
+		return nil, "", errors.Errorf("no public IP found with OCID %s", publicIPOCID)
+	}
+
+	return &response.PublicIp, ptr.Deref(response.Etag, ""), nil
+}
+
+// UpdatePublicIPTags replaces the freeform tags of the public IP with the given OCID if its ETag matches.
+func (svc *ociNetworkService) UpdatePublicIPTags(ctx context.Context, publicIPOCID, etag string, tags map[string]string) (string, error) {
+	request := core.UpdatePublicIpRequest{
+		PublicIpId: common.String(publicIPOCID),
+		IfMatch:    common.String(etag), // the request fails with 412 Precondition Failed if the public IP changed
+		UpdatePublicIpDetails: core.UpdatePublicIpDetails{
+			FreeformTags: tags,
+		},
+	}
+	response, err := svc.client.UpdatePublicIp(ctx, request)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to update public IP tags")
+	}
+
+	return ptr.Deref(response.Etag, ""), nil
+}
+
+// UpdatePublicIPIfMatch assigns the public IP with the given OCID to the private IP if its ETag matches.
+func (svc *ociNetworkService) UpdatePublicIPIfMatch(ctx context.Context, publicIPOCID, privateIPOCID, etag string) error {
+	request := core.UpdatePublicIpRequest{
+		PublicIpId: common.String(publicIPOCID),
+		IfMatch:    common.String(etag), // the request fails with 412 Precondition Failed if the public IP changed
+		UpdatePublicIpDetails: core.UpdatePublicIpDetails{
+			PrivateIpId: common.String(privateIPOCID),
+		},
+	}
+	if _, err := svc.client.UpdatePublicIp(ctx, request); err != nil {
+		return errors.Wrap(err, "failed to update public IP")
+	}
+
+	return nil
+}
+
 // DeletePublicIP deletes the public IP with the given OCID.
 func (svc *ociNetworkService) DeletePublicIP(ctx context.Context, publicIPOCID string) error {
 	request := core.DeletePublicIpRequest{
@@ -127,6 +191,44 @@ func (svc *ociNetworkService) GetPrimaryPrivateIPOfVnic(ctx context.Context, vni
 	return nil, errors.New("no primary private IP found for the VNIC %s" + vnicOCID)
 }
 
+// ListPrivateIPsOfVnic returns the primary and secondary private IPs of the VNIC.
+func (svc *ociNetworkService) ListPrivateIPsOfVnic(ctx context.Context, vnicOCID string) ([]core.PrivateIp, error) {
+	request := core.ListPrivateIpsRequest{
+		VnicId: common.String(vnicOCID),
+	}
+	var privateIPs []core.PrivateIp
+	for {
+		response, err := svc.client.ListPrivateIps(ctx, request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list private IPs for the VNIC %s", vnicOCID)
+		}
+		privateIPs = append(privateIPs, response.Items...)
+		if response.OpcNextPage == nil {
+			return privateIPs, nil
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+// GetPublicIPOfPrivateIP returns the public IP assigned to the private IP, nil if none.
+func (svc *ociNetworkService) GetPublicIPOfPrivateIP(ctx context.Context, privateIPOCID string) (*core.PublicIp, error) {
+	request := core.GetPublicIpByPrivateIpIdRequest{
+		GetPublicIpByPrivateIpIdDetails: core.GetPublicIpByPrivateIpIdDetails{
+			PrivateIpId: common.String(privateIPOCID),
+		},
+	}
+	response, err := svc.client.GetPublicIpByPrivateIpId(ctx, request)
+	if err != nil {
+		var serviceErr common.ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusNotFound {
+			return nil, nil //nolint:nilnil
+		}
+		return nil, errors.Wrapf(err, "failed to get public IP of private IP %s", privateIPOCID)
+	}
+
+	return &response.PublicIp, nil
+}
+
 // GetPrimaryVnic returns the primary VNIC from the given VNIC attachments.
 func (svc *ociNetworkService) GetPrimaryVnic(ctx context.Context, vnicAttachments []core.VnicAttachment) (*core.Vnic, error) {
 	for _, vnicAttachment := range vnicAttachments {
@@ -142,3 +244,13 @@ func (svc *ociNetworkService) GetPrimaryVnic(ctx context.Context, vnicAttachment
 
 	return nil, errors.New("no primary VNIC found from the given VNIC attachments")
 }
+
+// GetVnic returns the VNIC with the given OCID.
+func (svc *ociNetworkService) GetVnic(ctx context.Context, vnicOCID string) (*core.Vnic, error) {
+	response, err := svc.client.GetVnic(ctx, core.GetVnicRequest{VnicId: common.String(vnicOCID)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get VNIC details with OCID %s", vnicOCID)
+	}
+
+	return &response.Vnic, nil
+}
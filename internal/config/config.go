@@ -3,20 +3,59 @@ package config
 import (
 	"time"
 
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
 )
 
+// fileFlags are the flags that the configuration file can set
+var fileFlags = []string{"filter", "order-by", "retry-interval", "retry-attempts", "lease-duration", "lease-namespace", "log-level"}
+
+// ReassignPolicy is the policy applied when a reloaded filter excludes the assigned static public IP address
+type ReassignPolicy string
+
+const (
+	// ReassignPolicyKeep keeps the assigned static public IP address
+	ReassignPolicyKeep ReassignPolicy = "keep"
+	// ReassignPolicyReassign releases the assigned static public IP address and assigns one matching the new filter
+	ReassignPolicyReassign ReassignPolicy = "reassign"
+)
+
+// SliceFlagSeparator separates the values of the slice flags set with environment variables, for example FILTER
+// and TAINT_KEY. It is ";" instead of "," because AWS filter values can contain "," and the shorthand filter format
+// uses "," to separate Names and Values.
+const SliceFlagSeparator = ";"
+
 type Config struct {
+	// ConfigFile is the path to the YAML or JSON configuration file
+	ConfigFile string `json:"config"`
+	// ConfigMap is the namespace/name of the ConfigMap holding the configuration file
+	ConfigMap string `json:"config-map"`
+	// ConfigReloadInterval is the interval to check the configuration file for changes, disabled if zero
+	ConfigReloadInterval time.Duration `json:"config-reload-interval"`
+	// ReconcileInterval is the interval to check that the assigned static public IP address is still assigned, disabled if zero
+	ReconcileInterval time.Duration `json:"reconcile-interval"`
+	// ReassignPolicy is the policy applied when a reloaded filter excludes the assigned static public IP address
+	ReassignPolicy ReassignPolicy `json:"reassign-policy"`
+	// LogLevel is the log level
+	LogLevel string `json:"log-level"`
 	// KubeConfigPath is the path to the kubeconfig file
 	KubeConfigPath string `json:"kubeconfig"`
 	// NodeName is the name of the Kubernetes node
 	NodeName string `json:"node-name"`
-	// Project is the name of the GCP project or the AWS account ID or the OCI compartment OCID
+	// Project is the name of the GCP project or the AWS account ID or the Azure subscription ID or the OCI compartment OCID
 	Project string `json:"project"`
-	// Region is the name of the GCP region or the AWS region or the OCI region
+	// Region is the name of the GCP region or the AWS region or the Azure location or the OCI region
 	Region string `json:"region"`
 	// IPv6 support
 	IPv6 bool `json:"ipv6"`
+	// IPFamily is the IP family of the assigned addresses: ipv4, ipv6 or dual
+	IPFamily types.IPFamily `json:"ip-family"`
+	// NetworkInterface selects the network interface receiving the static public IP address, the primary one if zero
+	NetworkInterface types.NetworkInterfaceSelector `json:"network-interface"`
+	// AddressesPerNode is the number of static public IP addresses assigned to each node
+	AddressesPerNode int `json:"addresses-per-node"`
 	// DevelopMode mode
 	DevelopMode bool `json:"develop-mode"`
 	// Filter is the filter for the IP addresses
@@ -33,12 +72,53 @@ type Config struct {
 	LeaseDuration int `json:"lease-duration"`
 	// LeaseNamespace is the namespace of the kubernetes lease
 	LeaseNamespace string `json:"lease-namespace"`
-	// TaintKey is the taint key to remove from the node once the IP address is assigned
-	TaintKey string `json:"taint-key"`
+	// LockScope is the scope of the lease lock: cluster, pool or address
+	LockScope types.LockScope `json:"lock-scope"`
+	// TaintKeys are the taint keys added to the node until the IP address is assigned and removed once it is assigned
+	TaintKeys []string `json:"taint-key"`
+	// TaintEffect is the effect of the taints added to the node
+	TaintEffect corev1.TaintEffect `json:"taint-effect"`
+	// AzureDeletePrefixIP deletes public IPs allocated from an Azure public IP prefix once released
+	AzureDeletePrefixIP bool `json:"azure-delete-prefix-ip"`
+	// AwsRoleARN is the IAM role assumed to list and associate the AWS elastic IPs, in another account for example
+	AwsRoleARN string `json:"aws-role-arn"`
+	// AwsExternalID is the external ID passed when assuming the AwsRoleARN role
+	AwsExternalID string `json:"aws-external-id"`
+	// NodeSelector is the label selector of the nodes managed by the controller
+	NodeSelector string `json:"node-selector"`
+	// LeaderElection enables leader election for the controller
+	LeaderElection bool `json:"leader-election"`
+	// StaticIPPools enables looking up the address filter and order by from StaticIPPool resources
+	StaticIPPools bool `json:"static-ip-pools"`
+	// RecordAssignments records the static public IP address assigned to each node in a StaticIPAssignment resource
+	RecordAssignments bool `json:"record-assignments"`
+	// LabelNode labels and annotates the node with the assigned static public IP address
+	LabelNode bool `json:"label-node"`
+	// NodeCondition reports the static public IP address assignment in the StaticIPReady condition of the node
+	NodeCondition bool `json:"node-condition"`
+	// MetricsAddress is the address of the Prometheus metrics endpoint, disabled if empty
+	MetricsAddress string `json:"metrics-address"`
+	// HealthProbeAddress is the address of the liveness and readiness probes endpoint, disabled if empty
+	HealthProbeAddress string `json:"health-probe-address"`
+
+	// flags is the configuration from the flags and environment variables only
+	flags *Config
+	// file is the loaded configuration file
+	file *File
+	// flagSet are the file flags set on the command line or with environment variables
+	flagSet map[string]bool
 }
 
-func NewConfig(c *cli.Context) *Config {
+// NewConfig creates the configuration from the flags, the environment variables and the configuration file, in
+// this order of precedence, and validates it.
+func NewConfig(c *cli.Context) (*Config, error) {
 	var cfg Config
+	cfg.ConfigFile = c.String("config")
+	cfg.ConfigMap = c.String("config-map")
+	cfg.ConfigReloadInterval = c.Duration("config-reload-interval")
+	cfg.ReconcileInterval = c.Duration("reconcile-interval")
+	cfg.ReassignPolicy = ReassignPolicy(c.String("reassign-policy"))
+	cfg.LogLevel = c.String("log-level")
 	cfg.KubeConfigPath = c.String("kubeconfig")
 	cfg.NodeName = c.String("node-name")
 	cfg.DevelopMode = c.Bool("develop-mode")
@@ -48,10 +128,146 @@ func NewConfig(c *cli.Context) *Config {
 	cfg.OrderBy = c.String("order-by")
 	cfg.Project = c.String("project")
 	cfg.Region = c.String("region")
-	cfg.IPv6 = c.Bool("ipv6")
+	cfg.IPFamily = types.IPFamily(c.String("ip-family"))
+	if cfg.IPFamily == "" {
+		// the ipv6 flag selects the IP family when not set
+		cfg.IPFamily = types.IPFamilyIPv4
+		if c.Bool("ipv6") {
+			cfg.IPFamily = types.IPFamilyIPv6
+		}
+	}
+	cfg.IPv6 = cfg.IPFamily == types.IPFamilyIPv6
+	networkInterface, err := types.ParseNetworkInterfaceSelector(c.String("network-interface"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid configuration")
+	}
+	cfg.NetworkInterface = networkInterface
+	cfg.AddressesPerNode = c.Int("addresses-per-node")
 	cfg.ReleaseOnExit = c.Bool("release-on-exit")
 	cfg.LeaseDuration = c.Int("lease-duration")
 	cfg.LeaseNamespace = c.String("lease-namespace")
-	cfg.TaintKey = c.String("taint-key")
+	cfg.LockScope = types.LockScope(c.String("lock-scope"))
+	for _, key := range c.StringSlice("taint-key") {
+		// the TAINT_KEY environment variable may be set but empty
+		if key != "" {
+			cfg.TaintKeys = append(cfg.TaintKeys, key)
+		}
+	}
+	cfg.TaintEffect = corev1.TaintEffect(c.String("taint-effect"))
+	if cfg.TaintEffect == "" {
+		cfg.TaintEffect = corev1.TaintEffectNoSchedule
+	}
+	cfg.AzureDeletePrefixIP = c.Bool("azure-delete-prefix-ip")
+	cfg.AwsRoleARN = c.String("aws-role-arn")
+	cfg.AwsExternalID = c.String("aws-external-id")
+	cfg.NodeSelector = c.String("node-selector")
+	cfg.LeaderElection = c.Bool("leader-election")
+	cfg.StaticIPPools = c.Bool("static-ip-pools")
+	cfg.RecordAssignments = c.Bool("record-assignments")
+	cfg.LabelNode = c.Bool("label-node")
+	cfg.NodeCondition = c.Bool("node-condition")
+	cfg.MetricsAddress = c.String("metrics-address")
+	cfg.HealthProbeAddress = c.String("health-probe-address")
+
+	cfg.flagSet = make(map[string]bool, len(fileFlags))
+	for _, name := range fileFlags {
+		cfg.flagSet[name] = c.IsSet(name)
+	}
+
+	if cfg.ConfigFile != "" && cfg.ConfigMap != "" {
+		return nil, errors.New("invalid configuration: config and config-map are mutually exclusive")
+	}
+	if cfg.ConfigFile != "" {
+		file, err := LoadFile(cfg.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.WithFile(file)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid configuration")
+	}
+	return &cfg, nil
+}
+
+// WithFile returns the configuration with the settings of the configuration file, replacing the settings of the
+// previously loaded file; the flags and environment variables keep precedence.
+func (c *Config) WithFile(file *File) (*Config, error) {
+	flags := c.flags
+	if flags == nil {
+		flags = c
+	}
+	cfg := *flags
+	cfg.flags = flags
+	cfg.file = file
+	file.Settings.apply(&cfg)
+	if err := cfg.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid configuration")
+	}
+	return &cfg, nil
+}
+
+// ForNode returns the configuration of the node: the settings of the cloud and node pool sections of the
+// configuration file override the top level settings, unless the matching flags are set.
+func (c *Config) ForNode(node *types.Node) *Config {
+	cfg := *c
+	if c.file == nil {
+		return &cfg
+	}
+	if settings, ok := c.file.Clouds[node.Cloud]; ok {
+		settings.apply(&cfg)
+	}
+	if settings, ok := c.file.Pools[node.Pool]; ok && node.Pool != "" {
+		settings.apply(&cfg)
+	}
 	return &cfg
 }
+
+func (c *Config) validate() error {
+	switch c.IPFamily {
+	case types.IPFamilyIPv4, types.IPFamilyIPv6, types.IPFamilyDual:
+	default:
+		return errors.Errorf("unknown IP family %s", c.IPFamily)
+	}
+	switch c.ReassignPolicy {
+	case ReassignPolicyKeep, ReassignPolicyReassign:
+	default:
+		return errors.Errorf("unknown reassign policy %s", c.ReassignPolicy)
+	}
+	switch c.LockScope {
+	case types.LockScopeCluster, types.LockScopePool, types.LockScopeAddress, types.LockScopeCloud:
+	default:
+		return errors.Errorf("unknown lock scope %s", c.LockScope)
+	}
+	switch c.TaintEffect {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return errors.Errorf("unknown taint effect %s", c.TaintEffect)
+	}
+	if c.RetryInterval <= 0 {
+		return errors.Errorf("retry-interval must be positive, got %v", c.RetryInterval)
+	}
+	if c.ReconcileInterval < 0 {
+		return errors.Errorf("reconcile-interval must not be negative, got %v", c.ReconcileInterval)
+	}
+	if c.RetryAttempts < 0 {
+		return errors.Errorf("retry-attempts must not be negative, got %d", c.RetryAttempts)
+	}
+	if c.AwsExternalID != "" && c.AwsRoleARN == "" {
+		return errors.New("aws-external-id requires aws-role-arn")
+	}
+	if c.AwsRoleARN != "" && c.IPFamily != types.IPFamilyIPv4 {
+		return errors.Errorf("aws-role-arn is not supported with the %s IP family", c.IPFamily)
+	}
+	if c.AddressesPerNode < 1 {
+		return errors.Errorf("addresses-per-node must be positive, got %d", c.AddressesPerNode)
+	}
+	if c.AddressesPerNode > 1 && c.IPFamily == types.IPFamilyDual {
+		return errors.New("addresses-per-node is not supported with the dual IP family")
+	}
+	if c.LeaseDuration <= 0 {
+		return errors.Errorf("lease-duration must be positive, got %d", c.LeaseDuration)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testConfigFile = `
+filter:
+  - Name=tag:env,Values=prod
+order-by: name
+retry-interval: 1m
+lease-namespace: kube-system
+clouds:
+  aws:
+    retry-attempts: 20
+    order-by: tag:priority
+pools:
+  public-pool:
+    filter:
+      - Name=tag:pool,Values=public;internet
+`
+
+func newTestContext(t *testing.T, args []string, env map[string]string) *cli.Context {
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	flags := []cli.Flag{
+		&cli.PathFlag{Name: "config"},
+		&cli.StringSliceFlag{Name: "filter", EnvVars: []string{"FILTER"}},
+		&cli.StringFlag{Name: "order-by", EnvVars: []string{"ORDER_BY"}},
+		&cli.StringFlag{Name: "ip-family"},
+		&cli.IntFlag{Name: "addresses-per-node", Value: 1},
+		&cli.StringFlag{Name: "aws-role-arn"},
+		&cli.StringFlag{Name: "aws-external-id"},
+		&cli.DurationFlag{Name: "retry-interval", Value: 5 * time.Minute},
+		&cli.IntFlag{Name: "retry-attempts", Value: 10},
+		&cli.IntFlag{Name: "lease-duration", Value: 5},
+		&cli.StringFlag{Name: "lease-namespace", Value: "default"},
+		&cli.StringFlag{Name: "reassign-policy", Value: string(ReassignPolicyKeep)},
+		&cli.StringFlag{Name: "lock-scope", Value: string(types.LockScopeCluster)},
+		&cli.StringFlag{Name: "log-level", Value: "info"},
+		&cli.StringSliceFlag{Name: "taint-key", EnvVars: []string{"TAINT_KEY"}},
+		&cli.StringFlag{Name: "taint-effect", Value: string(corev1.TaintEffectNoSchedule)},
+	}
+	// parse the flags with the settings of the kubeip app
+	var ctx *cli.Context
+	app := &cli.App{
+		SliceFlagSeparator: SliceFlagSeparator,
+		Flags:              flags,
+		Action: func(c *cli.Context) error {
+			ctx = c
+			return nil
+		},
+	}
+	if err := app.Run(append([]string{t.Name()}, args...)); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	return ctx
+}
+
+func TestNewConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfigFile), 0o600); err != nil {
+		t.Fatalf("failed to write configuration file: %v", err)
+	}
+	type want struct {
+		filter         []string
+		orderBy        string
+		retryInterval  time.Duration
+		retryAttempts  int
+		leaseNamespace string
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		node    *types.Node
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "defaults without configuration file",
+			node: &types.Node{Cloud: types.CloudProviderAWS, Pool: "public-pool"},
+			want: want{retryInterval: 5 * time.Minute, retryAttempts: 10, leaseNamespace: "default"},
+		},
+		{
+			name: "top level settings",
+			args: []string{"--config", path},
+			node: &types.Node{Cloud: types.CloudProviderGCP, Pool: "default-pool"},
+			want: want{
+				filter:         []string{"Name=tag:env,Values=prod"},
+				orderBy:        "name",
+				retryInterval:  time.Minute,
+				retryAttempts:  10,
+				leaseNamespace: "kube-system",
+			},
+		},
+		{
+			name: "cloud and pool sections",
+			args: []string{"--config", path},
+			node: &types.Node{Cloud: types.CloudProviderAWS, Pool: "public-pool"},
+			want: want{
+				filter:         []string{"Name=tag:pool,Values=public;internet"},
+				orderBy:        "tag:priority",
+				retryInterval:  time.Minute,
+				retryAttempts:  20,
+				leaseNamespace: "kube-system",
+			},
+		},
+		{
+			name: "flags and environment variables take precedence",
+			args: []string{"--config", path, "--retry-attempts", "3", "--order-by", "address"},
+			env:  map[string]string{"FILTER": "labels.env=prod"},
+			node: &types.Node{Cloud: types.CloudProviderAWS, Pool: "public-pool"},
+			want: want{
+				filter:         []string{"labels.env=prod"},
+				orderBy:        "address",
+				retryInterval:  time.Minute,
+				retryAttempts:  3,
+				leaseNamespace: "kube-system",
+			},
+		},
+		{
+			name:    "missing configuration file",
+			args:    []string{"--config", filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid flag",
+			args:    []string{"--ip-family", "ipv5"},
+			wantErr: true,
+		},
+		{
+			name:    "aws external id without role",
+			args:    []string{"--aws-external-id", "kubeip"},
+			wantErr: true,
+		},
+		{
+			name:    "aws role with ipv6 IP family",
+			args:    []string{"--aws-role-arn", "arn:aws:iam::123456789012:role/kubeip", "--ip-family", "ipv6"},
+			wantErr: true,
+		},
+		{
+			name:    "aws role with dual IP family",
+			args:    []string{"--aws-role-arn", "arn:aws:iam::123456789012:role/kubeip", "--ip-family", "dual"},
+			wantErr: true,
+		},
+		{
+			name:    "no addresses per node",
+			args:    []string{"--addresses-per-node", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "addresses per node with dual IP family",
+			args:    []string{"--addresses-per-node", "2", "--ip-family", "dual"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewConfig(newTestContext(t, tt.args, tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := cfg.ForNode(tt.node)
+			if !reflect.DeepEqual(got.Filter, tt.want.filter) {
+				t.Errorf("Filter = %v, want %v", got.Filter, tt.want.filter)
+			}
+			if got.OrderBy != tt.want.orderBy {
+				t.Errorf("OrderBy = %v, want %v", got.OrderBy, tt.want.orderBy)
+			}
+			if got.RetryInterval != tt.want.retryInterval {
+				t.Errorf("RetryInterval = %v, want %v", got.RetryInterval, tt.want.retryInterval)
+			}
+			if got.RetryAttempts != tt.want.retryAttempts {
+				t.Errorf("RetryAttempts = %v, want %v", got.RetryAttempts, tt.want.retryAttempts)
+			}
+			if got.LeaseNamespace != tt.want.leaseNamespace {
+				t.Errorf("LeaseNamespace = %v, want %v", got.LeaseNamespace, tt.want.leaseNamespace)
+			}
+		})
+	}
+}
+
+func TestNewConfigTaints(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantKeys   []string
+		wantEffect corev1.TaintEffect
+		wantErr    bool
+	}{
+		{
+			name:       "empty taint key environment variable",
+			env:        map[string]string{"TAINT_KEY": ""},
+			wantEffect: corev1.TaintEffectNoSchedule,
+		},
+		{
+			name:       "taint keys from environment variable",
+			env:        map[string]string{"TAINT_KEY": "kubeip.com/not-ready;example.com/no-egress"},
+			wantKeys:   []string{"kubeip.com/not-ready", "example.com/no-egress"},
+			wantEffect: corev1.TaintEffectNoSchedule,
+		},
+		{
+			name:       "taint keys and effect from flags",
+			args:       []string{"--taint-key", "kubeip.com/not-ready", "--taint-key", "example.com/no-egress", "--taint-effect", "NoExecute"},
+			wantKeys:   []string{"kubeip.com/not-ready", "example.com/no-egress"},
+			wantEffect: corev1.TaintEffectNoExecute,
+		},
+		{
+			name:    "invalid taint effect",
+			args:    []string{"--taint-effect", "NoWay"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewConfig(newTestContext(t, tt.args, tt.env))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(cfg.TaintKeys, tt.wantKeys) {
+				t.Errorf("TaintKeys = %v, want %v", cfg.TaintKeys, tt.wantKeys)
+			}
+			if cfg.TaintEffect != tt.wantEffect {
+				t.Errorf("TaintEffect = %v, want %v", cfg.TaintEffect, tt.wantEffect)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "yaml",
+			content: testConfigFile,
+		},
+		{
+			name:    "json",
+			content: `{"retry-interval": "30s", "clouds": {"gcp": {"order-by": "name"}}, "pools": {"p1": {"retry-attempts": 5}}}`,
+		},
+		{
+			name:    "unknown setting",
+			content: "retry-intervals: 1m",
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			content: "retry-interval: 60",
+			wantErr: true,
+		},
+		{
+			name:    "unknown cloud",
+			content: "clouds:\n  ibm:\n    order-by: name",
+			wantErr: true,
+		},
+		{
+			name:    "lease namespace in pool section",
+			content: "pools:\n  p1:\n    lease-namespace: kube-system",
+			wantErr: true,
+		},
+		{
+			name:    "negative retry attempts",
+			content: "retry-attempts: -1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write configuration file: %v", err)
+			}
+			if _, err := LoadFile(path); (err != nil) != tt.wantErr {
+				t.Errorf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// File is the YAML or JSON configuration file. The top level settings apply to all nodes; the settings of the
+// cloud section of the node override them, and the settings of the node pool section override both.
+type File struct {
+	Settings `json:",inline"`
+	// Clouds are the settings of the nodes of each cloud provider: gcp, aws, azure or oci
+	Clouds map[types.CloudProvider]Settings `json:"clouds,omitempty"`
+	// Pools are the settings of the nodes of each node pool, by node pool name
+	Pools map[string]Settings `json:"pools,omitempty"`
+	// content is the content the file was parsed from
+	content []byte
+}
+
+// Settings are the settings of the configuration file; a setting is used only if the matching flag is not set
+// on the command line or with its environment variable.
+type Settings struct {
+	// Filter is the filter for the IP addresses; unlike the filter flag, each item may contain ";" and ","
+	Filter []string `json:"filter,omitempty"`
+	// OrderBy is the order by for the IP addresses
+	OrderBy *string `json:"order-by,omitempty"`
+	// RetryInterval is the retry interval, for example 5s or 1m
+	RetryInterval *Duration `json:"retry-interval,omitempty"`
+	// RetryAttempts is the number of attempts to assign the static public IP address
+	RetryAttempts *int `json:"retry-attempts,omitempty"`
+	// LeaseDuration is the duration of the kubernetes lease
+	LeaseDuration *int `json:"lease-duration,omitempty"`
+	// LeaseNamespace is the namespace of the kubernetes lease; top level only, the lease is shared by all nodes
+	LeaseNamespace *string `json:"lease-namespace,omitempty"`
+	// LogLevel is the log level: debug, info, warning, error, fatal or panic; top level only
+	LogLevel *string `json:"log-level,omitempty"`
+}
+
+// Duration is a time.Duration read from a duration string, for example 5s or 1m.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses the duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "duration must be a string, for example 5s or 1m")
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration %s", s)
+	}
+	d.Duration = duration
+	return nil
+}
+
+// MarshalJSON formats the duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String()) //nolint:wrapcheck
+}
+
+// LoadFile reads and validates the YAML or JSON configuration file.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read configuration file %s", path)
+	}
+	file, err := ParseFile(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "configuration file %s", path)
+	}
+	return file, nil
+}
+
+// ParseFile parses and validates the YAML or JSON configuration file content.
+func ParseFile(data []byte) (*File, error) {
+	var file File
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, errors.Wrap(err, "failed to parse configuration file")
+	}
+	if err := file.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid configuration file")
+	}
+	file.content = data
+	return &file, nil
+}
+
+func (f *File) validate() error {
+	if err := f.Settings.validate(); err != nil {
+		return err
+	}
+	for cloud, settings := range f.Clouds {
+		switch cloud {
+		case types.CloudProviderGCP, types.CloudProviderAWS, types.CloudProviderAzure, types.CloudProviderOCI:
+		default:
+			return errors.Errorf("unknown cloud provider %s in clouds section", cloud)
+		}
+		if err := settings.validateSection(); err != nil {
+			return errors.Wrapf(err, "cloud %s", cloud)
+		}
+	}
+	for pool, settings := range f.Pools {
+		if err := settings.validateSection(); err != nil {
+			return errors.Wrapf(err, "pool %s", pool)
+		}
+	}
+	return nil
+}
+
+// validateSection validates the settings of a cloud or node pool section
+func (s *Settings) validateSection() error {
+	if s.LeaseNamespace != nil {
+		return errors.New("lease-namespace can only be set at the top level")
+	}
+	if s.LogLevel != nil {
+		return errors.New("log-level can only be set at the top level")
+	}
+	return s.validate()
+}
+
+func (s *Settings) validate() error {
+	if s.RetryInterval != nil && s.RetryInterval.Duration <= 0 {
+		return errors.Errorf("retry-interval must be positive, got %v", s.RetryInterval.Duration)
+	}
+	if s.RetryAttempts != nil && *s.RetryAttempts < 0 {
+		return errors.Errorf("retry-attempts must not be negative, got %d", *s.RetryAttempts)
+	}
+	if s.LeaseDuration != nil && *s.LeaseDuration <= 0 {
+		return errors.Errorf("lease-duration must be positive, got %d", *s.LeaseDuration)
+	}
+	if s.LogLevel != nil {
+		switch strings.ToLower(*s.LogLevel) {
+		case "debug", "info", "warning", "error", "fatal", "panic":
+		default:
+			return errors.Errorf("unknown log-level %s", *s.LogLevel)
+		}
+	}
+	return nil
+}
+
+// apply sets the settings on the configuration, skipping the flags that are set
+func (s *Settings) apply(cfg *Config) {
+	if s.Filter != nil && !cfg.flagSet["filter"] {
+		cfg.Filter = s.Filter
+	}
+	if s.OrderBy != nil && !cfg.flagSet["order-by"] {
+		cfg.OrderBy = *s.OrderBy
+	}
+	if s.RetryInterval != nil && !cfg.flagSet["retry-interval"] {
+		cfg.RetryInterval = s.RetryInterval.Duration
+	}
+	if s.RetryAttempts != nil && !cfg.flagSet["retry-attempts"] {
+		cfg.RetryAttempts = *s.RetryAttempts
+	}
+	if s.LeaseDuration != nil && !cfg.flagSet["lease-duration"] {
+		cfg.LeaseDuration = *s.LeaseDuration
+	}
+	if s.LeaseNamespace != nil && !cfg.flagSet["lease-namespace"] {
+		cfg.LeaseNamespace = *s.LeaseNamespace
+	}
+	if s.LogLevel != nil && !cfg.flagSet["log-level"] {
+		cfg.LogLevel = *s.LogLevel
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMapKey is the key of the configuration file in the ConfigMap
+const ConfigMapKey = "config.yaml"
+
+// Source reads the content of the configuration file.
+type Source interface {
+	Read(ctx context.Context) ([]byte, error)
+}
+
+type fileSource struct {
+	path string
+}
+
+// NewFileSource creates a Source reading the configuration file, for example mounted from a ConfigMap.
+func NewFileSource(path string) Source {
+	return &fileSource{path: path}
+}
+
+func (s *fileSource) Read(_ context.Context) ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read configuration file %s", s.path)
+	}
+	return data, nil
+}
+
+type configMapSource struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapSource creates a Source reading the configuration file from the config.yaml key of the ConfigMap,
+// referenced as namespace/name.
+func NewConfigMapSource(client kubernetes.Interface, ref string) (Source, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, errors.Errorf("invalid ConfigMap reference %s, expected namespace/name", ref)
+	}
+	return &configMapSource{client: client, namespace: namespace, name: name}, nil
+}
+
+func (s *configMapSource) Read(ctx context.Context) ([]byte, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get ConfigMap %s/%s", s.namespace, s.name)
+	}
+	data, ok := cm.Data[ConfigMapKey]
+	if !ok {
+		return nil, errors.Errorf("ConfigMap %s/%s has no %s key", s.namespace, s.name, ConfigMapKey)
+	}
+	return []byte(data), nil
+}
+
+// Watcher polls the configuration file source and sends the configuration when the file content changes.
+type Watcher struct {
+	source   Source
+	interval time.Duration
+	logger   *logrus.Entry
+	cfg      *Config
+	last     []byte
+}
+
+// NewWatcher creates a new Watcher of the configuration file source; cfg is the current configuration. If cfg
+// already holds the settings of the configuration file, the watcher reports only the later changes of the file.
+func NewWatcher(logger *logrus.Entry, source Source, cfg *Config) *Watcher {
+	w := &Watcher{
+		source:   source,
+		interval: cfg.ConfigReloadInterval,
+		logger:   logger,
+		cfg:      cfg,
+	}
+	if cfg.file != nil {
+		w.last = cfg.file.content
+	}
+	return w
+}
+
+// Load reads the configuration file and returns the current configuration with its settings.
+func (w *Watcher) Load(ctx context.Context) (*Config, error) {
+	data, err := w.source.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := w.cfg.WithFile(file)
+	if err != nil {
+		return nil, err
+	}
+	w.cfg, w.last = cfg, data
+	return cfg, nil
+}
+
+// Watch checks the configuration file for changes at the reload interval until the context is done; the returned
+// channel receives the configuration with the settings of each changed and valid configuration file.
+func (w *Watcher) Watch(ctx context.Context) <-chan *Config {
+	changes := make(chan *Config)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			data, err := w.source.Read(ctx)
+			if err != nil {
+				w.logger.WithError(err).Warn("failed to read configuration file, keeping current configuration")
+				continue
+			}
+			if bytes.Equal(data, w.last) {
+				continue
+			}
+			w.last = data
+			file, err := ParseFile(data)
+			if err == nil {
+				var cfg *Config
+				if cfg, err = w.cfg.WithFile(file); err == nil {
+					w.cfg = cfg
+				}
+			}
+			if err != nil {
+				w.logger.WithError(err).Error("invalid configuration file, keeping current configuration")
+				continue
+			}
+			select {
+			case changes <- w.cfg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
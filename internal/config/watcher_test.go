@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kubeip"},
+		Data:       map[string]string{ConfigMapKey: "order-by: name"},
+	}
+	client := fake.NewSimpleClientset(cm)
+	source, err := NewConfigMapSource(client, "kube-system/kubeip")
+	if err != nil {
+		t.Fatalf("NewConfigMapSource() error = %v", err)
+	}
+	cfg := &Config{
+		OrderBy:              "address",
+		RetryInterval:        time.Minute,
+		LeaseDuration:        5,
+		IPFamily:             "ipv4",
+		AddressesPerNode:     1,
+		ReassignPolicy:       ReassignPolicyKeep,
+		LockScope:            types.LockScopeCluster,
+		TaintEffect:          corev1.TaintEffectNoSchedule,
+		ConfigReloadInterval: time.Millisecond,
+		flagSet:              map[string]bool{"retry-interval": true},
+	}
+	w := NewWatcher(logrus.NewEntry(logrus.New()), source, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loaded, err := w.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.OrderBy != "name" {
+		t.Errorf("Load() order by = %v, want name", loaded.OrderBy)
+	}
+	changes := w.Watch(ctx)
+
+	// invalid configuration files are skipped
+	cm.Data[ConfigMapKey] = "retry-attempts: -1"
+	if _, err = client.CoreV1().ConfigMaps("kube-system").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update ConfigMap: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cm.Data[ConfigMapKey] = "retry-interval: 1s\nretry-attempts: 3"
+	if _, err = client.CoreV1().ConfigMaps("kube-system").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update ConfigMap: %v", err)
+	}
+
+	select {
+	case got := <-changes:
+		if got.OrderBy != "address" {
+			t.Errorf("Watch() order by = %v, want address", got.OrderBy)
+		}
+		if got.RetryAttempts != 3 {
+			t.Errorf("Watch() retry attempts = %v, want 3", got.RetryAttempts)
+		}
+		if got.RetryInterval != time.Minute {
+			t.Errorf("Watch() retry interval = %v, want flag value %v", got.RetryInterval, time.Minute)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not send the changed configuration")
+	}
+
+	cancel()
+	if _, ok := <-changes; ok {
+		t.Error("Watch() channel not closed after context done")
+	}
+}
+
+func TestWatcher_loadedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("order-by: name"), 0o600); err != nil {
+		t.Fatalf("failed to write configuration file: %v", err)
+	}
+	file, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	flags := &Config{
+		RetryInterval:        time.Minute,
+		LeaseDuration:        5,
+		IPFamily:             "ipv4",
+		AddressesPerNode:     1,
+		ReassignPolicy:       ReassignPolicyKeep,
+		LockScope:            types.LockScopeCluster,
+		TaintEffect:          corev1.TaintEffectNoSchedule,
+		ConfigReloadInterval: time.Millisecond,
+	}
+	cfg, err := flags.WithFile(file)
+	if err != nil {
+		t.Fatalf("WithFile() error = %v", err)
+	}
+	w := NewWatcher(logrus.NewEntry(logrus.New()), NewFileSource(path), cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := w.Watch(ctx)
+
+	// the file loaded at startup is not reported as a change
+	select {
+	case got := <-changes:
+		t.Fatalf("Watch() sent unchanged configuration, order by = %v", got.OrderBy)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err = os.WriteFile(path, []byte("order-by: address"), 0o600); err != nil {
+		t.Fatalf("failed to write configuration file: %v", err)
+	}
+	select {
+	case got := <-changes:
+		if got.OrderBy != "address" {
+			t.Errorf("Watch() order by = %v, want address", got.OrderBy)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() did not send the changed configuration")
+	}
+}
+
+func TestNewConfigMapSource(t *testing.T) {
+	for _, ref := range []string{"kubeip", "/kubeip", "kube-system/"} {
+		if _, err := NewConfigMapSource(fake.NewSimpleClientset(), ref); err == nil {
+			t.Errorf("NewConfigMapSource(%s) expected error", ref)
+		}
+	}
+}
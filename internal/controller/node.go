@@ -0,0 +1,405 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/address"
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	"github.com/doitintl/kubeip/internal/lease"
+	"github.com/doitintl/kubeip/internal/metrics"
+	nd "github.com/doitintl/kubeip/internal/node"
+	"github.com/doitintl/kubeip/internal/pool"
+	"github.com/doitintl/kubeip/internal/status"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// AssignerFactory creates an address assigner for the cloud provider of a node.
+type AssignerFactory func(ctx context.Context, cloudProvider types.CloudProvider) (address.Assigner, error)
+
+type assignment struct {
+	node    *types.Node
+	address string
+}
+
+// pendingAssignment tracks the failed attempts to assign an address to a node
+type pendingAssignment struct {
+	failures int
+	started  time.Time
+}
+
+// NodeReconciler assigns static public IP addresses to all nodes matching the label selector.
+type NodeReconciler struct {
+	client      client.Reader
+	kubeClient  kubernetes.Interface
+	explorer    nd.Explorer
+	tainter     nd.Tainter
+	labeler     nd.Labeler
+	newAssigner AssignerFactory
+	resolver    pool.Resolver
+	recorder    status.Recorder
+	events      events.Recorder
+	selector    labels.Selector
+	log         *logrus.Entry
+	cfg         *config.Config
+
+	mu        sync.Mutex
+	assigners map[types.CloudProvider]address.Assigner
+	assigned  map[string]*assignment
+	pending   map[string]*pendingAssignment
+}
+
+// NewNodeReconciler creates a new NodeReconciler; if resolver is nil, all nodes use the configured filter and order by.
+func NewNodeReconciler(log *logrus.Entry, c client.Reader, kubeClient kubernetes.Interface, newAssigner AssignerFactory, resolver pool.Resolver, recorder status.Recorder, eventRecorder events.Recorder, cfg *config.Config) (*NodeReconciler, error) {
+	selector, err := labels.Parse(cfg.NodeSelector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse node selector %s", cfg.NodeSelector)
+	}
+	labeler := nd.NewNoopLabeler()
+	if cfg.LabelNode {
+		labeler = nd.NewLabeler(kubeClient)
+	}
+	return &NodeReconciler{
+		client:      c,
+		kubeClient:  kubeClient,
+		explorer:    nd.NewExplorer(kubeClient),
+		tainter:     nd.NewTainter(kubeClient),
+		labeler:     labeler,
+		newAssigner: newAssigner,
+		resolver:    resolver,
+		recorder:    recorder,
+		events:      eventRecorder,
+		selector:    selector,
+		log:         log,
+		cfg:         cfg,
+		assigners:   make(map[types.CloudProvider]address.Assigner),
+		assigned:    make(map[string]*assignment),
+		pending:     make(map[string]*pendingAssignment),
+	}, nil
+}
+
+// SetupWithManager registers the reconciler with the manager; the manager node informer triggers a reconcile
+// when a node is created or deleted and when its labels, taints or addresses change. Once elected, the reconciler
+// recovers the assigned addresses recorded by the previous leader.
+func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		Named("kubeip-node").
+		For(&corev1.Node{}, builder.WithPredicates(nodeChangedPredicate())).
+		Complete(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to create node controller")
+	}
+	err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		// a failed recovery must not stop the manager: the assigned addresses of the existing nodes are rebuilt by their reconcile
+		if recoverErr := r.Recover(ctx); recoverErr != nil {
+			r.log.WithError(recoverErr).Warn("failed to recover assigned static public IP addresses")
+		}
+		return nil
+	}))
+	if err != nil {
+		return errors.Wrap(err, "failed to add assignment recovery")
+	}
+	return nil
+}
+
+// Recover rebuilds the assigned addresses from the recorded assignments after a restart or a leader change, and
+// releases the addresses of the nodes deleted in the meantime, which are never reconciled again.
+func (r *NodeReconciler) Recover(ctx context.Context) error {
+	assignments, err := r.recorder.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list recorded assignments")
+	}
+	for _, a := range assignments {
+		log := r.log.WithField("node", a.Node.Name)
+		r.mu.Lock()
+		if _, ok := r.assigned[a.Node.Name]; !ok {
+			r.assigned[a.Node.Name] = &assignment{node: a.Node, address: a.Address}
+		}
+		r.mu.Unlock()
+
+		var node corev1.Node
+		err = r.client.Get(ctx, client.ObjectKey{Name: a.Node.Name}, &node)
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get node %s", a.Node.Name)
+		}
+		log.WithField("address", a.Address).Info("releasing static public IP address of node deleted before recovery")
+		if err = r.release(ctx, log, a.Node.Name); err != nil {
+			log.WithError(err).Warn("failed to release static public IP address of deleted node")
+		}
+	}
+	return nil
+}
+
+func nodeChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return !reflect.DeepEqual(oldNode.Labels, newNode.Labels) ||
+				!reflect.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) ||
+				!reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) ||
+				!oldNode.DeletionTimestamp.Equal(newNode.DeletionTimestamp)
+		},
+	}
+}
+
+// Reconcile assigns a static public IP address to the node, removes the taint key once the node reports the
+// assigned address and releases the address when the node is deleted or stops matching the selector.
+func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.WithField("node", req.Name)
+
+	var node corev1.Node
+	if err := r.client.Get(ctx, req.NamespacedName, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the instance is usually gone together with the node, so do not retry failed releases
+			if releaseErr := r.release(ctx, log, req.Name); releaseErr != nil {
+				log.WithError(releaseErr).Warn("failed to release static public IP address of deleted node")
+			}
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "failed to get node")
+	}
+
+	if !node.DeletionTimestamp.IsZero() || !r.selector.Matches(labels.Set(node.Labels)) {
+		return ctrl.Result{}, r.release(ctx, log, node.Name)
+	}
+
+	n, err := r.explorer.GetNode(ctx, node.Name)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to explore node")
+	}
+	cfg := r.cfg.ForNode(n)
+
+	assignedAddress, ok := r.assignedAddress(n.Name)
+	if !ok {
+		r.begin(n.Name)
+		assignedAddress, err = r.assign(ctx, log, n, cfg)
+		if err != nil {
+			log.WithError(err).WithField("instance", n.Instance).Error("failed to assign static public IP address to node")
+			if errors.Is(err, address.ErrNoAvailableStaticIP) {
+				r.events.Eventf(n, corev1.EventTypeWarning, events.ReasonPoolExhausted, "No static public IP address available: %v", err)
+			} else {
+				r.events.Eventf(n, corev1.EventTypeWarning, events.ReasonAssignFailed, "Failed to assign static public IP address: %v", err)
+			}
+			if recordErr := r.recorder.Failed(ctx, n, r.fail(n.Name), err); recordErr != nil {
+				log.WithError(recordErr).Warn("failed to record static public IP address assignment failure")
+			}
+			metrics.Retries.WithLabelValues(metrics.OperationAssign).Inc()
+			log.Infof("retrying after %v", cfg.RetryInterval)
+			return ctrl.Result{RequeueAfter: cfg.RetryInterval}, nil
+		}
+		retryCount, elapsed := r.track(n, assignedAddress)
+		metrics.AssignmentDuration.WithLabelValues(string(n.Cloud)).Observe(elapsed.Seconds())
+		r.recordAssigned(ctx, log, n, assignedAddress, retryCount)
+		log.WithFields(logrus.Fields{
+			"instance": n.Instance,
+			"address":  assignedAddress,
+		}).Info("static public IP address assigned to node")
+	}
+
+	if !hasTaintKey(&node, cfg.TaintKeys...) {
+		return ctrl.Result{}, nil
+	}
+
+	if !address.Reported(n.ExternalIPs, assignedAddress) {
+		log.WithField("address", assignedAddress).Warn("Node is not yet reporting the assigned address")
+		metrics.Retries.WithLabelValues(metrics.OperationWaitAddress).Inc()
+		return ctrl.Result{RequeueAfter: cfg.RetryInterval}, nil
+	}
+
+	if _, err = r.tainter.RemoveTaintKey(ctx, n, cfg.TaintKeys...); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to remove node taint key")
+	}
+	log.WithField("taint-key", cfg.TaintKeys).Info("taint key removed successfully")
+	r.events.Eventf(n, corev1.EventTypeNormal, events.ReasonTaintRemoved, "Removed taint key %s", strings.Join(cfg.TaintKeys, ", "))
+
+	return ctrl.Result{}, nil
+}
+
+func (r *NodeReconciler) assign(ctx context.Context, log *logrus.Entry, n *types.Node, cfg *config.Config) (string, error) {
+	assigner, err := r.assigner(ctx, n.Cloud)
+	if err != nil {
+		return "", err
+	}
+
+	filter, orderBy := cfg.Filter, cfg.OrderBy
+	if r.resolver != nil {
+		selection, err := r.resolver.Resolve(ctx, n)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to resolve static IP pool")
+		}
+		// nodes not matching any pool use the filter and order by of the node configuration
+		if selection.Pool != "" {
+			log.WithField("pool", selection.Pool).Debug("static IP pool resolved")
+			filter, orderBy = selection.Filter, selection.OrderBy
+		}
+	}
+
+	// share the lock with the DaemonSet agents
+	lock := lease.NewScopedLock(log, r.kubeClient, n.Instance, filter, cfg.LockScope, cfg.LeaseNamespace, cfg.LeaseDuration)
+	if err = lock.Lock(ctx); err != nil {
+		return "", errors.Wrap(err, "failed to acquire lock")
+	}
+	log.WithField("fencing-token", lock.Token()).Debug("lock acquired")
+	defer func() {
+		lock.Unlock(ctx) //nolint:errcheck
+		log.Debug("lock released")
+	}()
+
+	assignedAddress, err := assigner.Assign(lease.NewContext(events.NewContext(ctx, r.events, n), lock), n.Instance, n.Zone, filter, orderBy)
+	if errors.Is(err, address.ErrStaticIPAlreadyAssigned) {
+		r.events.Eventf(n, corev1.EventTypeNormal, events.ReasonAlreadyAssigned, "Static public IP address %s is already assigned", assignedAddress)
+		return assignedAddress, nil
+	}
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	r.events.Eventf(n, corev1.EventTypeNormal, events.ReasonAssigned, "Assigned static public IP address %s", assignedAddress)
+	return assignedAddress, nil
+}
+
+func (r *NodeReconciler) release(ctx context.Context, log *logrus.Entry, name string) error {
+	r.mu.Lock()
+	a, ok := r.assigned[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if r.cfg.ReleaseOnExit {
+		assigner, err := r.assigner(ctx, a.node.Cloud)
+		if err != nil {
+			return err
+		}
+		if err = assigner.Unassign(ctx, a.node.Instance, a.node.Zone); err != nil && !errors.Is(err, address.ErrNoStaticIPAssigned) {
+			r.events.Eventf(a.node, corev1.EventTypeWarning, events.ReasonReleaseFailed, "Failed to release static public IP address %s: %v", a.address, err)
+			return errors.Wrap(err, "failed to release static public IP address")
+		}
+		log.WithField("address", a.address).Info("static public IP address released")
+		r.events.Eventf(a.node, corev1.EventTypeNormal, events.ReasonReleased, "Released static public IP address %s", a.address)
+		if err = r.recorder.Released(ctx, a.node); err != nil {
+			log.WithError(err).Warn("failed to record static public IP address release")
+		}
+		if err = r.labeler.ClearAssigned(ctx, a.node); err != nil {
+			log.WithError(err).Warn("failed to remove static public IP address label from node")
+		}
+	}
+
+	r.mu.Lock()
+	delete(r.assigned, name)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *NodeReconciler) assigner(ctx context.Context, cloudProvider types.CloudProvider) (address.Assigner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if assigner, ok := r.assigners[cloudProvider]; ok {
+		return assigner, nil
+	}
+	assigner, err := r.newAssigner(ctx, cloudProvider)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize assigner")
+	}
+	r.assigners[cloudProvider] = assigner
+	return assigner, nil
+}
+
+func (r *NodeReconciler) assignedAddress(name string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if a, ok := r.assigned[name]; ok {
+		return a.address, true
+	}
+	return "", false
+}
+
+func (r *NodeReconciler) recordAssigned(ctx context.Context, log *logrus.Entry, n *types.Node, assignedAddress string, retryCount int) {
+	assigner, err := r.assigner(ctx, n.Cloud)
+	if err != nil {
+		log.WithError(err).Warn("failed to record static public IP address assignment")
+		return
+	}
+	resourceID, err := address.LookupResourceID(ctx, assigner, assignedAddress)
+	if err != nil {
+		log.WithError(err).WithField("address", assignedAddress).Warn("failed to look up static public IP address resource ID")
+	}
+	if err = r.recorder.Assigned(ctx, n, assignedAddress, resourceID, retryCount); err != nil {
+		log.WithError(err).Warn("failed to record static public IP address assignment")
+	}
+	if err = r.labeler.SetAssigned(ctx, n, assignedAddress, resourceID); err != nil {
+		log.WithError(err).Warn("failed to label node with static public IP address")
+	}
+}
+
+// begin starts tracking the attempts to assign an address to the node, unless already started
+func (r *NodeReconciler) begin(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[name]; !ok {
+		r.pending[name] = &pendingAssignment{started: time.Now()}
+	}
+}
+
+// track records the address assigned to the node and returns the number of failed attempts before the assignment
+// and the time since the first attempt
+func (r *NodeReconciler) track(n *types.Node, assignedAddress string) (int, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assigned[n.Name] = &assignment{node: n, address: assignedAddress}
+	p, ok := r.pending[n.Name]
+	if !ok {
+		return 0, 0
+	}
+	delete(r.pending, n.Name)
+	return p.failures, time.Since(p.started)
+}
+
+// fail counts a failed attempt to assign an address to the node and returns the number of failed attempts
+func (r *NodeReconciler) fail(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pending[name]
+	if !ok {
+		p = &pendingAssignment{started: time.Now()}
+		r.pending[name] = p
+	}
+	p.failures++
+	return p.failures
+}
+
+func hasTaintKey(node *corev1.Node, taintKeys ...string) bool {
+	for _, taint := range node.Spec.Taints {
+		for _, taintKey := range taintKeys {
+			if taint.Key == taintKey {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,390 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doitintl/kubeip/api/v1alpha1"
+	"github.com/doitintl/kubeip/internal/address"
+	"github.com/doitintl/kubeip/internal/config"
+	"github.com/doitintl/kubeip/internal/events"
+	nd "github.com/doitintl/kubeip/internal/node"
+	"github.com/doitintl/kubeip/internal/pool"
+	"github.com/doitintl/kubeip/internal/status"
+	"github.com/doitintl/kubeip/internal/types"
+	mocks "github.com/doitintl/kubeip/mocks/address"
+	nodeMocks "github.com/doitintl/kubeip/mocks/node"
+	poolMocks "github.com/doitintl/kubeip/mocks/pool"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	tmock "github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testNode(nodeLabels map[string]string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-node", Labels: nodeLabels},
+		Spec:       corev1.NodeSpec{Taints: taints},
+	}
+}
+
+func testTypesNode(externalIPs ...string) *types.Node {
+	n := &types.Node{
+		Name:     "test-node",
+		Cloud:    types.CloudProviderGCP,
+		Instance: "test-instance",
+		Zone:     "test-zone",
+	}
+	for _, ip := range externalIPs {
+		n.ExternalIPs = append(n.ExternalIPs, net.ParseIP(ip))
+	}
+	return n
+}
+
+func TestNodeReconciler_Reconcile(t *testing.T) {
+	type fields struct {
+		objects    []client.Object
+		assigned   map[string]*assignment
+		explorerFn func(t *testing.T) nd.Explorer
+		tainterFn  func(t *testing.T) nd.Tainter
+		assignerFn func(t *testing.T) address.Assigner
+		resolverFn func(t *testing.T) pool.Resolver
+		cfg        *config.Config
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		want         ctrl.Result
+		wantAssigned map[string]string
+		wantEvents   []string
+		wantErr      bool
+	}{
+		{
+			name: "assign address to matching node",
+			fields: fields{
+				objects: []client.Object{testNode(map[string]string{"kubeip": "use"})},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					mock := nodeMocks.NewExplorer(t)
+					mock.EXPECT().GetNode(tmock.Anything, "test-node").Return(testTypesNode(), nil)
+					return mock
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					mock := mocks.NewAssigner(t)
+					mock.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string{"test-filter"}, "test-order-by").Return("1.1.1.1", nil)
+					return mock
+				},
+				cfg: &config.Config{
+					NodeSelector:  "kubeip=use",
+					Filter:        []string{"test-filter"},
+					OrderBy:       "test-order-by",
+					LeaseDuration: 1,
+				},
+			},
+			wantAssigned: map[string]string{"test-node": "1.1.1.1"},
+			wantEvents:   []string{events.ReasonAssigned},
+		},
+		{
+			name: "assign address from static IP pool",
+			fields: fields{
+				objects: []client.Object{testNode(nil)},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					mock := nodeMocks.NewExplorer(t)
+					mock.EXPECT().GetNode(tmock.Anything, "test-node").Return(testTypesNode(), nil)
+					return mock
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					mock := mocks.NewAssigner(t)
+					mock.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string{"labels.pool=public"}, "Name").Return("1.1.1.1", nil)
+					return mock
+				},
+				resolverFn: func(t *testing.T) pool.Resolver {
+					mock := poolMocks.NewResolver(t)
+					mock.EXPECT().Resolve(tmock.Anything, testTypesNode()).Return(&pool.Selection{
+						Pool:    "public",
+						Filter:  []string{"labels.pool=public"},
+						OrderBy: "Name",
+					}, nil)
+					return mock
+				},
+				cfg: &config.Config{
+					Filter:        []string{"test-filter"},
+					LeaseDuration: 1,
+				},
+			},
+			wantAssigned: map[string]string{"test-node": "1.1.1.1"},
+			wantEvents:   []string{events.ReasonAssigned},
+		},
+		{
+			name: "skip node not matching selector",
+			fields: fields{
+				objects: []client.Object{testNode(map[string]string{"kubeip": "skip"})},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					return nodeMocks.NewExplorer(t)
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					return mocks.NewAssigner(t)
+				},
+				cfg: &config.Config{NodeSelector: "kubeip=use"},
+			},
+			wantAssigned: map[string]string{},
+		},
+		{
+			name: "requeue when assignment fails",
+			fields: fields{
+				objects: []client.Object{testNode(nil)},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					mock := nodeMocks.NewExplorer(t)
+					mock.EXPECT().GetNode(tmock.Anything, "test-node").Return(testTypesNode(), nil)
+					return mock
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					mock := mocks.NewAssigner(t)
+					mock.EXPECT().Assign(tmock.Anything, "test-instance", "test-zone", []string(nil), "").Return("", errors.New("no free address"))
+					return mock
+				},
+				cfg: &config.Config{
+					RetryInterval: time.Minute,
+					LeaseDuration: 1,
+				},
+			},
+			want:         ctrl.Result{RequeueAfter: time.Minute},
+			wantAssigned: map[string]string{},
+			wantEvents:   []string{events.ReasonAssignFailed},
+		},
+		{
+			name: "remove taint once node reports assigned address",
+			fields: fields{
+				objects:  []client.Object{testNode(nil, corev1.Taint{Key: "kubeip.com/not-ready", Effect: corev1.TaintEffectNoSchedule})},
+				assigned: map[string]*assignment{"test-node": {node: testTypesNode(), address: "1.1.1.1"}},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					mock := nodeMocks.NewExplorer(t)
+					mock.EXPECT().GetNode(tmock.Anything, "test-node").Return(testTypesNode("1.1.1.1"), nil)
+					return mock
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					mock := nodeMocks.NewTainter(t)
+					mock.EXPECT().RemoveTaintKey(tmock.Anything, testTypesNode("1.1.1.1"), "kubeip.com/not-ready").Return(true, nil)
+					return mock
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					return mocks.NewAssigner(t)
+				},
+				cfg: &config.Config{TaintKeys: []string{"kubeip.com/not-ready"}},
+			},
+			wantAssigned: map[string]string{"test-node": "1.1.1.1"},
+			wantEvents:   []string{events.ReasonTaintRemoved},
+		},
+		{
+			name: "wait for node to report assigned address before removing taint",
+			fields: fields{
+				objects:  []client.Object{testNode(nil, corev1.Taint{Key: "kubeip.com/not-ready", Effect: corev1.TaintEffectNoSchedule})},
+				assigned: map[string]*assignment{"test-node": {node: testTypesNode(), address: "1.1.1.1"}},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					mock := nodeMocks.NewExplorer(t)
+					mock.EXPECT().GetNode(tmock.Anything, "test-node").Return(testTypesNode("2.2.2.2"), nil)
+					return mock
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					return mocks.NewAssigner(t)
+				},
+				cfg: &config.Config{
+					TaintKeys:     []string{"kubeip.com/not-ready"},
+					RetryInterval: time.Second,
+				},
+			},
+			want:         ctrl.Result{RequeueAfter: time.Second},
+			wantAssigned: map[string]string{"test-node": "1.1.1.1"},
+		},
+		{
+			name: "release address of deleted node",
+			fields: fields{
+				assigned: map[string]*assignment{"test-node": {node: testTypesNode(), address: "1.1.1.1"}},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					return nodeMocks.NewExplorer(t)
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					mock := mocks.NewAssigner(t)
+					mock.EXPECT().Unassign(tmock.Anything, "test-instance", "test-zone").Return(nil)
+					return mock
+				},
+				cfg: &config.Config{ReleaseOnExit: true},
+			},
+			wantAssigned: map[string]string{},
+			wantEvents:   []string{events.ReasonReleased},
+		},
+		{
+			name: "retry release of node no longer matching selector",
+			fields: fields{
+				objects:  []client.Object{testNode(map[string]string{"kubeip": "skip"})},
+				assigned: map[string]*assignment{"test-node": {node: testTypesNode(), address: "1.1.1.1"}},
+				explorerFn: func(t *testing.T) nd.Explorer {
+					return nodeMocks.NewExplorer(t)
+				},
+				tainterFn: func(t *testing.T) nd.Tainter {
+					return nodeMocks.NewTainter(t)
+				},
+				assignerFn: func(t *testing.T) address.Assigner {
+					mock := mocks.NewAssigner(t)
+					mock.EXPECT().Unassign(tmock.Anything, "test-instance", "test-zone").Return(errors.New("api error"))
+					return mock
+				},
+				cfg: &config.Config{
+					NodeSelector:  "kubeip=use",
+					ReleaseOnExit: true,
+				},
+			},
+			wantAssigned: map[string]string{"test-node": "1.1.1.1"},
+			wantEvents:   []string{events.ReasonReleaseFailed},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := labels.Parse(tt.fields.cfg.NodeSelector)
+			if err != nil {
+				t.Fatalf("failed to parse selector: %v", err)
+			}
+			assigner := tt.fields.assignerFn(t)
+			eventRecorder := record.NewFakeRecorder(len(tt.wantEvents) + 1)
+			assigned := tt.fields.assigned
+			if assigned == nil {
+				assigned = make(map[string]*assignment)
+			}
+			r := &NodeReconciler{
+				client:     ctrlfake.NewClientBuilder().WithObjects(tt.fields.objects...).Build(),
+				kubeClient: fake.NewSimpleClientset(),
+				explorer:   tt.fields.explorerFn(t),
+				tainter:    tt.fields.tainterFn(t),
+				newAssigner: func(context.Context, types.CloudProvider) (address.Assigner, error) {
+					return assigner, nil
+				},
+				selector:  selector,
+				log:       logrus.NewEntry(logrus.New()),
+				cfg:       tt.fields.cfg,
+				assigners: make(map[types.CloudProvider]address.Assigner),
+				assigned:  assigned,
+				pending:   make(map[string]*pendingAssignment),
+				recorder:  status.NewNoopRecorder(),
+				labeler:   nd.NewNoopLabeler(),
+				events:    events.NewRecorder(eventRecorder),
+			}
+			if tt.fields.resolverFn != nil {
+				r.resolver = tt.fields.resolverFn(t)
+			}
+			got, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: k8stypes.NamespacedName{Name: "test-node"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Reconcile() = %v, want %v", got, tt.want)
+			}
+			if len(r.assigned) != len(tt.wantAssigned) {
+				t.Errorf("Reconcile() assigned = %v, want %v", r.assigned, tt.wantAssigned)
+			}
+			for name, want := range tt.wantAssigned {
+				if a, ok := r.assigned[name]; !ok || a.address != want {
+					t.Errorf("Reconcile() assigned[%s] = %v, want %v", name, a, want)
+				}
+			}
+			close(eventRecorder.Events)
+			var gotEvents []string
+			for event := range eventRecorder.Events {
+				// fake recorder events are formatted as "<type> <reason> <message>"
+				gotEvents = append(gotEvents, strings.Fields(event)[1])
+			}
+			if !reflect.DeepEqual(gotEvents, tt.wantEvents) {
+				t.Errorf("Reconcile() events = %v, want %v", gotEvents, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestNodeReconciler_Recover(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	testAssignment := func(name, instance, address string) *v1alpha1.StaticIPAssignment {
+		return &v1alpha1.StaticIPAssignment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1alpha1.StaticIPAssignmentSpec{NodeName: name, Instance: instance, Cloud: string(types.CloudProviderGCP), Zone: "test-zone"},
+			Status:     v1alpha1.StaticIPAssignmentStatus{Phase: v1alpha1.AssignmentPhaseAssigned, Address: address},
+		}
+	}
+	c := ctrlfake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		testNode(nil),
+		testAssignment("test-node", "test-instance", "1.1.1.1"),
+		testAssignment("deleted-node", "deleted-instance", "2.2.2.2"),
+	).Build()
+	assigner := mocks.NewAssigner(t)
+	assigner.EXPECT().Unassign(tmock.Anything, "deleted-instance", "test-zone").Return(nil).Once()
+	eventRecorder := record.NewFakeRecorder(10)
+
+	r := &NodeReconciler{
+		client:     c,
+		kubeClient: fake.NewSimpleClientset(),
+		newAssigner: func(context.Context, types.CloudProvider) (address.Assigner, error) {
+			return assigner, nil
+		},
+		log:       logrus.NewEntry(logrus.New()),
+		cfg:       &config.Config{ReleaseOnExit: true},
+		assigners: make(map[types.CloudProvider]address.Assigner),
+		assigned:  make(map[string]*assignment),
+		pending:   make(map[string]*pendingAssignment),
+		recorder:  status.NewRecorder(c),
+		labeler:   nd.NewNoopLabeler(),
+		events:    events.NewRecorder(eventRecorder),
+	}
+	if err := r.Recover(context.Background()); err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if len(r.assigned) != 1 || r.assigned["test-node"] == nil || r.assigned["test-node"].address != "1.1.1.1" {
+		t.Errorf("Recover() assigned = %v, want only test-node with 1.1.1.1", r.assigned)
+	}
+	if r.assigned["test-node"] != nil && r.assigned["test-node"].node.Instance != "test-instance" {
+		t.Errorf("Recover() assigned instance = %v, want test-instance", r.assigned["test-node"].node.Instance)
+	}
+	var released v1alpha1.StaticIPAssignment
+	if err := c.Get(context.Background(), client.ObjectKey{Name: "deleted-node"}, &released); err != nil {
+		t.Fatalf("failed to get static IP assignment: %v", err)
+	}
+	if released.Status.Phase != v1alpha1.AssignmentPhaseReleased {
+		t.Errorf("deleted node assignment phase = %v, want %v", released.Status.Phase, v1alpha1.AssignmentPhaseReleased)
+	}
+}
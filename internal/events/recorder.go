@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+
+	"github.com/doitintl/kubeip/internal/types"
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Component is the source component of the kubeip events
+const Component = "kubeip"
+
+// Reasons of the events recorded on the Node for each step of the static public IP address assignment lifecycle
+const (
+	ReasonAddressSelected = "StaticIPSelected"
+	ReasonAssigned        = "StaticIPAssigned"
+	ReasonAlreadyAssigned = "StaticIPAlreadyAssigned"
+	ReasonPoolExhausted   = "StaticIPPoolExhausted"
+	ReasonAssignFailed    = "StaticIPAssignFailed"
+	ReasonTaintRemoved    = "TaintRemoved"
+	ReasonTaintAdded      = "TaintAdded"
+	ReasonReleased        = "StaticIPReleased"
+	ReasonReleaseFailed   = "StaticIPReleaseFailed"
+	ReasonAddressExcluded = "StaticIPExcluded"
+	ReasonDrifted         = "StaticIPDrifted"
+)
+
+// Recorder records events on the Node of a static public IP address assignment.
+type Recorder interface {
+	Eventf(node *types.Node, eventType, reason, messageFmt string, args ...interface{})
+}
+
+type recorder struct {
+	recorder record.EventRecorder
+}
+
+// NewRecorder creates a Recorder recording events with the client-go event recorder.
+func NewRecorder(eventRecorder record.EventRecorder) Recorder {
+	return &recorder{recorder: eventRecorder}
+}
+
+// NewBroadcastRecorder creates a Recorder sending events to the Kubernetes API; call the returned function to
+// flush the pending events and stop the broadcaster.
+func NewBroadcastRecorder(client kubernetes.Interface, host string) (Recorder, func()) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	eventRecorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: Component, Host: host})
+	return NewRecorder(eventRecorder), broadcaster.Shutdown
+}
+
+// Eventf records an event on the node.
+func (r *recorder) Eventf(node *types.Node, eventType, reason, messageFmt string, args ...interface{}) {
+	r.recorder.Eventf(nodeReference(node), eventType, reason, messageFmt, args...)
+}
+
+// nodeReference returns the reference of the node; like the kubelet, use the node name as UID, which kubectl
+// describe node looks up together with the node UID
+func nodeReference(node *types.Node) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind: "Node",
+		Name: node.Name,
+		UID:  k8stypes.UID(node.Name),
+	}
+}
+
+type noopRecorder struct{}
+
+// NewNoopRecorder creates a Recorder that does not record anything.
+func NewNoopRecorder() Recorder {
+	return noopRecorder{}
+}
+
+func (noopRecorder) Eventf(*types.Node, string, string, string, ...interface{}) {}
+
+type contextKey struct{}
+
+type nodeRecorder struct {
+	recorder Recorder
+	node     *types.Node
+}
+
+// NewContext returns a context recording the events of the assign call chain on the node.
+func NewContext(ctx context.Context, r Recorder, node *types.Node) context.Context {
+	return context.WithValue(ctx, contextKey{}, &nodeRecorder{recorder: r, node: node})
+}
+
+// Eventf records an event on the node of the context, if any.
+func Eventf(ctx context.Context, eventType, reason, messageFmt string, args ...interface{}) {
+	if nr, ok := ctx.Value(contextKey{}).(*nodeRecorder); ok {
+		nr.recorder.Eventf(nr.node, eventType, reason, messageFmt, args...)
+	}
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/doitintl/kubeip/internal/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEventf(t *testing.T) {
+	node := &types.Node{Name: "test-node"}
+	tests := []struct {
+		name  string
+		ctxFn func(r Recorder) context.Context
+		want  []string
+	}{
+		{
+			name: "record event on node of context",
+			ctxFn: func(r Recorder) context.Context {
+				return NewContext(context.Background(), r, node)
+			},
+			want: []string{"Normal StaticIPSelected Selected static public IP address 1.1.1.1"},
+		},
+		{
+			name: "skip event without node",
+			ctxFn: func(Recorder) context.Context {
+				return context.Background()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeRecorder := record.NewFakeRecorder(1)
+			ctx := tt.ctxFn(NewRecorder(fakeRecorder))
+			Eventf(ctx, corev1.EventTypeNormal, ReasonAddressSelected, "Selected static public IP address %s", "1.1.1.1")
+			close(fakeRecorder.Events)
+			var got []string
+			for event := range fakeRecorder.Events {
+				got = append(got, event)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Eventf() events = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Eventf() event = %v, want %v", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
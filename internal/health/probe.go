@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+var errNotReady = errors.New("static public IP address is not assigned yet")
+
+// Probe tracks the state of the agent for the liveness and readiness probes: the agent is live while its retry
+// loops keep advancing and ready once the static public IP address is assigned and reported by the node.
+type Probe struct {
+	mu           sync.Mutex
+	ready        bool
+	idle         bool
+	lastProgress time.Time
+	stallTimeout time.Duration
+	now          func() time.Time
+}
+
+// NewProbe creates a new Probe; the agent is considered stuck when it does not advance for the stall timeout.
+func NewProbe(stallTimeout time.Duration) *Probe {
+	return &Probe{
+		lastProgress: time.Now(),
+		stallTimeout: stallTimeout,
+		now:          time.Now,
+	}
+}
+
+// Progress records that the agent advanced, for example on each retry loop iteration.
+func (p *Probe) Progress() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastProgress = p.now()
+}
+
+// SetReady marks the agent ready or not ready; once ready, the agent idles until shutdown and no longer has to advance.
+func (p *Probe) SetReady(ready bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ready = ready
+	if ready {
+		p.idle = true
+	}
+}
+
+// Live returns an error if the agent did not advance for the stall timeout.
+func (p *Probe) Live(_ *http.Request) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if stalled := p.now().Sub(p.lastProgress); !p.idle && stalled > p.stallTimeout {
+		return errors.Errorf("no progress for %v", stalled.Round(time.Second))
+	}
+	return nil
+}
+
+// Ready returns an error until the agent is marked ready.
+func (p *Probe) Ready(_ *http.Request) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.ready {
+		return errNotReady
+	}
+	return nil
+}
+
+// Serve exposes the /healthz and /readyz probes of the Probe on the address until the context is done.
+func Serve(ctx context.Context, address string, p *Probe) error {
+	mux := http.NewServeMux()
+	handle(mux, "/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{"progress": p.Live}})
+	handle(mux, "/readyz", &healthz.Handler{Checks: map[string]healthz.Checker{"assigned": p.Ready}})
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx) //nolint:errcheck,contextcheck
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrapf(err, "failed to serve health probes on %s", address)
+	}
+	return nil
+}
+
+// handle registers the handler on the path and its individual check sub-paths
+func handle(mux *http.ServeMux, path string, handler http.Handler) {
+	mux.Handle(path, http.StripPrefix(path, handler))
+	mux.Handle(path+"/", http.StripPrefix(path, handler))
+}
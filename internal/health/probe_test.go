@@ -0,0 +1,72 @@
+package health
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		update    func(p *Probe, now *time.Time)
+		wantLive  bool
+		wantReady bool
+	}{
+		{
+			name:     "starting",
+			update:   func(*Probe, *time.Time) {},
+			wantLive: true,
+		},
+		{
+			name: "advancing",
+			update: func(p *Probe, now *time.Time) {
+				*now = now.Add(4 * time.Minute)
+				p.Progress()
+				*now = now.Add(4 * time.Minute)
+			},
+			wantLive: true,
+		},
+		{
+			name: "stuck",
+			update: func(_ *Probe, now *time.Time) {
+				*now = now.Add(6 * time.Minute)
+			},
+		},
+		{
+			name: "ready",
+			update: func(p *Probe, now *time.Time) {
+				p.SetReady(true)
+				*now = now.Add(time.Hour)
+			},
+			wantLive:  true,
+			wantReady: true,
+		},
+		{
+			name: "shutting down",
+			update: func(p *Probe, now *time.Time) {
+				p.SetReady(true)
+				p.SetReady(false)
+				*now = now.Add(time.Hour)
+			},
+			wantLive: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			p := NewProbe(5 * time.Minute)
+			p.now = func() time.Time { return now }
+			p.lastProgress = start
+			tt.update(p, &now)
+			req := httptest.NewRequest("GET", "/healthz", nil)
+			if err := p.Live(req); (err == nil) != tt.wantLive {
+				t.Errorf("Live() error = %v, wantLive %v", err, tt.wantLive)
+			}
+			if err := p.Ready(req); (err == nil) != tt.wantReady {
+				t.Errorf("Ready() error = %v, wantReady %v", err, tt.wantReady)
+			}
+		})
+	}
+}
@@ -2,19 +2,35 @@ package lease
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"github.com/doitintl/kubeip/internal/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	coordinationv1 "k8s.io/api/coordination/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/ptr"
 )
 
+// queuePollJitter is the jitter of the poll delay of the lock queue
+const queuePollJitter = 0.5
+
+// ErrLockLost is returned when the lock is no longer held by its holder
+var ErrLockLost = errors.New("lease lock lost")
+
+// KubeLock is a cluster wide lock held with a Kubernetes lease. The lease is only acquired, renewed and released
+// with conditional updates (resourceVersion precondition), and its transitions count is the fencing token of the holder.
 type KubeLock interface {
 	Lock(ctx context.Context) error
 	Unlock(ctx context.Context) error
+	// Check returns ErrLockLost if the lock is no longer held, for example before a mutating cloud call
+	Check(ctx context.Context) error
+	// Token returns the fencing token of the held lock: the lease transitions count when the lock was acquired
+	Token() int32
 }
 
 type kubeLeaseLock struct {
@@ -24,75 +40,145 @@ type kubeLeaseLock struct {
 	holderIdentity string
 	leaseDuration  int // seconds
 	cancelFunc     context.CancelFunc
+	logger         logrus.FieldLogger
+
+	mu        sync.Mutex
+	token     int32
+	lost      bool
+	renewedAt time.Time // last successful acquire or renew of the lease
 }
 
 func NewKubeLeaseLock(client kubernetes.Interface, leaseName, namespace, holderIdentity string, leaseDurationSeconds int) KubeLock {
+	return newKubeLeaseLock(logrus.StandardLogger(), client, leaseName, namespace, holderIdentity, leaseDurationSeconds)
+}
+
+func newKubeLeaseLock(logger logrus.FieldLogger, client kubernetes.Interface, leaseName, namespace, holderIdentity string, leaseDurationSeconds int) *kubeLeaseLock {
 	return &kubeLeaseLock{
 		client:         client,
 		leaseName:      leaseName,
 		namespace:      namespace,
 		holderIdentity: holderIdentity,
 		leaseDuration:  leaseDurationSeconds,
+		logger:         logger.WithField("holder", holderIdentity),
 	}
 }
 
+// Lock waits for the lock in the FIFO queue of its holders, then acquires it.
 func (k *kubeLeaseLock) Lock(ctx context.Context) error {
-	backoff := wait.Backoff{
-		Duration: time.Second, // start with 1 second
-		Factor:   1.5,         //nolint:gomnd // multiply by 1.5 on each retry
-		Jitter:   0.5,         //nolint:gomnd // add 50% jitter to wait time on each retry
-		Steps:    100,         //nolint:gomnd // retry 100 times
-		Cap:      time.Hour,   // but never wait more than 1 hour
+	start := time.Now()
+	defer func() {
+		metrics.LockWait.Observe(time.Since(start).Seconds())
+	}()
+
+	q, err := k.joinQueue(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to join lock queue")
 	}
+	defer q.leave(ctx)
+
+	for {
+		position, err := q.position(ctx)
+		if err != nil {
+			return err
+		}
+		// only the first holder of the queue tries the lock
+		if position == 0 {
+			acquired, err := k.tryLock(ctx)
+			if err != nil || acquired {
+				return err
+			}
+		}
+		// poll more often closer to the head of the queue, at least once per lease duration to keep the ticket alive
+		delay := time.Duration(min(position+1, k.leaseDuration)) * time.Second
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "context cancelled while waiting for lock")
+		case <-time.After(wait.Jitter(delay, queuePollJitter)):
+		}
+	}
+}
+
+// tryLock tries once to acquire the lease and, if acquired, keeps renewing it until unlocked
+func (k *kubeLeaseLock) tryLock(ctx context.Context) (bool, error) {
+	acquired, err := k.tryAcquire(ctx)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	// Create a child context with cancellation
+	var renewCtx context.Context
+	renewCtx, k.cancelFunc = context.WithCancel(ctx)
+	go k.renewLeasePeriodically(renewCtx)
+
+	return true, nil
+}
 
-	return wait.ExponentialBackoff(backoff, func() (bool, error) { //nolint:wrapcheck
-		timestamp := metav1.MicroTime{Time: time.Now()}
-		lease := &coordinationv1.Lease{
+// tryAcquire creates the lease, or takes it over if it is released or expired; returns false if the lease is held
+// by another holder or was changed concurrently
+func (k *kubeLeaseLock) tryAcquire(ctx context.Context) (bool, error) {
+	now := time.Now()
+	lease, err := k.client.CoordinationV1().Leases(k.namespace).Get(ctx, k.leaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      k.leaseName,
 				Namespace: k.namespace,
 			},
-			Spec: coordinationv1.LeaseSpec{
-				HolderIdentity:       &k.holderIdentity,
-				LeaseDurationSeconds: ptr.To(int32(k.leaseDuration)),
-				AcquireTime:          &timestamp,
-				RenewTime:            &timestamp,
-			},
 		}
-
-		_, err := k.client.CoordinationV1().Leases(k.namespace).Create(ctx, lease, metav1.CreateOptions{})
-		if err != nil {
-			if errors.IsAlreadyExists(err) {
-				// If the lease already exists, check if it's held by another holder
-				existingLease, getErr := k.client.CoordinationV1().Leases(k.namespace).Get(ctx, k.leaseName, metav1.GetOptions{})
-				if getErr != nil {
-					return false, getErr //nolint:wrapcheck
-				}
-				// check if the lease is expired
-				if existingLease.Spec.RenewTime != nil && time.Since(existingLease.Spec.RenewTime.Time) > time.Duration(k.leaseDuration)*time.Second {
-					// If the lease is expired, delete it and retry
-					delErr := k.client.CoordinationV1().Leases(k.namespace).Delete(ctx, k.leaseName, metav1.DeleteOptions{})
-					if delErr != nil {
-						return false, delErr //nolint:wrapcheck
-					}
-					return false, nil
-				}
-				// check if the lease is held by another holder
-				if existingLease.Spec.HolderIdentity != nil && *existingLease.Spec.HolderIdentity != k.holderIdentity {
-					// If the lease is held by another holder, return false to retry
-					return false, nil
-				}
-				return true, nil
-			}
-			return false, err //nolint:wrapcheck
+		k.hold(lease, now)
+		created, createErr := k.client.CoordinationV1().Leases(k.namespace).Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(createErr) {
+			// another holder created the lease first
+			return false, nil
+		}
+		if createErr != nil {
+			return false, createErr //nolint:wrapcheck
 		}
+		k.acquired(created)
+		return true, nil
+	}
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
 
-		// Create a child context with cancellation
-		ctx, k.cancelFunc = context.WithCancel(ctx)
-		go k.renewLeasePeriodically(ctx)
+	// the lease is held by another holder: retry
+	if isHeld(lease, now) && stringOrEmpty(lease.Spec.HolderIdentity) != k.holderIdentity {
+		return false, nil
+	}
 
-		return true, nil
-	})
+	// take over the released or expired lease (or the lease of a previous run of this holder); the update fails
+	// with a conflict if another holder changed the lease since it was read
+	k.hold(lease, now)
+	lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	updated, err := k.client.CoordinationV1().Leases(k.namespace).Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	k.acquired(updated)
+	return true, nil
+}
+
+// hold sets the holder, the duration and the acquire and renew times of the lease
+func (k *kubeLeaseLock) hold(lease *coordinationv1.Lease, now time.Time) {
+	timestamp := metav1.MicroTime{Time: now}
+	lease.Spec.HolderIdentity = ptr.To(k.holderIdentity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(k.leaseDuration))
+	lease.Spec.AcquireTime = &timestamp
+	lease.Spec.RenewTime = &timestamp
+}
+
+// acquired records the fencing token of the acquired lease
+func (k *kubeLeaseLock) acquired(lease *coordinationv1.Lease) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.token = ptr.Deref(lease.Spec.LeaseTransitions, 0)
+	k.lost = false
+	if lease.Spec.RenewTime != nil {
+		k.renewedAt = lease.Spec.RenewTime.Time
+	}
 }
 
 func (k *kubeLeaseLock) renewLeasePeriodically(ctx context.Context) {
@@ -103,13 +189,31 @@ func (k *kubeLeaseLock) renewLeasePeriodically(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
-			lease, err := k.client.CoordinationV1().Leases(k.namespace).Get(ctx, k.leaseName, metav1.GetOptions{})
-			if err != nil || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != k.holderIdentity {
+			lease, err := k.get(ctx)
+			if errors.Is(err, ErrLockLost) {
+				k.markLost()
+				return
+			}
+			if err == nil {
+				now := time.Now()
+				lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+				if _, err = k.client.CoordinationV1().Leases(k.namespace).Update(ctx, lease, metav1.UpdateOptions{}); err == nil {
+					k.renewed(now)
+					continue
+				}
+				err = errors.Wrap(err, "failed to update lease")
+			}
+			if ctx.Err() != nil {
+				// unlocked while renewing
+				return
+			}
+			// retry on the next tick (a conflict means the lease changed since it was read), until the lease expires
+			k.logger.WithError(err).Warn("failed to renew lease")
+			if k.renewExpired(time.Now()) {
+				k.logger.Error("lease not renewed within the lease duration, lock lost")
+				k.markLost()
 				return
 			}
-
-			lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
-			k.client.CoordinationV1().Leases(k.namespace).Update(ctx, lease, metav1.UpdateOptions{}) //nolint:errcheck
 		case <-ctx.Done():
 			// Exit the goroutine when the context is cancelled
 			return
@@ -117,19 +221,119 @@ func (k *kubeLeaseLock) renewLeasePeriodically(ctx context.Context) {
 	}
 }
 
+// renewed records the renew time of the lease
+func (k *kubeLeaseLock) renewed(now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.renewedAt = now
+}
+
+// renewExpired returns true if the lease was not renewed within the lease duration
+func (k *kubeLeaseLock) renewExpired(now time.Time) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return now.Sub(k.renewedAt) > time.Duration(k.leaseDuration)*time.Second
+}
+
+func (k *kubeLeaseLock) markLost() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lost = true
+}
+
+// get returns the lease if it is still held with the fencing token of this lock, ErrLockLost otherwise
+func (k *kubeLeaseLock) get(ctx context.Context) (*coordinationv1.Lease, error) {
+	k.mu.Lock()
+	token, lost := k.token, k.lost
+	k.mu.Unlock()
+	if lost {
+		return nil, ErrLockLost
+	}
+
+	lease, err := k.client.CoordinationV1().Leases(k.namespace).Get(ctx, k.leaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrLockLost
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get lease")
+	}
+	if stringOrEmpty(lease.Spec.HolderIdentity) != k.holderIdentity || ptr.Deref(lease.Spec.LeaseTransitions, 0) != token {
+		return nil, ErrLockLost
+	}
+	return lease, nil
+}
+
+// Check returns ErrLockLost if the lease is no longer held with the fencing token of this lock or is expired.
+func (k *kubeLeaseLock) Check(ctx context.Context) error {
+	lease, err := k.get(ctx)
+	if err != nil {
+		return err
+	}
+	if !isHeld(lease, time.Now()) {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Token returns the fencing token of the held lock.
+func (k *kubeLeaseLock) Token() int32 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.token
+}
+
+// Unlock releases the lease, keeping its transitions count, if it is still held with the fencing token of this lock.
 func (k *kubeLeaseLock) Unlock(ctx context.Context) error {
 	// Call the cancel function to stop the lease renewal process
 	if k.cancelFunc != nil {
 		k.cancelFunc()
 	}
-	lease, err := k.client.CoordinationV1().Leases(k.namespace).Get(ctx, k.leaseName, metav1.GetOptions{})
+	lease, err := k.get(ctx)
+	if errors.Is(err, ErrLockLost) {
+		return nil
+	}
 	if err != nil {
-		return err //nolint:wrapcheck
+		return err
 	}
 
-	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != k.holderIdentity {
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+	_, err = k.client.CoordinationV1().Leases(k.namespace).Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		// the lease was taken over since it was read
 		return nil
 	}
+	return errors.Wrap(err, "failed to release lease")
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the lock, checked with CheckHeld before mutating cloud calls.
+func NewContext(ctx context.Context, lock KubeLock) context.Context {
+	return context.WithValue(ctx, contextKey{}, lock)
+}
 
-	return k.client.CoordinationV1().Leases(k.namespace).Delete(ctx, k.leaseName, metav1.DeleteOptions{}) //nolint:wrapcheck
+// CheckHeld returns ErrLockLost if the lock of the context is no longer held; nil if the context carries no lock.
+func CheckHeld(ctx context.Context) error {
+	if lock, ok := ctx.Value(contextKey{}).(KubeLock); ok {
+		return lock.Check(ctx)
+	}
+	return nil
+}
+
+// isHeld returns true if the lease has a holder and is not expired
+func isHeld(lease *coordinationv1.Lease, now time.Time) bool {
+	if stringOrEmpty(lease.Spec.HolderIdentity) == "" || lease.Spec.RenewTime == nil {
+		return false
+	}
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+	return now.Sub(lease.Spec.RenewTime.Time) <= duration
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
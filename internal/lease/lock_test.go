@@ -7,11 +7,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/utils/ptr"
 )
 
@@ -134,3 +138,86 @@ func TestConcurrentLock(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestFencingToken(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	lock1 := NewKubeLeaseLock(client, "test-lease", "test-namespace", "test-holder-1", 5)
+	require.NoError(t, lock1.Lock(ctx))
+	assert.NoError(t, lock1.Check(ctx))
+	assert.NoError(t, CheckHeld(NewContext(ctx, lock1)))
+	require.NoError(t, lock1.Unlock(ctx))
+
+	// the released lease is kept with its transitions count
+	lease, err := client.CoordinationV1().Leases("test-namespace").Get(ctx, "test-lease", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, lease.Spec.HolderIdentity)
+
+	lock2 := NewKubeLeaseLock(client, "test-lease", "test-namespace", "test-holder-2", 5)
+	require.NoError(t, lock2.Lock(ctx))
+	assert.Greater(t, lock2.Token(), lock1.Token())
+	assert.ErrorIs(t, lock1.Check(ctx), ErrLockLost)
+
+	// a stale holder with the same identity but an old token has lost the lock
+	lease, err = client.CoordinationV1().Leases("test-namespace").Get(ctx, "test-lease", metav1.GetOptions{})
+	require.NoError(t, err)
+	lease.Spec.LeaseTransitions = ptr.To(lock2.Token() + 1)
+	_, err = client.CoordinationV1().Leases("test-namespace").Update(ctx, lease, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, CheckHeld(NewContext(ctx, lock2)), ErrLockLost)
+	assert.NoError(t, lock2.Unlock(ctx))
+
+	// the context without a lock is not checked
+	assert.NoError(t, CheckHeld(ctx))
+}
+
+func TestLockRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	expired := metav1.MicroTime{Time: time.Now().Add(-time.Minute)}
+	client := fake.NewSimpleClientset(&v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-lease", Namespace: "test-namespace"},
+		Spec: v1.LeaseSpec{
+			HolderIdentity:       ptr.To("another-holder"),
+			LeaseDurationSeconds: ptr.To(int32(1)),
+			RenewTime:            &expired,
+			LeaseTransitions:     ptr.To(int32(3)),
+		},
+	})
+	// the first takeover loses the race with another holder
+	conflicts := 1
+	client.PrependReactor("update", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			conflicts--
+			return true, nil, apierrors.NewConflict(v1.Resource("leases"), "test-lease", fmt.Errorf("object was modified"))
+		}
+		return false, nil, nil
+	})
+
+	lock := NewKubeLeaseLock(client, "test-lease", "test-namespace", "test-holder", 5)
+	require.NoError(t, lock.Lock(ctx))
+	assert.Equal(t, int32(4), lock.Token())
+	assert.NoError(t, lock.Check(ctx))
+	assert.NoError(t, lock.Unlock(ctx))
+}
+
+func TestLockLostWhenRenewFails(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	lock := newKubeLeaseLock(logrus.StandardLogger(), client, "test-lease", "test-namespace", "test-holder", 1)
+	require.NoError(t, lock.Lock(ctx))
+	assert.NoError(t, lock.Check(ctx))
+
+	// every renew fails with an error other than a conflict
+	client.PrependReactor("update", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInternalError(fmt.Errorf("etcd unavailable"))
+	})
+
+	assert.Eventually(t, func() bool {
+		lock.mu.Lock()
+		defer lock.mu.Unlock()
+		return lock.lost
+	}, 5*time.Second, 100*time.Millisecond)
+	assert.ErrorIs(t, CheckHeld(NewContext(ctx, lock)), ErrLockLost)
+	assert.NoError(t, lock.Unlock(ctx))
+}
@@ -0,0 +1,200 @@
+package lease
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// queueLabel labels the ticket leases with the name of the lock they wait for
+	queueLabel = "kubeip.doit.com/lock-queue"
+	// ticketAnnotation is the ticket number of a ticket lease
+	ticketAnnotation = "kubeip.doit.com/ticket"
+	// nextTicketAnnotation is the next ticket number of the queue lease
+	nextTicketAnnotation = "kubeip.doit.com/next-ticket"
+	// ticketDurationFactor is the ticket lease duration, in lease durations; a ticket not renewed in time is dropped
+	ticketDurationFactor = 3
+)
+
+// queue is the FIFO queue of the holders waiting for a lock: each holder takes the next number of the ticket counter,
+// kept on the <lock>-queue lease, and keeps a <lock>-ticket-<hash> lease alive while waiting. The lock is only tried
+// by the holder with the lowest live ticket number.
+type queue struct {
+	lock     *kubeLeaseLock
+	number   int64
+	ticket   *coordinationv1.Lease
+	renewed  time.Time
+	lastSeen int
+	logger   logrus.FieldLogger
+}
+
+// joinQueue takes the next ticket of the queue of the lock and creates the ticket lease of the holder
+func (k *kubeLeaseLock) joinQueue(ctx context.Context) (*queue, error) {
+	number, err := k.nextTicket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := &queue{
+		lock:     k,
+		number:   number,
+		lastSeen: -1,
+		logger:   k.logger.WithFields(logrus.Fields{"lock": k.leaseName, "ticket": number}),
+	}
+	if err = q.renew(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// nextTicket increments the ticket counter of the queue with a conditional update and returns the taken number
+func (k *kubeLeaseLock) nextTicket(ctx context.Context) (int64, error) {
+	leases := k.client.CoordinationV1().Leases(k.namespace)
+	name := k.leaseName + "-queue"
+	for {
+		counter, err := leases.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			counter = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   k.namespace,
+				Annotations: map[string]string{nextTicketAnnotation: "1"},
+			}}
+			_, err = leases.Create(ctx, counter, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				continue
+			}
+			if err != nil {
+				return 0, errors.Wrap(err, "failed to create lock queue")
+			}
+			return 0, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get lock queue")
+		}
+		number, _ := strconv.ParseInt(counter.Annotations[nextTicketAnnotation], 10, 64)
+		if counter.Annotations == nil {
+			counter.Annotations = map[string]string{}
+		}
+		counter.Annotations[nextTicketAnnotation] = strconv.FormatInt(number+1, 10)
+		_, err = leases.Update(ctx, counter, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			// another holder took a ticket since the counter was read
+			continue
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to take lock queue ticket")
+		}
+		return number, nil
+	}
+}
+
+// renew creates or renews the ticket lease; a ticket lease deleted as expired is created again with the same number
+func (q *queue) renew(ctx context.Context, now time.Time) error {
+	leases := q.lock.client.CoordinationV1().Leases(q.lock.namespace)
+	timestamp := metav1.MicroTime{Time: now}
+	if q.ticket != nil {
+		q.ticket.Spec.RenewTime = &timestamp
+		ticket, err := leases.Update(ctx, q.ticket, metav1.UpdateOptions{})
+		if err == nil {
+			q.ticket, q.renewed = ticket, now
+			return nil
+		}
+		if !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			return errors.Wrap(err, "failed to renew lock queue ticket")
+		}
+	}
+	name := q.lock.leaseName + "-ticket-" + hash(q.lock.holderIdentity)
+	ticket := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   q.lock.namespace,
+			Labels:      map[string]string{queueLabel: q.lock.leaseName},
+			Annotations: map[string]string{ticketAnnotation: strconv.FormatInt(q.number, 10)},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(q.lock.holderIdentity),
+			LeaseDurationSeconds: ptr.To(int32(q.lock.leaseDuration * ticketDurationFactor)),
+			AcquireTime:          &timestamp,
+			RenewTime:            &timestamp,
+		},
+	}
+	created, err := leases.Create(ctx, ticket, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// the ticket of a previous run of this holder: replace it
+		var existing *coordinationv1.Lease
+		if existing, err = leases.Get(ctx, name, metav1.GetOptions{}); err == nil {
+			ticket.ResourceVersion = existing.ResourceVersion
+			created, err = leases.Update(ctx, ticket, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to create lock queue ticket")
+	}
+	q.ticket, q.renewed = created, now
+	return nil
+}
+
+// position returns the number of live tickets ahead of the ticket of the holder, deleting the expired tickets;
+// the ticket is renewed once per lease duration
+func (q *queue) position(ctx context.Context) (int, error) {
+	now := time.Now()
+	if now.Sub(q.renewed) >= time.Duration(q.lock.leaseDuration)*time.Second {
+		if err := q.renew(ctx, now); err != nil {
+			return 0, err
+		}
+	}
+	leases := q.lock.client.CoordinationV1().Leases(q.lock.namespace)
+	tickets, err := leases.List(ctx, metav1.ListOptions{LabelSelector: queueLabel + "=" + q.lock.leaseName})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list lock queue tickets")
+	}
+	position := 0
+	for i := range tickets.Items {
+		ticket := &tickets.Items[i]
+		if ticket.Name == q.ticket.Name {
+			continue
+		}
+		if !isHeld(ticket, now) {
+			// the holder of the expired ticket stopped waiting without leaving the queue
+			err = leases.Delete(ctx, ticket.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &ticket.ResourceVersion}})
+			if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+				q.logger.WithError(err).WithField("expired-ticket", ticket.Name).Debug("failed to delete expired lock queue ticket")
+			}
+			continue
+		}
+		if number, parseErr := strconv.ParseInt(ticket.Annotations[ticketAnnotation], 10, 64); parseErr == nil && number < q.number {
+			position++
+		}
+	}
+	metrics.LockQueuePosition.Set(float64(position))
+	if position != q.lastSeen {
+		log := q.logger.WithField("queue-position", position)
+		if position > 0 {
+			log.Info("waiting for lock in queue")
+		} else {
+			log.Debug("first in lock queue")
+		}
+		q.lastSeen = position
+	}
+	return position, nil
+}
+
+// leave deletes the ticket lease of the holder
+func (q *queue) leave(ctx context.Context) {
+	metrics.LockQueuePosition.Set(0)
+	if q.ticket == nil {
+		return
+	}
+	err := q.lock.client.CoordinationV1().Leases(q.lock.namespace).Delete(ctx, q.ticket.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		q.logger.WithError(err).Warn("failed to delete lock queue ticket")
+	}
+}
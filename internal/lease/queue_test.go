@@ -0,0 +1,86 @@
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestLockQueueOrder(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	first := NewKubeLeaseLock(client, "test-lease", "test-namespace", "test-holder-0", 2)
+	require.NoError(t, first.Lock(ctx))
+
+	// the holders join the queue in order while the lock is held
+	acquired := make(chan string, 2)
+	for _, holder := range []string{"test-holder-1", "test-holder-2"} {
+		go func(holder string) {
+			lock := NewKubeLeaseLock(client, "test-lease", "test-namespace", holder, 2)
+			if err := lock.Lock(ctx); err == nil {
+				acquired <- holder
+				time.Sleep(100 * time.Millisecond)
+				lock.Unlock(ctx) //nolint:errcheck
+			}
+		}(holder)
+		time.Sleep(200 * time.Millisecond)
+	}
+	tickets, err := client.CoordinationV1().Leases("test-namespace").List(ctx, metav1.ListOptions{LabelSelector: queueLabel + "=test-lease"})
+	require.NoError(t, err)
+	assert.Len(t, tickets.Items, 2)
+
+	require.NoError(t, first.Unlock(ctx))
+	for _, want := range []string{"test-holder-1", "test-holder-2"} {
+		select {
+		case holder := <-acquired:
+			assert.Equal(t, want, holder)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s did not acquire the lock", want)
+		}
+	}
+}
+
+func TestLockQueueDropsExpiredTickets(t *testing.T) {
+	ctx := context.Background()
+	expired := metav1.MicroTime{Time: time.Now().Add(-time.Minute)}
+	client := fake.NewSimpleClientset(&v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-lease-ticket-crashed",
+			Namespace:   "test-namespace",
+			Labels:      map[string]string{queueLabel: "test-lease"},
+			Annotations: map[string]string{ticketAnnotation: "0"},
+		},
+		Spec: v1.LeaseSpec{
+			HolderIdentity:       ptr.To("crashed-holder"),
+			LeaseDurationSeconds: ptr.To(int32(3)),
+			RenewTime:            &expired,
+		},
+	}, &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-lease-queue",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{nextTicketAnnotation: "1"},
+		},
+	})
+
+	lock := NewKubeLeaseLock(client, "test-lease", "test-namespace", "test-holder", 1)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, lock.Lock(waitCtx))
+	require.NoError(t, lock.Unlock(ctx))
+
+	_, err := client.CoordinationV1().Leases("test-namespace").Get(ctx, "test-lease-ticket-crashed", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "expired ticket not deleted: %v", err)
+	tickets, err := client.CoordinationV1().Leases("test-namespace").List(ctx, metav1.ListOptions{LabelSelector: queueLabel + "=test-lease"})
+	require.NoError(t, err)
+	assert.Empty(t, tickets.Items)
+}
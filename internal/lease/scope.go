@@ -0,0 +1,129 @@
+package lease
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LockName is the name of the cluster wide lease lock
+	LockName = "kubeip-lock"
+	// hashLength is the length of the hex hash suffix of the pool and address lease names
+	hashLength = 16
+)
+
+// ErrAddressClaimed is returned when the address is claimed by another holder
+var ErrAddressClaimed = errors.New("address is claimed by another holder")
+
+// Claimer is implemented by locks that claim single addresses instead of serialising the assignments.
+type Claimer interface {
+	// Claim tries once to claim the address; returns ErrAddressClaimed if another holder claimed it
+	Claim(ctx context.Context, address string) (KubeLock, error)
+}
+
+// NewScopedLock creates the lock held by the holder while assigning an address matching the filter:
+//   - cluster: the kubeip-lock lease shared by all nodes
+//   - pool: a lease shared by the nodes with the same filter; filters of different pools must not match the same addresses
+//   - address: no lock, each candidate address is claimed with its own lease (see ClaimAddress)
+//   - cloud: no lock, the assigners claim each candidate address on the cloud side with its labels or tags
+func NewScopedLock(logger logrus.FieldLogger, client kubernetes.Interface, holderIdentity string, filter []string, scope types.LockScope, namespace string, leaseDuration int) KubeLock {
+	switch scope {
+	case types.LockScopePool:
+		return newKubeLeaseLock(logger, client, PoolLockName(filter), namespace, holderIdentity, leaseDuration)
+	case types.LockScopeCloud:
+		return noopLock{}
+	case types.LockScopeAddress:
+		return &addressLock{
+			logger:         logger,
+			client:         client,
+			namespace:      namespace,
+			holderIdentity: holderIdentity,
+			leaseDuration:  leaseDuration,
+		}
+	default:
+		return newKubeLeaseLock(logger, client, LockName, namespace, holderIdentity, leaseDuration)
+	}
+}
+
+// PoolLockName returns the name of the lease shared by the nodes assigning addresses matching the filter.
+func PoolLockName(filter []string) string {
+	return LockName + "-pool-" + hash(strings.Join(filter, "\n"))
+}
+
+// AddressLockName returns the name of the lease claiming the address.
+func AddressLockName(address string) string {
+	return LockName + "-address-" + hash(address)
+}
+
+// hash returns a short hex hash of the key, valid in a lease name (IPv6 addresses and filters are not)
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// ClaimAddress claims the address if the lock of the context is a Claimer. The returned context carries the address
+// claim, checked with CheckHeld, and release releases it once the address is assigned or the assignment failed.
+// Without a Claimer, the address is covered by the lock of the context and release does nothing.
+func ClaimAddress(ctx context.Context, address string) (context.Context, func(), error) {
+	claimer, ok := ctx.Value(contextKey{}).(Claimer)
+	if !ok {
+		return ctx, func() {}, nil
+	}
+	claim, err := claimer.Claim(ctx, address)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	release := func() {
+		claim.Unlock(ctx) //nolint:errcheck
+	}
+	return NewContext(ctx, claim), release, nil
+}
+
+// noopLock does not serialise the assignments
+type noopLock struct{}
+
+func (noopLock) Lock(context.Context) error {
+	return nil
+}
+
+func (noopLock) Unlock(context.Context) error {
+	return nil
+}
+
+func (noopLock) Check(context.Context) error {
+	return nil
+}
+
+func (noopLock) Token() int32 {
+	return 0
+}
+
+// addressLock does not serialise the assignments: it claims each candidate address with its own lease
+type addressLock struct {
+	noopLock
+	logger         logrus.FieldLogger
+	client         kubernetes.Interface
+	namespace      string
+	holderIdentity string
+	leaseDuration  int
+}
+
+// Claim tries once to acquire the lease of the address and keeps renewing it until the claim is unlocked.
+func (l *addressLock) Claim(ctx context.Context, address string) (KubeLock, error) {
+	claim := newKubeLeaseLock(l.logger, l.client, AddressLockName(address), l.namespace, l.holderIdentity, l.leaseDuration)
+	acquired, err := claim.tryLock(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to claim address %s", address)
+	}
+	if !acquired {
+		return nil, errors.Wrap(ErrAddressClaimed, address)
+	}
+	return claim, nil
+}
@@ -0,0 +1,99 @@
+package lease
+
+import (
+	"context"
+	"testing"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewScopedLock(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     types.LockScope
+		filter    []string
+		wantLease string
+	}{
+		{
+			name:      "cluster scope",
+			scope:     types.LockScopeCluster,
+			filter:    []string{"labels.env=prod"},
+			wantLease: LockName,
+		},
+		{
+			name:      "pool scope",
+			scope:     types.LockScopePool,
+			filter:    []string{"labels.env=prod"},
+			wantLease: PoolLockName([]string{"labels.env=prod"}),
+		},
+		{
+			name:  "address scope",
+			scope: types.LockScopeAddress,
+		},
+		{
+			name:  "cloud scope",
+			scope: types.LockScopeCloud,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewSimpleClientset()
+			lock := NewScopedLock(logrus.New(), client, "test-holder", tt.filter, tt.scope, "test-namespace", 5)
+			require.NoError(t, lock.Lock(ctx))
+			defer lock.Unlock(ctx) //nolint:errcheck
+
+			leases, err := client.CoordinationV1().Leases("test-namespace").List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			if tt.wantLease == "" {
+				assert.Empty(t, leases.Items)
+				return
+			}
+			lease, err := client.CoordinationV1().Leases("test-namespace").Get(ctx, tt.wantLease, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "test-holder", *lease.Spec.HolderIdentity)
+		})
+	}
+}
+
+func TestPoolLockName(t *testing.T) {
+	assert.Equal(t, PoolLockName([]string{"a", "b"}), PoolLockName([]string{"a", "b"}))
+	assert.NotEqual(t, PoolLockName([]string{"a", "b"}), PoolLockName([]string{"a"}))
+	assert.LessOrEqual(t, len(AddressLockName("2001:db8::1")), 63)
+	assert.NotContains(t, AddressLockName("2001:db8::1"), ":")
+}
+
+func TestClaimAddress(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	ctx1 := NewContext(ctx, NewScopedLock(logrus.New(), client, "test-holder-1", nil, types.LockScopeAddress, "test-namespace", 5))
+	ctx2 := NewContext(ctx, NewScopedLock(logrus.New(), client, "test-holder-2", nil, types.LockScopeAddress, "test-namespace", 5))
+
+	claimCtx, release, err := ClaimAddress(ctx1, "10.0.0.1")
+	require.NoError(t, err)
+	assert.NoError(t, CheckHeld(claimCtx))
+
+	// the address is claimed by the first holder, other addresses are not
+	_, _, err = ClaimAddress(ctx2, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrAddressClaimed)
+	_, release2, err := ClaimAddress(ctx2, "10.0.0.2")
+	require.NoError(t, err)
+	release2()
+
+	// the released address can be claimed again
+	release()
+	_, release, err = ClaimAddress(ctx2, "10.0.0.1")
+	require.NoError(t, err)
+	release()
+
+	// without a claimer the address is covered by the lock of the context
+	claimCtx, release, err = ClaimAddress(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, ctx, claimCtx)
+	release()
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "kubeip"
+
+	// OutcomeSuccess and OutcomeFailure are the outcome label values of the assign and unassign attempts
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	// OperationAssign and OperationWaitAddress are the operation label values of the retries
+	OperationAssign      = "assign"
+	OperationWaitAddress = "wait_address"
+
+	// ResultInSync, ResultDrifted and ResultError are the result label values of the reconciles
+	ResultInSync  = "in_sync"
+	ResultDrifted = "drifted"
+	ResultError   = "error"
+
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+var (
+	// AssignAttempts counts the static public IP address assign attempts by cloud, outcome and reason
+	AssignAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "assign_attempts_total",
+		Help:      "Number of static public IP address assign attempts.",
+	}, []string{"cloud", "outcome", "reason"})
+
+	// UnassignAttempts counts the static public IP address unassign attempts by cloud, outcome and reason
+	UnassignAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unassign_attempts_total",
+		Help:      "Number of static public IP address unassign attempts.",
+	}, []string{"cloud", "outcome", "reason"})
+
+	// AssignmentDuration observes the time from the first assign attempt to the assignment, retries included
+	AssignmentDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "assignment_duration_seconds",
+		Help:      "Time from start to static public IP address assignment.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}, //nolint:gomnd
+	}, []string{"cloud"})
+
+	// LockWait observes the time spent waiting for the cluster wide lease lock
+	LockWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "lock_wait_seconds",
+		Help:      "Time spent waiting for the cluster wide lease lock.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 14), //nolint:gomnd
+	})
+
+	// LockQueuePosition is the number of holders ahead in the FIFO queue of the lease lock, while waiting for it
+	LockQueuePosition = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "lock_queue_position",
+		Help:      "Number of holders ahead in the queue of the lease lock while waiting for it.",
+	})
+
+	// Retries counts the retries of the assign and wait for address operations
+	Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Number of retries of assigning the static public IP address and waiting for the node to report it.",
+	}, []string{"operation"})
+
+	// Reconciles counts the checks that the assigned static public IP address is still assigned, by result
+	Reconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconciles_total",
+		Help:      "Number of checks that the assigned static public IP address is still assigned to the instance.",
+	}, []string{"result"})
+
+	// FreeAddresses is the number of free static public IP addresses seen by the last list call
+	FreeAddresses = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "free_addresses",
+		Help:      "Number of free static public IP addresses seen by the last list call.",
+	}, []string{"cloud"})
+)
+
+func init() {
+	// register with the controller-runtime registry, served by the controller manager metrics server
+	ctrlmetrics.Registry.MustRegister(AssignAttempts, UnassignAttempts, AssignmentDuration, LockWait, LockQueuePosition, Retries, Reconciles, FreeAddresses)
+}
+
+// Serve exposes the metrics on the /metrics path of the address until the context is done.
+func Serve(ctx context.Context, address string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx) //nolint:errcheck,contextcheck
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrapf(err, "failed to serve metrics on %s", address)
+	}
+	return nil
+}
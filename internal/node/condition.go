@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// StaticIPReadyCondition is the node condition reporting whether the node holds its static public IP address
+const StaticIPReadyCondition v1.NodeConditionType = "StaticIPReady"
+
+// Reasons of the StaticIPReady node condition
+const (
+	ConditionReasonAssigned         = "Assigned"
+	ConditionReasonWaitingForReport = "WaitingForReport"
+	ConditionReasonPoolExhausted    = "PoolExhausted"
+	ConditionReasonCloudError       = "CloudError"
+	ConditionReasonDrifted          = "Drifted"
+	ConditionReasonReleased         = "Released"
+)
+
+type ConditionReporter interface {
+	// SetStaticIPReady sets the StaticIPReady condition of the node; the transition time changes with the status only
+	SetStaticIPReady(ctx context.Context, node *types.Node, status v1.ConditionStatus, reason, message string) error
+}
+
+type conditionReporter struct {
+	client kubernetes.Interface
+	now    func() time.Time
+}
+
+func NewConditionReporter(client kubernetes.Interface) ConditionReporter {
+	return &conditionReporter{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// SetStaticIPReady sets the StaticIPReady condition in the node status; the node is not patched if the condition
+// has the same status, reason and message.
+func (r *conditionReporter) SetStaticIPReady(ctx context.Context, node *types.Node, status v1.ConditionStatus, reason, message string) error {
+	_, err := patchNode(ctx, r.client, node.Name, func(n *v1.Node) bool {
+		return setCondition(&n.Status.Conditions, v1.NodeCondition{
+			Type:    StaticIPReadyCondition,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		}, metav1.NewTime(r.now()))
+	}, "status")
+	if err != nil {
+		return errors.Wrap(err, "failed to patch node StaticIPReady condition")
+	}
+	return nil
+}
+
+// setCondition sets the condition in the conditions, keeping the last transition time if the status did not change;
+// returns true if the conditions changed
+func setCondition(conditions *[]v1.NodeCondition, condition v1.NodeCondition, now metav1.Time) bool {
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	for i := range *conditions {
+		existing := &(*conditions)[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return false
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		*existing = condition
+		return true
+	}
+	*conditions = append(*conditions, condition)
+	return true
+}
+
+type noopConditionReporter struct{}
+
+// NewNoopConditionReporter creates a ConditionReporter that does not patch the node.
+func NewNoopConditionReporter() ConditionReporter {
+	return noopConditionReporter{}
+}
+
+func (noopConditionReporter) SetStaticIPReady(context.Context, *types.Node, v1.ConditionStatus, string, string) error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_conditionReporter_SetStaticIPReady(t *testing.T) {
+	before := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	nodeReady := v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue, Reason: "KubeletReady", LastTransitionTime: before, LastHeartbeatTime: before}
+	waiting := v1.NodeCondition{
+		Type:               StaticIPReadyCondition,
+		Status:             v1.ConditionFalse,
+		Reason:             ConditionReasonWaitingForReport,
+		Message:            "waiting",
+		LastHeartbeatTime:  before,
+		LastTransitionTime: before,
+	}
+	tests := []struct {
+		name       string
+		conditions []v1.NodeCondition
+		status     v1.ConditionStatus
+		reason     string
+		message    string
+		want       []v1.NodeCondition
+	}{
+		{
+			name:       "add condition",
+			conditions: []v1.NodeCondition{nodeReady},
+			status:     v1.ConditionFalse,
+			reason:     ConditionReasonPoolExhausted,
+			message:    "exhausted",
+			want: []v1.NodeCondition{nodeReady, {
+				Type:               StaticIPReadyCondition,
+				Status:             v1.ConditionFalse,
+				Reason:             ConditionReasonPoolExhausted,
+				Message:            "exhausted",
+				LastHeartbeatTime:  now,
+				LastTransitionTime: now,
+			}},
+		},
+		{
+			name:       "status changed",
+			conditions: []v1.NodeCondition{nodeReady, waiting},
+			status:     v1.ConditionTrue,
+			reason:     ConditionReasonAssigned,
+			message:    "assigned",
+			want: []v1.NodeCondition{nodeReady, {
+				Type:               StaticIPReadyCondition,
+				Status:             v1.ConditionTrue,
+				Reason:             ConditionReasonAssigned,
+				Message:            "assigned",
+				LastHeartbeatTime:  now,
+				LastTransitionTime: now,
+			}},
+		},
+		{
+			name:       "reason changed keeps transition time",
+			conditions: []v1.NodeCondition{waiting},
+			status:     v1.ConditionFalse,
+			reason:     ConditionReasonCloudError,
+			message:    "api error",
+			want: []v1.NodeCondition{{
+				Type:               StaticIPReadyCondition,
+				Status:             v1.ConditionFalse,
+				Reason:             ConditionReasonCloudError,
+				Message:            "api error",
+				LastHeartbeatTime:  now,
+				LastTransitionTime: before,
+			}},
+		},
+		{
+			name:       "condition unchanged",
+			conditions: []v1.NodeCondition{waiting},
+			status:     v1.ConditionFalse,
+			reason:     ConditionReasonWaitingForReport,
+			message:    "waiting",
+			want:       []v1.NodeCondition{waiting},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewSimpleClientset(&v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Status:     v1.NodeStatus{Conditions: tt.conditions},
+			})
+			reporter := &conditionReporter{client: client, now: func() time.Time { return now.Time }}
+
+			err := reporter.SetStaticIPReady(ctx, &types.Node{Name: "node1"}, tt.status, tt.reason, tt.message)
+			require.NoError(t, err)
+			node, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Len(t, node.Status.Conditions, len(tt.want))
+			for i := range tt.want {
+				assert.True(t, tt.want[i].LastTransitionTime.Equal(&node.Status.Conditions[i].LastTransitionTime), "LastTransitionTime = %v, want %v", node.Status.Conditions[i].LastTransitionTime, tt.want[i].LastTransitionTime)
+				assert.True(t, tt.want[i].LastHeartbeatTime.Equal(&node.Status.Conditions[i].LastHeartbeatTime), "LastHeartbeatTime = %v, want %v", node.Status.Conditions[i].LastHeartbeatTime, tt.want[i].LastHeartbeatTime)
+				node.Status.Conditions[i].LastTransitionTime, node.Status.Conditions[i].LastHeartbeatTime = tt.want[i].LastTransitionTime, tt.want[i].LastHeartbeatTime
+			}
+			assert.Equal(t, tt.want, node.Status.Conditions)
+		})
+	}
+}
@@ -73,6 +73,11 @@ func getInstance(providerID string) (string, error) {
 		return providerID, nil
 	}
 
+	// In case of Azure, the instance ID is the VM resource ID (required to locate the VM network interface)
+	if strings.HasPrefix(providerID, "azure://") {
+		return strings.TrimPrefix(providerID, "azure://"), nil
+	}
+
 	s := strings.Split(providerID, "/")
 	if len(s) < minProviderIDTokens {
 		return "", errors.Errorf("failed to get instance ID")
@@ -191,5 +196,6 @@ func (d *explorer) GetNode(ctx context.Context, nodeName string) (*types.Node, e
 		Pool:        pool,
 		ExternalIPs: externalIPs,
 		InternalIPs: internalIPs,
+		Labels:      n.Labels,
 	}, nil
 }
@@ -443,6 +443,12 @@ func Test_explorer_GetNode(t *testing.T) {
 				InternalIPs: []net.IP{
 					net.ParseIP("10.10.0.1"),
 				},
+				Labels: map[string]string{
+					"eks.amazonaws.com/nodegroup":   "test-node-pool",
+					"beta.kubernetes.io/os":         "linux",
+					"topology.kubernetes.io/region": "us-west-2",
+					"topology.kubernetes.io/zone":   "us-west-2b",
+				},
 			},
 		},
 		{
@@ -486,6 +492,10 @@ func Test_explorer_GetNode(t *testing.T) {
 				InternalIPs: []net.IP{
 					net.ParseIP("10.10.0.1"),
 				},
+				Labels: map[string]string{
+					"topology.kubernetes.io/region": "us-west-2",
+					"topology.kubernetes.io/zone":   "us-west-2b",
+				},
 			},
 		},
 		{
@@ -656,7 +666,7 @@ func Test_getInstance(t *testing.T) {
 			args: args{
 				providerID: "azure:///subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/aks-agentpool-12345678-vmss_0",
 			},
-			want: "aks-agentpool-12345678-vmss_0",
+			want: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/aks-agentpool-12345678-vmss_0",
 		},
 		{
 			name: "gcp",
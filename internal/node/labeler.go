@@ -0,0 +1,106 @@
+package node
+
+import (
+	"context"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// AssignedLabel is set to "true" on nodes holding a static public IP address
+	AssignedLabel = "kubeip.doit.com/assigned"
+	// AddressAnnotation is the static public IP address assigned to the node
+	AddressAnnotation = "kubeip.doit.com/address"
+	// ResourceIDAnnotation is the cloud allocation or resource ID of the static public IP address assigned to the node
+	ResourceIDAnnotation = "kubeip.doit.com/resource-id"
+)
+
+type Labeler interface {
+	SetAssigned(ctx context.Context, node *types.Node, address, resourceID string) error
+	ClearAssigned(ctx context.Context, node *types.Node) error
+}
+
+type labeler struct {
+	client kubernetes.Interface
+}
+
+func NewLabeler(client kubernetes.Interface) Labeler {
+	return &labeler{
+		client: client,
+	}
+}
+
+// SetAssigned labels the node as holding a static public IP address and annotates it with the address and its resource ID.
+func (l *labeler) SetAssigned(ctx context.Context, node *types.Node, address, resourceID string) error {
+	assigned := "true"
+	annotations := map[string]*string{
+		AddressAnnotation:    &address,
+		ResourceIDAnnotation: nil,
+	}
+	if resourceID != "" {
+		annotations[ResourceIDAnnotation] = &resourceID
+	}
+	if err := l.patch(ctx, node, &assigned, annotations); err != nil {
+		return errors.Wrap(err, "failed to patch node static public IP address label")
+	}
+	return nil
+}
+
+// ClearAssigned removes the static public IP address label and annotations from the node.
+func (l *labeler) ClearAssigned(ctx context.Context, node *types.Node) error {
+	annotations := map[string]*string{
+		AddressAnnotation:    nil,
+		ResourceIDAnnotation: nil,
+	}
+	if err := l.patch(ctx, node, nil, annotations); err != nil {
+		return errors.Wrap(err, "failed to remove node static public IP address label")
+	}
+	return nil
+}
+
+// patch sets the assigned label and annotations of the node; nil values remove them
+func (l *labeler) patch(ctx context.Context, node *types.Node, assigned *string, annotations map[string]*string) error {
+	_, err := patchNode(ctx, l.client, node.Name, func(n *v1.Node) bool {
+		changed := setOrDelete(&n.Labels, AssignedLabel, assigned)
+		for key, value := range annotations {
+			changed = setOrDelete(&n.Annotations, key, value) || changed
+		}
+		return changed
+	})
+	return err
+}
+
+// setOrDelete sets the key of the map to the value, or deletes the key if the value is nil; returns true if the map changed
+func setOrDelete(m *map[string]string, key string, value *string) bool {
+	current, ok := (*m)[key]
+	if value == nil {
+		delete(*m, key)
+		return ok
+	}
+	if ok && current == *value {
+		return false
+	}
+	if *m == nil {
+		*m = map[string]string{}
+	}
+	(*m)[key] = *value
+	return true
+}
+
+type noopLabeler struct{}
+
+// NewNoopLabeler creates a Labeler that does not patch the node.
+func NewNoopLabeler() Labeler {
+	return noopLabeler{}
+}
+
+func (noopLabeler) SetAssigned(context.Context, *types.Node, string, string) error {
+	return nil
+}
+
+func (noopLabeler) ClearAssigned(context.Context, *types.Node) error {
+	return nil
+}
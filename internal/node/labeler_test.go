@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/doitintl/kubeip/internal/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_labeler(t *testing.T) {
+	tests := []struct {
+		name            string
+		node            *v1.Node
+		patchFn         func(l Labeler, node *types.Node) error
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+		wantErr         bool
+	}{
+		{
+			name: "set assigned address",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:   "node1",
+				Labels: map[string]string{"pool": "public"},
+			}},
+			patchFn: func(l Labeler, node *types.Node) error {
+				return l.SetAssigned(context.Background(), node, "1.1.1.1", "eipalloc-1")
+			},
+			wantLabels: map[string]string{"pool": "public", AssignedLabel: "true"},
+			wantAnnotations: map[string]string{
+				AddressAnnotation:    "1.1.1.1",
+				ResourceIDAnnotation: "eipalloc-1",
+			},
+		},
+		{
+			name: "set assigned address without resource ID",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Annotations: map[string]string{AddressAnnotation: "2.2.2.2", ResourceIDAnnotation: "eipalloc-2"},
+			}},
+			patchFn: func(l Labeler, node *types.Node) error {
+				return l.SetAssigned(context.Background(), node, "1.1.1.1", "")
+			},
+			wantLabels:      map[string]string{AssignedLabel: "true"},
+			wantAnnotations: map[string]string{AddressAnnotation: "1.1.1.1"},
+		},
+		{
+			name: "clear assigned address",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:        "node1",
+				Labels:      map[string]string{"pool": "public", AssignedLabel: "true"},
+				Annotations: map[string]string{"other": "value", AddressAnnotation: "1.1.1.1", ResourceIDAnnotation: "eipalloc-1"},
+			}},
+			patchFn: func(l Labeler, node *types.Node) error {
+				return l.ClearAssigned(context.Background(), node)
+			},
+			wantLabels:      map[string]string{"pool": "public"},
+			wantAnnotations: map[string]string{"other": "value"},
+		},
+		{
+			name: "node not found",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+			patchFn: func(l Labeler, _ *types.Node) error {
+				return l.SetAssigned(context.Background(), &types.Node{Name: "node1"}, "1.1.1.1", "")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.node)
+			err := tt.patchFn(NewLabeler(client), &types.Node{Name: tt.node.Name})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("patch error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := client.CoreV1().Nodes().Get(context.Background(), tt.node.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			if len(got.Labels) != 0 || len(tt.wantLabels) != 0 {
+				if !reflect.DeepEqual(got.Labels, tt.wantLabels) {
+					t.Errorf("node labels = %v, want %v", got.Labels, tt.wantLabels)
+				}
+			}
+			if len(got.Annotations) != 0 || len(tt.wantAnnotations) != 0 {
+				if !reflect.DeepEqual(got.Annotations, tt.wantAnnotations) {
+					t.Errorf("node annotations = %v, want %v", got.Annotations, tt.wantAnnotations)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typesv1 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// patchNode applies the mutation to the latest node and patches the node with the changes only. The patch carries the
+// resourceVersion of the read node as a precondition: if another controller changed the node since it was read, the
+// API server rejects the patch with a conflict and the mutation is applied again to the latest node.
+// mutate returns false if the node needs no change; patchNode returns true if the node was patched. The status of the
+// node is patched with the "status" subresource.
+func patchNode(ctx context.Context, client kubernetes.Interface, name string, mutate func(node *v1.Node) bool, subresources ...string) (bool, error) {
+	patched := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		patched = false
+		node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrap(err, "failed to get kubernetes node")
+		}
+		updated := node.DeepCopy()
+		if !mutate(updated) {
+			return nil
+		}
+		patch, err := createPatch(node, updated)
+		if err != nil {
+			return err
+		}
+		_, err = client.CoreV1().Nodes().Patch(ctx, name, typesv1.StrategicMergePatchType, patch, metav1.PatchOptions{}, subresources...)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		patched = true
+		return nil
+	})
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	return patched, nil
+}
+
+// createPatch returns the strategic merge patch from the node to the updated node, with the resourceVersion
+// of the node as a precondition
+func createPatch(node, updated *v1.Node) ([]byte, error) {
+	original, err := json.Marshal(node)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal node")
+	}
+	modified, err := json.Marshal(updated)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal updated node")
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(original, modified, v1.Node{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create node patch")
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(patch, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal node patch")
+	}
+	metadata, ok := fields["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		fields["metadata"] = metadata
+	}
+	metadata["resourceVersion"] = node.ResourceVersion
+	patch, err = json.Marshal(fields)
+	return patch, errors.Wrap(err, "failed to marshal node patch")
+}
@@ -0,0 +1,181 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newConcurrentClient returns a fake clientset rejecting node patches with a stale resourceVersion, as the API server
+// does; concurrent modifies the node, as another controller would, before each of the first times patches
+func newConcurrentClient(t *testing.T, node *v1.Node, times int, concurrent func(node *v1.Node)) (*fake.Clientset, *int) {
+	client := fake.NewSimpleClientset(node)
+	nodes := v1.SchemeGroupVersion.WithResource("nodes")
+	patches := 0
+	client.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		patches++
+		obj, err := client.Tracker().Get(nodes, "", patch.GetName())
+		require.NoError(t, err)
+		stored := obj.(*v1.Node)
+		if patches <= times {
+			concurrent(stored)
+			version, _ := strconv.Atoi(stored.ResourceVersion)
+			stored.ResourceVersion = strconv.Itoa(version + 1)
+			require.NoError(t, client.Tracker().Update(nodes, stored, ""))
+		}
+		var fields struct {
+			Metadata metav1.ObjectMeta `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(patch.GetPatch(), &fields))
+		if fields.Metadata.ResourceVersion != stored.ResourceVersion {
+			return true, nil, apierrors.NewConflict(nodes.GroupResource(), patch.GetName(), assert.AnError)
+		}
+		return false, nil, nil
+	})
+	return client, &patches
+}
+
+func Test_patchNode_ConcurrentModification(t *testing.T) {
+	kubeipTaint := v1.Taint{Key: "kubeip.com/not-ready", Effect: v1.TaintEffectNoSchedule}
+	autoscalerTaint := v1.Taint{Key: "ToBeDeletedByClusterAutoscaler", Effect: v1.TaintEffectNoSchedule}
+	addAutoscalerTaint := func(node *v1.Node) {
+		node.Spec.Taints = append(node.Spec.Taints, autoscalerTaint)
+	}
+	tests := []struct {
+		name        string
+		taints      []v1.Taint
+		times       int
+		concurrent  func(node *v1.Node)
+		mutate      func(ctx context.Context, client *fake.Clientset) (bool, error)
+		want        bool
+		wantErr     bool
+		wantPatches int
+		validate    func(t *testing.T, node *v1.Node)
+	}{
+		{
+			name:       "remove taint keeps taint added concurrently",
+			taints:     []v1.Taint{kubeipTaint},
+			times:      1,
+			concurrent: addAutoscalerTaint,
+			mutate: func(ctx context.Context, client *fake.Clientset) (bool, error) {
+				return NewTainter(client).RemoveTaintKey(ctx, &types.Node{Name: "node1"}, kubeipTaint.Key)
+			},
+			want:        true,
+			wantPatches: 2,
+			validate: func(t *testing.T, node *v1.Node) {
+				assert.Equal(t, []v1.Taint{autoscalerTaint}, node.Spec.Taints)
+			},
+		},
+		{
+			name:       "add taint keeps taint added concurrently",
+			times:      1,
+			concurrent: addAutoscalerTaint,
+			mutate: func(ctx context.Context, client *fake.Clientset) (bool, error) {
+				return NewTainter(client).AddTaint(ctx, &types.Node{Name: "node1"}, kubeipTaint)
+			},
+			want:        true,
+			wantPatches: 2,
+			validate: func(t *testing.T, node *v1.Node) {
+				assert.Equal(t, []v1.Taint{autoscalerTaint, kubeipTaint}, node.Spec.Taints)
+			},
+		},
+		{
+			name:   "taint removed concurrently is not patched again",
+			taints: []v1.Taint{kubeipTaint},
+			times:  1,
+			concurrent: func(node *v1.Node) {
+				node.Spec.Taints = nil
+			},
+			mutate: func(ctx context.Context, client *fake.Clientset) (bool, error) {
+				return NewTainter(client).RemoveTaintKey(ctx, &types.Node{Name: "node1"}, kubeipTaint.Key)
+			},
+			want:        false,
+			wantPatches: 1,
+			validate: func(t *testing.T, node *v1.Node) {
+				assert.Empty(t, node.Spec.Taints)
+			},
+		},
+		{
+			name:  "set assigned keeps label added concurrently",
+			times: 1,
+			concurrent: func(node *v1.Node) {
+				node.Labels = map[string]string{"pool": "public"}
+			},
+			mutate: func(ctx context.Context, client *fake.Clientset) (bool, error) {
+				return true, NewLabeler(client).SetAssigned(ctx, &types.Node{Name: "node1"}, "1.1.1.1", "")
+			},
+			want:        true,
+			wantPatches: 2,
+			validate: func(t *testing.T, node *v1.Node) {
+				assert.Equal(t, map[string]string{"pool": "public", AssignedLabel: "true"}, node.Labels)
+				assert.Equal(t, map[string]string{AddressAnnotation: "1.1.1.1"}, node.Annotations)
+			},
+		},
+		{
+			name:  "set condition keeps condition updated concurrently",
+			times: 1,
+			concurrent: func(node *v1.Node) {
+				node.Status.Conditions = append(node.Status.Conditions, v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue})
+			},
+			mutate: func(ctx context.Context, client *fake.Clientset) (bool, error) {
+				return true, NewConditionReporter(client).SetStaticIPReady(ctx, &types.Node{Name: "node1"}, v1.ConditionTrue, ConditionReasonAssigned, "assigned")
+			},
+			want:        true,
+			wantPatches: 2,
+			validate: func(t *testing.T, node *v1.Node) {
+				require.Len(t, node.Status.Conditions, 2)
+				assert.Equal(t, v1.NodeReady, node.Status.Conditions[0].Type)
+				assert.Equal(t, StaticIPReadyCondition, node.Status.Conditions[1].Type)
+				assert.Equal(t, ConditionReasonAssigned, node.Status.Conditions[1].Reason)
+			},
+		},
+		{
+			name:       "node keeps changing",
+			taints:     []v1.Taint{kubeipTaint},
+			times:      100,
+			concurrent: func(*v1.Node) {},
+			mutate: func(ctx context.Context, client *fake.Clientset) (bool, error) {
+				return NewTainter(client).RemoveTaintKey(ctx, &types.Node{Name: "node1"}, kubeipTaint.Key)
+			},
+			want:        false,
+			wantErr:     true,
+			wantPatches: 5,
+			validate: func(t *testing.T, node *v1.Node) {
+				assert.Equal(t, []v1.Taint{kubeipTaint}, node.Spec.Taints)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, patches := newConcurrentClient(t, &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1", ResourceVersion: "1"},
+				Spec:       v1.NodeSpec{Taints: tt.taints},
+			}, tt.times, tt.concurrent)
+
+			got, err := tt.mutate(ctx, client)
+			if tt.wantErr {
+				assert.True(t, apierrors.IsConflict(err), "error = %v, want conflict", err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPatches, *patches)
+			node, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			require.NoError(t, err)
+			tt.validate(t, node)
+		})
+	}
+}
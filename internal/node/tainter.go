@@ -2,31 +2,30 @@ package node
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 
 	"github.com/doitintl/kubeip/internal/types"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	typesv1 "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
 type Tainter interface {
-	RemoveTaintKey(ctx context.Context, node *types.Node, taintKey string) (bool, error)
+	// RemoveTaintKey removes the taints with the keys from the node; returns true if a taint was removed
+	RemoveTaintKey(ctx context.Context, node *types.Node, taintKeys ...string) (bool, error)
+	// AddTaint adds the taints with keys not present on the node; returns true if a taint was added
+	AddTaint(ctx context.Context, node *types.Node, taints ...v1.Taint) (bool, error)
 }
 
 type tainter struct {
 	client kubernetes.Interface
 }
 
-func deleteTaintsByKey(taints []v1.Taint, taintKey string) ([]v1.Taint, bool) {
+func deleteTaintsByKey(taints []v1.Taint, taintKeys ...string) ([]v1.Taint, bool) {
 	newTaints := []v1.Taint{}
 	didDelete := false
 
 	for i := range taints {
-		if taintKey == taints[i].Key {
+		if hasKey(taintKeys, taints[i].Key) {
 			didDelete = true
 			continue
 		}
@@ -42,32 +41,50 @@ func NewTainter(client kubernetes.Interface) Tainter {
 	}
 }
 
-func (t *tainter) RemoveTaintKey(ctx context.Context, node *types.Node, taintKey string) (bool, error) {
-	// get node object from API server
-	n, err := t.client.CoreV1().Nodes().Get(ctx, node.Name, metav1.GetOptions{})
-	if err != nil {
-		return false, errors.Wrap(err, "failed to get kubernetes node")
-	}
-
-	// Remove taint from the node representation
-	newTaints, didDelete := deleteTaintsByKey(n.Spec.Taints, taintKey)
-	if !didDelete {
-		return false, nil
+func hasKey(taintKeys []string, key string) bool {
+	for _, taintKey := range taintKeys {
+		if taintKey == key {
+			return true
+		}
 	}
+	return false
+}
 
-	// Marshal the remaining taints of the node into json format for patching.
-	// The remaining taints may be empty, and that will result in an empty json array "[]"
-	newTaintsMarshaled, err := json.Marshal(newTaints)
+func (t *tainter) RemoveTaintKey(ctx context.Context, node *types.Node, taintKeys ...string) (bool, error) {
+	// remove the taints from the latest node; the remaining taints may be empty
+	removed, err := patchNode(ctx, t.client, node.Name, func(n *v1.Node) bool {
+		newTaints, didDelete := deleteTaintsByKey(n.Spec.Taints, taintKeys...)
+		n.Spec.Taints = newTaints
+		return didDelete
+	})
 	if err != nil {
-		return false, errors.Wrap(err, "failed to marshal new taints")
+		return false, errors.Wrap(err, "failed to patch node taints")
 	}
+	return removed, nil
+}
 
-	// Patch the node with only the remaining taints
-	patch := fmt.Sprintf(`{"spec":{"taints":%v}}`, string(newTaintsMarshaled))
-	_, err = t.client.CoreV1().Nodes().Patch(ctx, node.Name, typesv1.MergePatchType, []byte(patch), metav1.PatchOptions{})
+// AddTaint adds the taints with keys not present on the node; returns true if a taint was added.
+func (t *tainter) AddTaint(ctx context.Context, node *types.Node, taints ...v1.Taint) (bool, error) {
+	// add the taints to the latest node, keeping the taints set by other controllers
+	added, err := patchNode(ctx, t.client, node.Name, func(n *v1.Node) bool {
+		didAdd := false
+		for _, taint := range taints {
+			present := false
+			for i := range n.Spec.Taints {
+				if n.Spec.Taints[i].Key == taint.Key {
+					present = true
+					break
+				}
+			}
+			if !present {
+				n.Spec.Taints = append(n.Spec.Taints, taint)
+				didAdd = true
+			}
+		}
+		return didAdd
+	})
 	if err != nil {
 		return false, errors.Wrap(err, "failed to patch node taints")
 	}
-
-	return true, nil
+	return added, nil
 }
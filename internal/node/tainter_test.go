@@ -271,3 +271,65 @@ func Test_tainter_RemoveTaintKey(t *testing.T) {
 		})
 	}
 }
+
+func Test_tainter_AddTaint(t *testing.T) {
+	taint := v1.Taint{Key: "taint1", Effect: v1.TaintEffectNoSchedule}
+	other := v1.Taint{Key: "taint3", Effect: v1.TaintEffectNoExecute}
+	tests := []struct {
+		name       string
+		taints     []v1.Taint
+		add        []v1.Taint
+		want       bool
+		wantTaints []v1.Taint
+	}{
+		{
+			name:       "add taint",
+			taints:     []v1.Taint{{Key: "taint2", Value: "two", Effect: v1.TaintEffectNoSchedule}},
+			add:        []v1.Taint{taint},
+			want:       true,
+			wantTaints: []v1.Taint{{Key: "taint2", Value: "two", Effect: v1.TaintEffectNoSchedule}, taint},
+		},
+		{
+			name:       "add taint to node without taints",
+			add:        []v1.Taint{taint},
+			want:       true,
+			wantTaints: []v1.Taint{taint},
+		},
+		{
+			name:       "taint key already present on node",
+			taints:     []v1.Taint{{Key: "taint1", Value: "true", Effect: v1.TaintEffectNoExecute}},
+			add:        []v1.Taint{taint},
+			want:       false,
+			wantTaints: []v1.Taint{{Key: "taint1", Value: "true", Effect: v1.TaintEffectNoExecute}},
+		},
+		{
+			name:       "add missing taints only",
+			taints:     []v1.Taint{{Key: "taint1", Value: "true", Effect: v1.TaintEffectNoSchedule}},
+			add:        []v1.Taint{taint, other},
+			want:       true,
+			wantTaints: []v1.Taint{{Key: "taint1", Value: "true", Effect: v1.TaintEffectNoSchedule}, other},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewSimpleClientset(&v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Spec:       v1.NodeSpec{Taints: tt.taints},
+			})
+
+			got, err := NewTainter(client).AddTaint(ctx, &types.Node{Name: "node1"}, tt.add...)
+			if err != nil {
+				t.Fatalf("AddTaint() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AddTaint() got = %v, want %v", got, tt.want)
+			}
+			node, _ := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			if !reflect.DeepEqual(node.Spec.Taints, tt.wantTaints) {
+				t.Errorf("AddTaint() node.Spec.Taints = %v, want %v", node.Spec.Taints, tt.wantTaints)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package node
+
+import (
+	"context"
+	"net"
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WatchExternalIPs returns a channel receiving the external IPs of the node each time the node addresses change,
+// until the context is done. Only the latest external IPs are kept when the receiver is slow.
+func WatchExternalIPs(ctx context.Context, client kubernetes.Interface, nodeName string) <-chan []net.IP {
+	changes := make(chan []net.IP, 1)
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", nodeName).String()
+	}))
+	informer := factory.Core().V1().Nodes().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{ //nolint:errcheck
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, ok := oldObj.(*v1.Node)
+			if !ok {
+				return
+			}
+			newNode, ok := newObj.(*v1.Node)
+			if !ok || reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) {
+				return
+			}
+			externalIPs, _, err := getAddresses(newNode.Status.Addresses)
+			if err != nil {
+				return
+			}
+			// replace the external IPs not yet received
+			select {
+			case <-changes:
+			default:
+			}
+			changes <- externalIPs
+		},
+	})
+	factory.Start(ctx.Done())
+	return changes
+}
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatchExternalIPs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+			{Type: v1.NodeExternalIP, Address: "1.1.1.1"},
+		}},
+	}
+	client := fake.NewSimpleClientset(node)
+
+	changes := WatchExternalIPs(ctx, client, "node1")
+
+	// update the node addresses until the informer has synced and reports a change
+	timeout := time.After(5 * time.Second)
+	for i := 2; ; i++ {
+		node.Status.Addresses = []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: fmt.Sprintf("2.2.2.%d", i)}}
+		if _, err := client.CoreV1().Nodes().UpdateStatus(ctx, node.DeepCopy(), metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("failed to update node: %v", err)
+		}
+		select {
+		case externalIPs := <-changes:
+			if len(externalIPs) != 1 || externalIPs[0].Mask(net.CIDRMask(24, 32)).String() != "2.2.2.0" { //nolint:gomnd
+				t.Errorf("WatchExternalIPs() = %v, want [2.2.2.x]", externalIPs)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("WatchExternalIPs() did not report the address change")
+		}
+	}
+}
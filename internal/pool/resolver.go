@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"context"
+	"sort"
+
+	"github.com/doitintl/kubeip/api/v1alpha1"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Selection is the address filter and order by used to assign a static public IP address to a node.
+type Selection struct {
+	// Pool is the name of the matching StaticIPPool; empty if no pool matches the node
+	Pool    string
+	Filter  []string
+	OrderBy string
+}
+
+// Resolver looks up the StaticIPPool matching a node.
+type Resolver interface {
+	Resolve(ctx context.Context, node *types.Node) (*Selection, error)
+}
+
+type resolver struct {
+	client         client.Reader
+	defaultFilter  []string
+	defaultOrderBy string
+}
+
+// NewResolver creates a new Resolver; nodes not matching any pool use the default filter and order by.
+func NewResolver(c client.Reader, defaultFilter []string, defaultOrderBy string) Resolver {
+	return &resolver{
+		client:         c,
+		defaultFilter:  defaultFilter,
+		defaultOrderBy: defaultOrderBy,
+	}
+}
+
+// Resolve returns the selection of the pool matching the node labels. If several pools match,
+// the pool with the highest priority wins, and pools with the same priority are ordered by name.
+func (r *resolver) Resolve(ctx context.Context, node *types.Node) (*Selection, error) {
+	var pools v1alpha1.StaticIPPoolList
+	if err := r.client.List(ctx, &pools); err != nil {
+		return nil, errors.Wrap(err, "failed to list static IP pools")
+	}
+
+	var matching []v1alpha1.StaticIPPool
+	for i := range pools.Items {
+		ok, err := matchNode(&pools.Items[i], node)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matching = append(matching, pools.Items[i])
+		}
+	}
+	if len(matching) == 0 {
+		return &Selection{Filter: r.defaultFilter, OrderBy: r.defaultOrderBy}, nil
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		if matching[i].Spec.Priority != matching[j].Spec.Priority {
+			return matching[i].Spec.Priority > matching[j].Spec.Priority
+		}
+		return matching[i].Name < matching[j].Name
+	})
+
+	pool := &matching[0]
+	filter, err := buildFilter(&pool.Spec.Selector, node.Cloud)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid static IP pool %s", pool.Name)
+	}
+	return &Selection{Pool: pool.Name, Filter: filter, OrderBy: pool.Spec.OrderBy}, nil
+}
+
+func matchNode(pool *v1alpha1.StaticIPPool, node *types.Node) (bool, error) {
+	if pool.Spec.NodeSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid node selector of static IP pool %s", pool.Name)
+	}
+	return selector.Matches(labels.Set(node.Labels)), nil
+}
+
+// buildFilter builds the assigner filter of the cloud provider from the pool selector.
+func buildFilter(selector *v1alpha1.StaticIPPoolSelector, cloudProvider types.CloudProvider) ([]string, error) {
+	var filter []string
+	switch cloudProvider {
+	case types.CloudProviderGCP:
+		if selector.GCP == nil {
+			return nil, errors.Errorf("no selector for cloud provider %s", cloudProvider)
+		}
+		filter = append(filter, selector.GCP.Filter...)
+	case types.CloudProviderAWS:
+		if selector.AWS == nil {
+			return nil, errors.Errorf("no selector for cloud provider %s", cloudProvider)
+		}
+		filter = append(filter, selector.AWS.Filter...)
+	case types.CloudProviderAzure:
+		if selector.Azure == nil {
+			return nil, errors.Errorf("no selector for cloud provider %s", cloudProvider)
+		}
+		for _, key := range sortedKeys(selector.Azure.Tags) {
+			filter = append(filter, "tags."+key+"="+selector.Azure.Tags[key])
+		}
+		if selector.Azure.PublicIPPrefix != "" {
+			filter = append(filter, "publicIPPrefix="+selector.Azure.PublicIPPrefix)
+		}
+	case types.CloudProviderOCI:
+		if selector.OCI == nil {
+			return nil, errors.Errorf("no selector for cloud provider %s", cloudProvider)
+		}
+		for _, key := range sortedKeys(selector.OCI.FreeformTags) {
+			filter = append(filter, "freeformTags."+key+"="+selector.OCI.FreeformTags[key])
+		}
+	default:
+		return nil, errors.Errorf("unsupported cloud provider: %s", cloudProvider)
+	}
+	return filter, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
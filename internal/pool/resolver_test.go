@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/doitintl/kubeip/api/v1alpha1"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func matchErr(err, wantErr error) bool {
+	if err == nil && wantErr == nil {
+		return true
+	}
+	if err == nil || wantErr == nil {
+		return false
+	}
+	return err.Error() == wantErr.Error()
+}
+
+func testPool(name string, priority int32, nodeLabels map[string]string, selector v1alpha1.StaticIPPoolSelector) *v1alpha1.StaticIPPool {
+	pool := &v1alpha1.StaticIPPool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.StaticIPPoolSpec{
+			Selector: selector,
+			OrderBy:  name + "-order",
+			Priority: priority,
+		},
+	}
+	if nodeLabels != nil {
+		pool.Spec.NodeSelector = &metav1.LabelSelector{MatchLabels: nodeLabels}
+	}
+	return pool
+}
+
+func Test_resolver_Resolve(t *testing.T) {
+	gcpSelector := func(filter ...string) v1alpha1.StaticIPPoolSelector {
+		return v1alpha1.StaticIPPoolSelector{GCP: &v1alpha1.GCPPoolSelector{Filter: filter}}
+	}
+	tests := []struct {
+		name    string
+		pools   []client.Object
+		node    *types.Node
+		want    *Selection
+		wantErr error
+	}{
+		{
+			name: "no pools, use defaults",
+			node: &types.Node{Cloud: types.CloudProviderGCP},
+			want: &Selection{Filter: []string{"labels.default=true"}, OrderBy: "default-order"},
+		},
+		{
+			name: "no matching pool, use defaults",
+			pools: []client.Object{
+				testPool("public", 0, map[string]string{"pool": "public"}, gcpSelector("labels.pool=public")),
+			},
+			node: &types.Node{Cloud: types.CloudProviderGCP, Labels: map[string]string{"pool": "private"}},
+			want: &Selection{Filter: []string{"labels.default=true"}, OrderBy: "default-order"},
+		},
+		{
+			name: "matching pool",
+			pools: []client.Object{
+				testPool("public", 0, map[string]string{"pool": "public"}, gcpSelector("labels.pool=public")),
+				testPool("private", 0, map[string]string{"pool": "private"}, gcpSelector("labels.pool=private")),
+			},
+			node: &types.Node{Cloud: types.CloudProviderGCP, Labels: map[string]string{"pool": "private"}},
+			want: &Selection{Pool: "private", Filter: []string{"labels.pool=private"}, OrderBy: "private-order"},
+		},
+		{
+			name: "highest priority pool wins",
+			pools: []client.Object{
+				testPool("all", 0, nil, gcpSelector("labels.pool=all")),
+				testPool("public", 10, map[string]string{"pool": "public"}, gcpSelector("labels.pool=public")),
+			},
+			node: &types.Node{Cloud: types.CloudProviderGCP, Labels: map[string]string{"pool": "public"}},
+			want: &Selection{Pool: "public", Filter: []string{"labels.pool=public"}, OrderBy: "public-order"},
+		},
+		{
+			name: "same priority pools ordered by name",
+			pools: []client.Object{
+				testPool("b-pool", 0, nil, gcpSelector("labels.pool=b")),
+				testPool("a-pool", 0, nil, gcpSelector("labels.pool=a")),
+			},
+			node: &types.Node{Cloud: types.CloudProviderGCP},
+			want: &Selection{Pool: "a-pool", Filter: []string{"labels.pool=a"}, OrderBy: "a-pool-order"},
+		},
+		{
+			name: "oci freeform tags",
+			pools: []client.Object{
+				testPool("oci", 0, nil, v1alpha1.StaticIPPoolSelector{OCI: &v1alpha1.OCIPoolSelector{
+					FreeformTags: map[string]string{"env": "dev", "app": "streamer"},
+				}}),
+			},
+			node: &types.Node{Cloud: types.CloudProviderOCI},
+			want: &Selection{Pool: "oci", Filter: []string{"freeformTags.app=streamer", "freeformTags.env=dev"}, OrderBy: "oci-order"},
+		},
+		{
+			name: "azure tags and public IP prefix",
+			pools: []client.Object{
+				testPool("azure", 0, nil, v1alpha1.StaticIPPoolSelector{Azure: &v1alpha1.AzurePoolSelector{
+					Tags:           map[string]string{"env": "dev"},
+					PublicIPPrefix: "prefix-id",
+				}}),
+			},
+			node: &types.Node{Cloud: types.CloudProviderAzure},
+			want: &Selection{Pool: "azure", Filter: []string{"tags.env=dev", "publicIPPrefix=prefix-id"}, OrderBy: "azure-order"},
+		},
+		{
+			name: "pool without selector for node cloud",
+			pools: []client.Object{
+				testPool("gcp", 0, nil, gcpSelector("labels.pool=gcp")),
+			},
+			node:    &types.Node{Cloud: types.CloudProviderAWS},
+			wantErr: errors.New("invalid static IP pool gcp: no selector for cloud provider aws"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to build scheme: %v", err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.pools...).Build()
+			r := NewResolver(c, []string{"labels.default=true"}, "default-order")
+			got, err := r.Resolve(context.Background(), tt.node)
+			if !matchErr(err, tt.wantErr) {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
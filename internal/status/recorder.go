@@ -0,0 +1,145 @@
+package status
+
+import (
+	"context"
+
+	"github.com/doitintl/kubeip/api/v1alpha1"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Recorder records the static public IP address assignment of a node in a StaticIPAssignment resource.
+type Recorder interface {
+	Assigned(ctx context.Context, node *types.Node, address, resourceID string, retryCount int) error
+	Failed(ctx context.Context, node *types.Node, retryCount int, err error) error
+	Released(ctx context.Context, node *types.Node) error
+	List(ctx context.Context) ([]Assignment, error)
+}
+
+// Assignment is a recorded static public IP address assigned to a node.
+type Assignment struct {
+	Node    *types.Node
+	Address string
+}
+
+type recorder struct {
+	client client.Client
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder(c client.Client) Recorder {
+	return &recorder{client: c}
+}
+
+// Assigned records the address assigned to the node.
+func (r *recorder) Assigned(ctx context.Context, node *types.Node, address, resourceID string, retryCount int) error {
+	return r.update(ctx, node, func(status *v1alpha1.StaticIPAssignmentStatus) {
+		now := metav1.Now()
+		status.Phase = v1alpha1.AssignmentPhaseAssigned
+		status.Address = address
+		status.ResourceID = resourceID
+		status.AssignedAt = &now
+		status.RetryCount = int32(retryCount)
+		status.LastError = ""
+	})
+}
+
+// Failed records the failed attempt to assign an address to the node.
+func (r *recorder) Failed(ctx context.Context, node *types.Node, retryCount int, err error) error {
+	return r.update(ctx, node, func(status *v1alpha1.StaticIPAssignmentStatus) {
+		status.Phase = v1alpha1.AssignmentPhaseFailed
+		status.RetryCount = int32(retryCount)
+		status.LastError = err.Error()
+	})
+}
+
+// Released records the release of the address assigned to the node; the released address is kept for reference.
+func (r *recorder) Released(ctx context.Context, node *types.Node) error {
+	return r.update(ctx, node, func(status *v1alpha1.StaticIPAssignmentStatus) {
+		status.Phase = v1alpha1.AssignmentPhaseReleased
+		status.AssignedAt = nil
+	})
+}
+
+// List returns the recorded assignments of the nodes holding a static public IP address, including deleted nodes.
+func (r *recorder) List(ctx context.Context) ([]Assignment, error) {
+	var list v1alpha1.StaticIPAssignmentList
+	if err := r.client.List(ctx, &list); err != nil {
+		return nil, errors.Wrap(err, "failed to list static IP assignments")
+	}
+	assignments := make([]Assignment, 0, len(list.Items))
+	for _, item := range list.Items {
+		if item.Status.Phase != v1alpha1.AssignmentPhaseAssigned || item.Status.Address == "" {
+			continue
+		}
+		assignments = append(assignments, Assignment{
+			Node: &types.Node{
+				Name:     item.Spec.NodeName,
+				Instance: item.Spec.Instance,
+				Cloud:    types.CloudProvider(item.Spec.Cloud),
+				Zone:     item.Spec.Zone,
+			},
+			Address: item.Status.Address,
+		})
+	}
+	return assignments, nil
+}
+
+// update creates or updates the StaticIPAssignment of the node, named after the node
+func (r *recorder) update(ctx context.Context, node *types.Node, mutate func(status *v1alpha1.StaticIPAssignmentStatus)) error {
+	var assignment v1alpha1.StaticIPAssignment
+	err := r.client.Get(ctx, client.ObjectKey{Name: node.Name}, &assignment)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get static IP assignment %s", node.Name)
+	}
+	create := apierrors.IsNotFound(err)
+	if create {
+		assignment.Name = node.Name
+	}
+
+	assignment.Spec = v1alpha1.StaticIPAssignmentSpec{
+		NodeName: node.Name,
+		Instance: node.Instance,
+		Cloud:    string(node.Cloud),
+		Zone:     node.Zone,
+	}
+	mutate(&assignment.Status)
+	assignment.Status.LastUpdateTime = metav1.Now()
+
+	if create {
+		if err = r.client.Create(ctx, &assignment); err != nil {
+			return errors.Wrapf(err, "failed to create static IP assignment %s", node.Name)
+		}
+		return nil
+	}
+	if err = r.client.Update(ctx, &assignment); err != nil {
+		return errors.Wrapf(err, "failed to update static IP assignment %s", node.Name)
+	}
+	return nil
+}
+
+type noopRecorder struct{}
+
+// NewNoopRecorder creates a Recorder that does not record anything.
+func NewNoopRecorder() Recorder {
+	return noopRecorder{}
+}
+
+func (noopRecorder) Assigned(context.Context, *types.Node, string, string, int) error {
+	return nil
+}
+
+func (noopRecorder) Failed(context.Context, *types.Node, int, error) error {
+	return nil
+}
+
+func (noopRecorder) Released(context.Context, *types.Node) error {
+	return nil
+}
+
+func (noopRecorder) List(context.Context) ([]Assignment, error) {
+	return nil, nil
+}
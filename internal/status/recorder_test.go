@@ -0,0 +1,154 @@
+package status
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/doitintl/kubeip/api/v1alpha1"
+	"github.com/doitintl/kubeip/internal/types"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestClient(t *testing.T, objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func Test_recorder(t *testing.T) {
+	node := &types.Node{Name: "test-node", Instance: "test-instance", Cloud: types.CloudProviderAWS, Zone: "test-zone"}
+	tests := []struct {
+		name     string
+		objects  []client.Object
+		recordFn func(r Recorder) error
+		want     v1alpha1.StaticIPAssignmentStatus
+	}{
+		{
+			name: "create assignment on first assign",
+			recordFn: func(r Recorder) error {
+				return r.Assigned(context.Background(), node, "1.1.1.1", "eipalloc-1", 2)
+			},
+			want: v1alpha1.StaticIPAssignmentStatus{
+				Phase:      v1alpha1.AssignmentPhaseAssigned,
+				Address:    "1.1.1.1",
+				ResourceID: "eipalloc-1",
+				RetryCount: 2,
+			},
+		},
+		{
+			name: "record failure",
+			recordFn: func(r Recorder) error {
+				return r.Failed(context.Background(), node, 3, errors.New("no available elastic IPs"))
+			},
+			want: v1alpha1.StaticIPAssignmentStatus{
+				Phase:      v1alpha1.AssignmentPhaseFailed,
+				RetryCount: 3,
+				LastError:  "no available elastic IPs",
+			},
+		},
+		{
+			name: "assign clears last error",
+			objects: []client.Object{&v1alpha1.StaticIPAssignment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
+				Status: v1alpha1.StaticIPAssignmentStatus{
+					Phase:      v1alpha1.AssignmentPhaseFailed,
+					RetryCount: 1,
+					LastError:  "no available elastic IPs",
+				},
+			}},
+			recordFn: func(r Recorder) error {
+				return r.Assigned(context.Background(), node, "1.1.1.1", "eipalloc-1", 1)
+			},
+			want: v1alpha1.StaticIPAssignmentStatus{
+				Phase:      v1alpha1.AssignmentPhaseAssigned,
+				Address:    "1.1.1.1",
+				ResourceID: "eipalloc-1",
+				RetryCount: 1,
+			},
+		},
+		{
+			name: "release keeps address",
+			objects: []client.Object{&v1alpha1.StaticIPAssignment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-node"},
+				Status: v1alpha1.StaticIPAssignmentStatus{
+					Phase:      v1alpha1.AssignmentPhaseAssigned,
+					Address:    "1.1.1.1",
+					ResourceID: "eipalloc-1",
+					AssignedAt: &metav1.Time{},
+				},
+			}},
+			recordFn: func(r Recorder) error {
+				return r.Released(context.Background(), node)
+			},
+			want: v1alpha1.StaticIPAssignmentStatus{
+				Phase:      v1alpha1.AssignmentPhaseReleased,
+				Address:    "1.1.1.1",
+				ResourceID: "eipalloc-1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.objects...)
+			if err := tt.recordFn(NewRecorder(c)); err != nil {
+				t.Fatalf("record error = %v", err)
+			}
+			var got v1alpha1.StaticIPAssignment
+			if err := c.Get(context.Background(), client.ObjectKey{Name: "test-node"}, &got); err != nil {
+				t.Fatalf("failed to get static IP assignment: %v", err)
+			}
+			if got.Spec.NodeName != node.Name || got.Spec.Instance != node.Instance || got.Spec.Cloud != string(node.Cloud) || got.Spec.Zone != node.Zone {
+				t.Errorf("spec = %+v, want node %v", got.Spec, node)
+			}
+			if got.Status.Phase != tt.want.Phase || got.Status.Address != tt.want.Address ||
+				got.Status.ResourceID != tt.want.ResourceID || got.Status.RetryCount != tt.want.RetryCount ||
+				got.Status.LastError != tt.want.LastError {
+				t.Errorf("status = %+v, want %+v", got.Status, tt.want)
+			}
+			if (got.Status.AssignedAt != nil) != (tt.want.Phase == v1alpha1.AssignmentPhaseAssigned) {
+				t.Errorf("status assignedAt = %v, want set only when assigned", got.Status.AssignedAt)
+			}
+			if got.Status.LastUpdateTime.IsZero() {
+				t.Errorf("status lastUpdateTime is not set")
+			}
+		})
+	}
+}
+
+func Test_recorder_List(t *testing.T) {
+	c := newTestClient(t,
+		&v1alpha1.StaticIPAssignment{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-assigned"},
+			Spec:       v1alpha1.StaticIPAssignmentSpec{NodeName: "node-assigned", Instance: "instance-1", Cloud: "gcp", Zone: "zone-a"},
+			Status:     v1alpha1.StaticIPAssignmentStatus{Phase: v1alpha1.AssignmentPhaseAssigned, Address: "1.1.1.1"},
+		},
+		&v1alpha1.StaticIPAssignment{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-released"},
+			Spec:       v1alpha1.StaticIPAssignmentSpec{NodeName: "node-released", Instance: "instance-2", Cloud: "gcp", Zone: "zone-a"},
+			Status:     v1alpha1.StaticIPAssignmentStatus{Phase: v1alpha1.AssignmentPhaseReleased, Address: "2.2.2.2"},
+		},
+		&v1alpha1.StaticIPAssignment{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-failed"},
+			Spec:       v1alpha1.StaticIPAssignmentSpec{NodeName: "node-failed", Instance: "instance-3", Cloud: "gcp", Zone: "zone-a"},
+			Status:     v1alpha1.StaticIPAssignmentStatus{Phase: v1alpha1.AssignmentPhaseFailed},
+		},
+	)
+	got, err := NewRecorder(c).List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []Assignment{{
+		Node:    &types.Node{Name: "node-assigned", Instance: "instance-1", Cloud: types.CloudProviderGCP, Zone: "zone-a"},
+		Address: "1.1.1.1",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+}
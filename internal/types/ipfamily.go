@@ -0,0 +1,11 @@
+package types
+
+// IPFamily is the IP family of the static public IP addresses assigned to a node
+type IPFamily string
+
+const (
+	IPFamilyIPv4 IPFamily = "ipv4"
+	IPFamilyIPv6 IPFamily = "ipv6"
+	// IPFamilyDual assigns both a static public IPv4 and IPv6 address to the node
+	IPFamilyDual IPFamily = "dual"
+)
@@ -0,0 +1,15 @@
+package types
+
+// LockScope is the scope of the lock held while assigning a static public IP address
+type LockScope string
+
+const (
+	// LockScopeCluster serialises the assignments of all nodes with one cluster wide lease
+	LockScopeCluster LockScope = "cluster"
+	// LockScopePool serialises the assignments of the nodes with the same filter with one lease per filter
+	LockScopePool LockScope = "pool"
+	// LockScopeAddress claims each candidate address with its own lease, so nodes assign different addresses in parallel
+	LockScopeAddress LockScope = "address"
+	// LockScopeCloud claims each candidate address on the cloud side with its labels or tags, without a lease
+	LockScopeCloud LockScope = "cloud"
+)
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NetworkInterfaceSelectorKind is the property of the network interface matched by a NetworkInterfaceSelector
+type NetworkInterfaceSelectorKind string
+
+const (
+	// NetworkInterfaceByIndex selects the network interface by its index (GCP position, AWS device index, OCI VNIC attachment order)
+	NetworkInterfaceByIndex NetworkInterfaceSelectorKind = "index"
+	// NetworkInterfaceByName selects the network interface by its name (GCP name, AWS ENI ID, OCI VNIC display name)
+	NetworkInterfaceByName NetworkInterfaceSelectorKind = "name"
+	// NetworkInterfaceBySubnet selects the network interface by its subnet name, ID or self link
+	NetworkInterfaceBySubnet NetworkInterfaceSelectorKind = "subnet"
+	// NetworkInterfaceByTag selects the network interface by its tag, key=value or key (AWS tags, OCI freeform tags)
+	NetworkInterfaceByTag NetworkInterfaceSelectorKind = "tag"
+)
+
+// NetworkInterfaceSelector selects the network interface of the instance receiving the static public IP address.
+// The zero value selects the primary network interface.
+type NetworkInterfaceSelector struct {
+	Kind  NetworkInterfaceSelectorKind
+	Value string
+}
+
+// ParseNetworkInterfaceSelector parses the <kind>:<value> selector, for example index:1, name:nic1, subnet:public or
+// tag:role=public; an empty selector selects the primary network interface.
+func ParseNetworkInterfaceSelector(selector string) (NetworkInterfaceSelector, error) {
+	if selector == "" {
+		return NetworkInterfaceSelector{}, nil
+	}
+	kind, value, ok := strings.Cut(selector, ":")
+	if !ok || value == "" {
+		return NetworkInterfaceSelector{}, errors.Errorf("invalid network interface selector %q; supported format index:<n>, name:<name>, subnet:<subnet> or tag:<key>[=<value>]", selector)
+	}
+	s := NetworkInterfaceSelector{Kind: NetworkInterfaceSelectorKind(kind), Value: value}
+	switch s.Kind {
+	case NetworkInterfaceByIndex:
+		if index, err := strconv.Atoi(value); err != nil || index < 0 {
+			return NetworkInterfaceSelector{}, errors.Errorf("invalid network interface index %q", value)
+		}
+	case NetworkInterfaceByName, NetworkInterfaceBySubnet, NetworkInterfaceByTag:
+	default:
+		return NetworkInterfaceSelector{}, errors.Errorf("unknown network interface selector %q", kind)
+	}
+	return s, nil
+}
+
+// IsPrimary returns true if the selector selects the primary network interface.
+func (s NetworkInterfaceSelector) IsPrimary() bool {
+	return s.Kind == ""
+}
+
+// String returns the selector in the <kind>:<value> format, empty for the primary network interface.
+func (s NetworkInterfaceSelector) String() string {
+	if s.IsPrimary() {
+		return ""
+	}
+	return string(s.Kind) + ":" + s.Value
+}
+
+// Match returns true if the network interface with the index, name, subnet and tags is selected; the subnet matches
+// the selector value or ends with /<value> (GCP subnetwork self link). The primary selector matches the index 0.
+func (s NetworkInterfaceSelector) Match(index int, name, subnet string, tags map[string]string) bool {
+	switch s.Kind {
+	case "":
+		return index == 0
+	case NetworkInterfaceByIndex:
+		// compare the integers: index:01 selects the index 1
+		selected, err := strconv.Atoi(s.Value)
+		return err == nil && selected == index
+	case NetworkInterfaceByName:
+		return name == s.Value
+	case NetworkInterfaceBySubnet:
+		return subnet != "" && (subnet == s.Value || strings.HasSuffix(subnet, "/"+s.Value))
+	case NetworkInterfaceByTag:
+		key, value, hasValue := strings.Cut(s.Value, "=")
+		tagValue, ok := tags[key]
+		return ok && (!hasValue || tagValue == value)
+	default:
+		return false
+	}
+}
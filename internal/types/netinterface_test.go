@@ -0,0 +1,146 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestParseNetworkInterfaceSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		want     NetworkInterfaceSelector
+		wantErr  bool
+	}{
+		{
+			name:     "primary",
+			selector: "",
+			want:     NetworkInterfaceSelector{},
+		},
+		{
+			name:     "index",
+			selector: "index:1",
+			want:     NetworkInterfaceSelector{Kind: NetworkInterfaceByIndex, Value: "1"},
+		},
+		{
+			name:     "non canonical index",
+			selector: "index:01",
+			want:     NetworkInterfaceSelector{Kind: NetworkInterfaceByIndex, Value: "01"},
+		},
+		{
+			name:     "subnet self link",
+			selector: "subnet:projects/p/regions/r/subnetworks/public",
+			want:     NetworkInterfaceSelector{Kind: NetworkInterfaceBySubnet, Value: "projects/p/regions/r/subnetworks/public"},
+		},
+		{
+			name:     "tag with value",
+			selector: "tag:role=public",
+			want:     NetworkInterfaceSelector{Kind: NetworkInterfaceByTag, Value: "role=public"},
+		},
+		{
+			name:     "missing value",
+			selector: "name:",
+			wantErr:  true,
+		},
+		{
+			name:     "missing kind",
+			selector: "nic1",
+			wantErr:  true,
+		},
+		{
+			name:     "negative index",
+			selector: "index:-1",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown kind",
+			selector: "mac:00:00:5e:00:53:01",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNetworkInterfaceSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseNetworkInterfaceSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseNetworkInterfaceSelector() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != tt.selector {
+				t.Errorf("String() = %q, want %q", got.String(), tt.selector)
+			}
+		})
+	}
+}
+
+func TestNetworkInterfaceSelector_Match(t *testing.T) {
+	tags := map[string]string{"role": "public"}
+	tests := []struct {
+		name     string
+		selector NetworkInterfaceSelector
+		index    int
+		nicName  string
+		subnet   string
+		want     bool
+	}{
+		{
+			name:     "primary matches first interface",
+			selector: NetworkInterfaceSelector{},
+			index:    0,
+			want:     true,
+		},
+		{
+			name:     "primary does not match secondary interface",
+			selector: NetworkInterfaceSelector{},
+			index:    1,
+			want:     false,
+		},
+		{
+			name:     "index",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceByIndex, Value: "1"},
+			index:    1,
+			want:     true,
+		},
+		{
+			name:     "non canonical index",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceByIndex, Value: "01"},
+			index:    1,
+			want:     true,
+		},
+		{
+			name:     "name",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceByName, Value: "nic1"},
+			nicName:  "nic1",
+			want:     true,
+		},
+		{
+			name:     "subnet name matches self link",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceBySubnet, Value: "public"},
+			subnet:   "https://www.googleapis.com/compute/v1/projects/p/regions/r/subnetworks/public",
+			want:     true,
+		},
+		{
+			name:     "subnet name does not match suffix",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceBySubnet, Value: "public"},
+			subnet:   "projects/p/regions/r/subnetworks/not-public",
+			want:     false,
+		},
+		{
+			name:     "tag key",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceByTag, Value: "role"},
+			want:     true,
+		},
+		{
+			name:     "tag value mismatch",
+			selector: NetworkInterfaceSelector{Kind: NetworkInterfaceByTag, Value: "role=private"},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.Match(tt.index, tt.nicName, tt.subnet, tags); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ type Node struct {
 	Zone        string
 	ExternalIPs []net.IP
 	InternalIPs []net.IP
+	Labels      map[string]string
 }
 
 // Stringer interface: all fields with name and value
@@ -0,0 +1,95 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AssignmentChecker is an autogenerated mock type for the AssignmentChecker type
+type AssignmentChecker struct {
+	mock.Mock
+}
+
+type AssignmentChecker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AssignmentChecker) EXPECT() *AssignmentChecker_Expecter {
+	return &AssignmentChecker_Expecter{mock: &_m.Mock}
+}
+
+// IsAssigned provides a mock function with given fields: ctx, instanceID, zone, _a3
+func (_m *AssignmentChecker) IsAssigned(ctx context.Context, instanceID string, zone string, _a3 string) (bool, error) {
+	ret := _m.Called(ctx, instanceID, zone, _a3)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsAssigned")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (bool, error)); ok {
+		return rf(ctx, instanceID, zone, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) bool); ok {
+		r0 = rf(ctx, instanceID, zone, _a3)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, instanceID, zone, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AssignmentChecker_IsAssigned_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsAssigned'
+type AssignmentChecker_IsAssigned_Call struct {
+	*mock.Call
+}
+
+// IsAssigned is a helper method to define mock.On call
+//   - ctx context.Context
+//   - instanceID string
+//   - zone string
+//   - _a3 string
+func (_e *AssignmentChecker_Expecter) IsAssigned(ctx interface{}, instanceID interface{}, zone interface{}, _a3 interface{}) *AssignmentChecker_IsAssigned_Call {
+	return &AssignmentChecker_IsAssigned_Call{Call: _e.mock.On("IsAssigned", ctx, instanceID, zone, _a3)}
+}
+
+func (_c *AssignmentChecker_IsAssigned_Call) Run(run func(ctx context.Context, instanceID string, zone string, _a3 string)) *AssignmentChecker_IsAssigned_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *AssignmentChecker_IsAssigned_Call) Return(_a0 bool, _a1 error) *AssignmentChecker_IsAssigned_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AssignmentChecker_IsAssigned_Call) RunAndReturn(run func(context.Context, string, string, string) (bool, error)) *AssignmentChecker_IsAssigned_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAssignmentChecker creates a new instance of AssignmentChecker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAssignmentChecker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AssignmentChecker {
+	mock := &AssignmentChecker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
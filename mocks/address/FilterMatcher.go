@@ -0,0 +1,94 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// FilterMatcher is an autogenerated mock type for the FilterMatcher type
+type FilterMatcher struct {
+	mock.Mock
+}
+
+type FilterMatcher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *FilterMatcher) EXPECT() *FilterMatcher_Expecter {
+	return &FilterMatcher_Expecter{mock: &_m.Mock}
+}
+
+// MatchFilter provides a mock function with given fields: ctx, _a1, filter
+func (_m *FilterMatcher) MatchFilter(ctx context.Context, _a1 string, filter []string) (bool, error) {
+	ret := _m.Called(ctx, _a1, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MatchFilter")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) (bool, error)); ok {
+		return rf(ctx, _a1, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) bool); ok {
+		r0 = rf(ctx, _a1, filter)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, _a1, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FilterMatcher_MatchFilter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchFilter'
+type FilterMatcher_MatchFilter_Call struct {
+	*mock.Call
+}
+
+// MatchFilter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 string
+//   - filter []string
+func (_e *FilterMatcher_Expecter) MatchFilter(ctx interface{}, _a1 interface{}, filter interface{}) *FilterMatcher_MatchFilter_Call {
+	return &FilterMatcher_MatchFilter_Call{Call: _e.mock.On("MatchFilter", ctx, _a1, filter)}
+}
+
+func (_c *FilterMatcher_MatchFilter_Call) Run(run func(ctx context.Context, _a1 string, filter []string)) *FilterMatcher_MatchFilter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]string))
+	})
+	return _c
+}
+
+func (_c *FilterMatcher_MatchFilter_Call) Return(_a0 bool, _a1 error) *FilterMatcher_MatchFilter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FilterMatcher_MatchFilter_Call) RunAndReturn(run func(context.Context, string, []string) (bool, error)) *FilterMatcher_MatchFilter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewFilterMatcher creates a new instance of FilterMatcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewFilterMatcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *FilterMatcher {
+	mock := &FilterMatcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
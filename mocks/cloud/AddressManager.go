@@ -1,4 +1,4 @@
-// Code generated by mockery v2.30.16. DO NOT EDIT.
+// Code generated by mockery v2.53.3. DO NOT EDIT.
 
 package mocks
 
@@ -24,6 +24,10 @@ func (_m *AddressManager) EXPECT() *AddressManager_Expecter {
 func (_m *AddressManager) AddAccessConfig(project string, zone string, instance string, networkInterface string, fingerprint string, accessconfig *compute.AccessConfig) (*compute.Operation, error) {
 	ret := _m.Called(project, zone, instance, networkInterface, fingerprint, accessconfig)
 
+	if len(ret) == 0 {
+		panic("no return value specified for AddAccessConfig")
+	}
+
 	var r0 *compute.Operation
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string, string, string, string, string, *compute.AccessConfig) (*compute.Operation, error)); ok {
@@ -83,6 +87,10 @@ func (_c *AddressManager_AddAccessConfig_Call) RunAndReturn(run func(string, str
 func (_m *AddressManager) DeleteAccessConfig(project string, zone string, instance string, accessConfig string, networkInterface string, fingerprint string) (*compute.Operation, error) {
 	ret := _m.Called(project, zone, instance, accessConfig, networkInterface, fingerprint)
 
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAccessConfig")
+	}
+
 	var r0 *compute.Operation
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string, string, string, string, string, string) (*compute.Operation, error)); ok {
@@ -142,6 +150,10 @@ func (_c *AddressManager_DeleteAccessConfig_Call) RunAndReturn(run func(string,
 func (_m *AddressManager) GetAddress(project string, region string, name string) (*compute.Address, error) {
 	ret := _m.Called(project, region, name)
 
+	if len(ret) == 0 {
+		panic("no return value specified for GetAddress")
+	}
+
 	var r0 *compute.Address
 	var r1 error
 	if rf, ok := ret.Get(0).(func(string, string, string) (*compute.Address, error)); ok {
@@ -194,6 +206,56 @@ func (_c *AddressManager_GetAddress_Call) RunAndReturn(run func(string, string,
 	return _c
 }
 
+// SetAddressLabels provides a mock function with given fields: project, region, name, fingerprint, labels
+func (_m *AddressManager) SetAddressLabels(project string, region string, name string, fingerprint string, labels map[string]string) error {
+	ret := _m.Called(project, region, name, fingerprint, labels)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAddressLabels")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, string, map[string]string) error); ok {
+		r0 = rf(project, region, name, fingerprint, labels)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddressManager_SetAddressLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetAddressLabels'
+type AddressManager_SetAddressLabels_Call struct {
+	*mock.Call
+}
+
+// SetAddressLabels is a helper method to define mock.On call
+//   - project string
+//   - region string
+//   - name string
+//   - fingerprint string
+//   - labels map[string]string
+func (_e *AddressManager_Expecter) SetAddressLabels(project interface{}, region interface{}, name interface{}, fingerprint interface{}, labels interface{}) *AddressManager_SetAddressLabels_Call {
+	return &AddressManager_SetAddressLabels_Call{Call: _e.mock.On("SetAddressLabels", project, region, name, fingerprint, labels)}
+}
+
+func (_c *AddressManager_SetAddressLabels_Call) Run(run func(project string, region string, name string, fingerprint string, labels map[string]string)) *AddressManager_SetAddressLabels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(string), args[4].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *AddressManager_SetAddressLabels_Call) Return(_a0 error) *AddressManager_SetAddressLabels_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AddressManager_SetAddressLabels_Call) RunAndReturn(run func(string, string, string, string, map[string]string) error) *AddressManager_SetAddressLabels_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewAddressManager creates a new instance of AddressManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAddressManager(t interface {
@@ -0,0 +1,97 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	armnetwork "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AzureInterfaceGetter is an autogenerated mock type for the AzureInterfaceGetter type
+type AzureInterfaceGetter struct {
+	mock.Mock
+}
+
+type AzureInterfaceGetter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AzureInterfaceGetter) EXPECT() *AzureInterfaceGetter_Expecter {
+	return &AzureInterfaceGetter_Expecter{mock: &_m.Mock}
+}
+
+// Get provides a mock function with given fields: ctx, vmID
+func (_m *AzureInterfaceGetter) Get(ctx context.Context, vmID string) (*armnetwork.Interface, error) {
+	ret := _m.Called(ctx, vmID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *armnetwork.Interface
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*armnetwork.Interface, error)); ok {
+		return rf(ctx, vmID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *armnetwork.Interface); ok {
+		r0 = rf(ctx, vmID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*armnetwork.Interface)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, vmID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AzureInterfaceGetter_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type AzureInterfaceGetter_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vmID string
+func (_e *AzureInterfaceGetter_Expecter) Get(ctx interface{}, vmID interface{}) *AzureInterfaceGetter_Get_Call {
+	return &AzureInterfaceGetter_Get_Call{Call: _e.mock.On("Get", ctx, vmID)}
+}
+
+func (_c *AzureInterfaceGetter_Get_Call) Run(run func(ctx context.Context, vmID string)) *AzureInterfaceGetter_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AzureInterfaceGetter_Get_Call) Return(_a0 *armnetwork.Interface, _a1 error) *AzureInterfaceGetter_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AzureInterfaceGetter_Get_Call) RunAndReturn(run func(context.Context, string) (*armnetwork.Interface, error)) *AzureInterfaceGetter_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAzureInterfaceGetter creates a new instance of AzureInterfaceGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAzureInterfaceGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AzureInterfaceGetter {
+	mock := &AzureInterfaceGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,85 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	armnetwork "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AzureInterfaceUpdater is an autogenerated mock type for the AzureInterfaceUpdater type
+type AzureInterfaceUpdater struct {
+	mock.Mock
+}
+
+type AzureInterfaceUpdater_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AzureInterfaceUpdater) EXPECT() *AzureInterfaceUpdater_Expecter {
+	return &AzureInterfaceUpdater_Expecter{mock: &_m.Mock}
+}
+
+// Update provides a mock function with given fields: ctx, nic
+func (_m *AzureInterfaceUpdater) Update(ctx context.Context, nic *armnetwork.Interface) error {
+	ret := _m.Called(ctx, nic)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *armnetwork.Interface) error); ok {
+		r0 = rf(ctx, nic)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AzureInterfaceUpdater_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type AzureInterfaceUpdater_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - ctx context.Context
+//   - nic *armnetwork.Interface
+func (_e *AzureInterfaceUpdater_Expecter) Update(ctx interface{}, nic interface{}) *AzureInterfaceUpdater_Update_Call {
+	return &AzureInterfaceUpdater_Update_Call{Call: _e.mock.On("Update", ctx, nic)}
+}
+
+func (_c *AzureInterfaceUpdater_Update_Call) Run(run func(ctx context.Context, nic *armnetwork.Interface)) *AzureInterfaceUpdater_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*armnetwork.Interface))
+	})
+	return _c
+}
+
+func (_c *AzureInterfaceUpdater_Update_Call) Return(_a0 error) *AzureInterfaceUpdater_Update_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AzureInterfaceUpdater_Update_Call) RunAndReturn(run func(context.Context, *armnetwork.Interface) error) *AzureInterfaceUpdater_Update_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAzureInterfaceUpdater creates a new instance of AzureInterfaceUpdater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAzureInterfaceUpdater(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AzureInterfaceUpdater {
+	mock := &AzureInterfaceUpdater{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,155 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	armnetwork "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AzurePublicIPLister is an autogenerated mock type for the AzurePublicIPLister type
+type AzurePublicIPLister struct {
+	mock.Mock
+}
+
+type AzurePublicIPLister_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AzurePublicIPLister) EXPECT() *AzurePublicIPLister_Expecter {
+	return &AzurePublicIPLister_Expecter{mock: &_m.Mock}
+}
+
+// Get provides a mock function with given fields: ctx, publicIPID
+func (_m *AzurePublicIPLister) Get(ctx context.Context, publicIPID string) (*armnetwork.PublicIPAddress, error) {
+	ret := _m.Called(ctx, publicIPID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *armnetwork.PublicIPAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*armnetwork.PublicIPAddress, error)); ok {
+		return rf(ctx, publicIPID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *armnetwork.PublicIPAddress); ok {
+		r0 = rf(ctx, publicIPID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*armnetwork.PublicIPAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, publicIPID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AzurePublicIPLister_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type AzurePublicIPLister_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publicIPID string
+func (_e *AzurePublicIPLister_Expecter) Get(ctx interface{}, publicIPID interface{}) *AzurePublicIPLister_Get_Call {
+	return &AzurePublicIPLister_Get_Call{Call: _e.mock.On("Get", ctx, publicIPID)}
+}
+
+func (_c *AzurePublicIPLister_Get_Call) Run(run func(ctx context.Context, publicIPID string)) *AzurePublicIPLister_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AzurePublicIPLister_Get_Call) Return(_a0 *armnetwork.PublicIPAddress, _a1 error) *AzurePublicIPLister_Get_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AzurePublicIPLister_Get_Call) RunAndReturn(run func(context.Context, string) (*armnetwork.PublicIPAddress, error)) *AzurePublicIPLister_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *AzurePublicIPLister) List(ctx context.Context) ([]*armnetwork.PublicIPAddress, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []*armnetwork.PublicIPAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*armnetwork.PublicIPAddress, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*armnetwork.PublicIPAddress); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*armnetwork.PublicIPAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AzurePublicIPLister_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type AzurePublicIPLister_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *AzurePublicIPLister_Expecter) List(ctx interface{}) *AzurePublicIPLister_List_Call {
+	return &AzurePublicIPLister_List_Call{Call: _e.mock.On("List", ctx)}
+}
+
+func (_c *AzurePublicIPLister_List_Call) Run(run func(ctx context.Context)) *AzurePublicIPLister_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *AzurePublicIPLister_List_Call) Return(_a0 []*armnetwork.PublicIPAddress, _a1 error) *AzurePublicIPLister_List_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AzurePublicIPLister_List_Call) RunAndReturn(run func(context.Context) ([]*armnetwork.PublicIPAddress, error)) *AzurePublicIPLister_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAzurePublicIPLister creates a new instance of AzurePublicIPLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAzurePublicIPLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AzurePublicIPLister {
+	mock := &AzurePublicIPLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
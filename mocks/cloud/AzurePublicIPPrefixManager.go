@@ -0,0 +1,205 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	armnetwork "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AzurePublicIPPrefixManager is an autogenerated mock type for the AzurePublicIPPrefixManager type
+type AzurePublicIPPrefixManager struct {
+	mock.Mock
+}
+
+type AzurePublicIPPrefixManager_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AzurePublicIPPrefixManager) EXPECT() *AzurePublicIPPrefixManager_Expecter {
+	return &AzurePublicIPPrefixManager_Expecter{mock: &_m.Mock}
+}
+
+// CreatePublicIP provides a mock function with given fields: ctx, resourceGroup, name, address
+func (_m *AzurePublicIPPrefixManager) CreatePublicIP(ctx context.Context, resourceGroup string, name string, address *armnetwork.PublicIPAddress) (*armnetwork.PublicIPAddress, error) {
+	ret := _m.Called(ctx, resourceGroup, name, address)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePublicIP")
+	}
+
+	var r0 *armnetwork.PublicIPAddress
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *armnetwork.PublicIPAddress) (*armnetwork.PublicIPAddress, error)); ok {
+		return rf(ctx, resourceGroup, name, address)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *armnetwork.PublicIPAddress) *armnetwork.PublicIPAddress); ok {
+		r0 = rf(ctx, resourceGroup, name, address)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*armnetwork.PublicIPAddress)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *armnetwork.PublicIPAddress) error); ok {
+		r1 = rf(ctx, resourceGroup, name, address)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AzurePublicIPPrefixManager_CreatePublicIP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreatePublicIP'
+type AzurePublicIPPrefixManager_CreatePublicIP_Call struct {
+	*mock.Call
+}
+
+// CreatePublicIP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resourceGroup string
+//   - name string
+//   - address *armnetwork.PublicIPAddress
+func (_e *AzurePublicIPPrefixManager_Expecter) CreatePublicIP(ctx interface{}, resourceGroup interface{}, name interface{}, address interface{}) *AzurePublicIPPrefixManager_CreatePublicIP_Call {
+	return &AzurePublicIPPrefixManager_CreatePublicIP_Call{Call: _e.mock.On("CreatePublicIP", ctx, resourceGroup, name, address)}
+}
+
+func (_c *AzurePublicIPPrefixManager_CreatePublicIP_Call) Run(run func(ctx context.Context, resourceGroup string, name string, address *armnetwork.PublicIPAddress)) *AzurePublicIPPrefixManager_CreatePublicIP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(*armnetwork.PublicIPAddress))
+	})
+	return _c
+}
+
+func (_c *AzurePublicIPPrefixManager_CreatePublicIP_Call) Return(_a0 *armnetwork.PublicIPAddress, _a1 error) *AzurePublicIPPrefixManager_CreatePublicIP_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AzurePublicIPPrefixManager_CreatePublicIP_Call) RunAndReturn(run func(context.Context, string, string, *armnetwork.PublicIPAddress) (*armnetwork.PublicIPAddress, error)) *AzurePublicIPPrefixManager_CreatePublicIP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeletePublicIP provides a mock function with given fields: ctx, publicIPID
+func (_m *AzurePublicIPPrefixManager) DeletePublicIP(ctx context.Context, publicIPID string) error {
+	ret := _m.Called(ctx, publicIPID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePublicIP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, publicIPID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AzurePublicIPPrefixManager_DeletePublicIP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeletePublicIP'
+type AzurePublicIPPrefixManager_DeletePublicIP_Call struct {
+	*mock.Call
+}
+
+// DeletePublicIP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publicIPID string
+func (_e *AzurePublicIPPrefixManager_Expecter) DeletePublicIP(ctx interface{}, publicIPID interface{}) *AzurePublicIPPrefixManager_DeletePublicIP_Call {
+	return &AzurePublicIPPrefixManager_DeletePublicIP_Call{Call: _e.mock.On("DeletePublicIP", ctx, publicIPID)}
+}
+
+func (_c *AzurePublicIPPrefixManager_DeletePublicIP_Call) Run(run func(ctx context.Context, publicIPID string)) *AzurePublicIPPrefixManager_DeletePublicIP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AzurePublicIPPrefixManager_DeletePublicIP_Call) Return(_a0 error) *AzurePublicIPPrefixManager_DeletePublicIP_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AzurePublicIPPrefixManager_DeletePublicIP_Call) RunAndReturn(run func(context.Context, string) error) *AzurePublicIPPrefixManager_DeletePublicIP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPrefix provides a mock function with given fields: ctx, prefixID
+func (_m *AzurePublicIPPrefixManager) GetPrefix(ctx context.Context, prefixID string) (*armnetwork.PublicIPPrefix, error) {
+	ret := _m.Called(ctx, prefixID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPrefix")
+	}
+
+	var r0 *armnetwork.PublicIPPrefix
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*armnetwork.PublicIPPrefix, error)); ok {
+		return rf(ctx, prefixID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *armnetwork.PublicIPPrefix); ok {
+		r0 = rf(ctx, prefixID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*armnetwork.PublicIPPrefix)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, prefixID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AzurePublicIPPrefixManager_GetPrefix_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPrefix'
+type AzurePublicIPPrefixManager_GetPrefix_Call struct {
+	*mock.Call
+}
+
+// GetPrefix is a helper method to define mock.On call
+//   - ctx context.Context
+//   - prefixID string
+func (_e *AzurePublicIPPrefixManager_Expecter) GetPrefix(ctx interface{}, prefixID interface{}) *AzurePublicIPPrefixManager_GetPrefix_Call {
+	return &AzurePublicIPPrefixManager_GetPrefix_Call{Call: _e.mock.On("GetPrefix", ctx, prefixID)}
+}
+
+func (_c *AzurePublicIPPrefixManager_GetPrefix_Call) Run(run func(ctx context.Context, prefixID string)) *AzurePublicIPPrefixManager_GetPrefix_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *AzurePublicIPPrefixManager_GetPrefix_Call) Return(_a0 *armnetwork.PublicIPPrefix, _a1 error) *AzurePublicIPPrefixManager_GetPrefix_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AzurePublicIPPrefixManager_GetPrefix_Call) RunAndReturn(run func(context.Context, string) (*armnetwork.PublicIPPrefix, error)) *AzurePublicIPPrefixManager_GetPrefix_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAzurePublicIPPrefixManager creates a new instance of AzurePublicIPPrefixManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAzurePublicIPPrefixManager(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AzurePublicIPPrefixManager {
+	mock := &AzurePublicIPPrefixManager{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
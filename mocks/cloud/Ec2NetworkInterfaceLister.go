@@ -0,0 +1,96 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Ec2NetworkInterfaceLister is an autogenerated mock type for the Ec2NetworkInterfaceLister type
+type Ec2NetworkInterfaceLister struct {
+	mock.Mock
+}
+
+type Ec2NetworkInterfaceLister_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Ec2NetworkInterfaceLister) EXPECT() *Ec2NetworkInterfaceLister_Expecter {
+	return &Ec2NetworkInterfaceLister_Expecter{mock: &_m.Mock}
+}
+
+// List provides a mock function with given fields: ctx, instanceID
+func (_m *Ec2NetworkInterfaceLister) List(ctx context.Context, instanceID string) ([]types.NetworkInterface, error) {
+	ret := _m.Called(ctx, instanceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []types.NetworkInterface
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]types.NetworkInterface, error)); ok {
+		return rf(ctx, instanceID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []types.NetworkInterface); ok {
+		r0 = rf(ctx, instanceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.NetworkInterface)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, instanceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ec2NetworkInterfaceLister_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type Ec2NetworkInterfaceLister_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+//   - instanceID string
+func (_e *Ec2NetworkInterfaceLister_Expecter) List(ctx interface{}, instanceID interface{}) *Ec2NetworkInterfaceLister_List_Call {
+	return &Ec2NetworkInterfaceLister_List_Call{Call: _e.mock.On("List", ctx, instanceID)}
+}
+
+func (_c *Ec2NetworkInterfaceLister_List_Call) Run(run func(ctx context.Context, instanceID string)) *Ec2NetworkInterfaceLister_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Ec2NetworkInterfaceLister_List_Call) Return(_a0 []types.NetworkInterface, _a1 error) *Ec2NetworkInterfaceLister_List_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Ec2NetworkInterfaceLister_List_Call) RunAndReturn(run func(context.Context, string) ([]types.NetworkInterface, error)) *Ec2NetworkInterfaceLister_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewEc2NetworkInterfaceLister creates a new instance of Ec2NetworkInterfaceLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEc2NetworkInterfaceLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Ec2NetworkInterfaceLister {
+	mock := &Ec2NetworkInterfaceLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
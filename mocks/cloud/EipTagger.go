@@ -0,0 +1,132 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// EipTagger is an autogenerated mock type for the EipTagger type
+type EipTagger struct {
+	mock.Mock
+}
+
+type EipTagger_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *EipTagger) EXPECT() *EipTagger_Expecter {
+	return &EipTagger_Expecter{mock: &_m.Mock}
+}
+
+// Tag provides a mock function with given fields: ctx, allocationID, tags
+func (_m *EipTagger) Tag(ctx context.Context, allocationID string, tags map[string]string) error {
+	ret := _m.Called(ctx, allocationID, tags)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) error); ok {
+		r0 = rf(ctx, allocationID, tags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EipTagger_Tag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Tag'
+type EipTagger_Tag_Call struct {
+	*mock.Call
+}
+
+// Tag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - allocationID string
+//   - tags map[string]string
+func (_e *EipTagger_Expecter) Tag(ctx interface{}, allocationID interface{}, tags interface{}) *EipTagger_Tag_Call {
+	return &EipTagger_Tag_Call{Call: _e.mock.On("Tag", ctx, allocationID, tags)}
+}
+
+func (_c *EipTagger_Tag_Call) Run(run func(ctx context.Context, allocationID string, tags map[string]string)) *EipTagger_Tag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *EipTagger_Tag_Call) Return(_a0 error) *EipTagger_Tag_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *EipTagger_Tag_Call) RunAndReturn(run func(context.Context, string, map[string]string) error) *EipTagger_Tag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Untag provides a mock function with given fields: ctx, allocationID, tags
+func (_m *EipTagger) Untag(ctx context.Context, allocationID string, tags map[string]string) error {
+	ret := _m.Called(ctx, allocationID, tags)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Untag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) error); ok {
+		r0 = rf(ctx, allocationID, tags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EipTagger_Untag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Untag'
+type EipTagger_Untag_Call struct {
+	*mock.Call
+}
+
+// Untag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - allocationID string
+//   - tags map[string]string
+func (_e *EipTagger_Expecter) Untag(ctx interface{}, allocationID interface{}, tags interface{}) *EipTagger_Untag_Call {
+	return &EipTagger_Untag_Call{Call: _e.mock.On("Untag", ctx, allocationID, tags)}
+}
+
+func (_c *EipTagger_Untag_Call) Run(run func(ctx context.Context, allocationID string, tags map[string]string)) *EipTagger_Untag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *EipTagger_Untag_Call) Return(_a0 error) *EipTagger_Untag_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *EipTagger_Untag_Call) RunAndReturn(run func(context.Context, string, map[string]string) error) *EipTagger_Untag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewEipTagger creates a new instance of EipTagger. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEipTagger(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *EipTagger {
+	mock := &EipTagger{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
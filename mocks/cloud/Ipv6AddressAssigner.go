@@ -0,0 +1,132 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Ipv6AddressAssigner is an autogenerated mock type for the Ipv6AddressAssigner type
+type Ipv6AddressAssigner struct {
+	mock.Mock
+}
+
+type Ipv6AddressAssigner_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Ipv6AddressAssigner) EXPECT() *Ipv6AddressAssigner_Expecter {
+	return &Ipv6AddressAssigner_Expecter{mock: &_m.Mock}
+}
+
+// Assign provides a mock function with given fields: ctx, networkInterfaceID, address
+func (_m *Ipv6AddressAssigner) Assign(ctx context.Context, networkInterfaceID string, address string) error {
+	ret := _m.Called(ctx, networkInterfaceID, address)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Assign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, networkInterfaceID, address)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ipv6AddressAssigner_Assign_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Assign'
+type Ipv6AddressAssigner_Assign_Call struct {
+	*mock.Call
+}
+
+// Assign is a helper method to define mock.On call
+//   - ctx context.Context
+//   - networkInterfaceID string
+//   - address string
+func (_e *Ipv6AddressAssigner_Expecter) Assign(ctx interface{}, networkInterfaceID interface{}, address interface{}) *Ipv6AddressAssigner_Assign_Call {
+	return &Ipv6AddressAssigner_Assign_Call{Call: _e.mock.On("Assign", ctx, networkInterfaceID, address)}
+}
+
+func (_c *Ipv6AddressAssigner_Assign_Call) Run(run func(ctx context.Context, networkInterfaceID string, address string)) *Ipv6AddressAssigner_Assign_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Ipv6AddressAssigner_Assign_Call) Return(_a0 error) *Ipv6AddressAssigner_Assign_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Ipv6AddressAssigner_Assign_Call) RunAndReturn(run func(context.Context, string, string) error) *Ipv6AddressAssigner_Assign_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unassign provides a mock function with given fields: ctx, networkInterfaceID, address
+func (_m *Ipv6AddressAssigner) Unassign(ctx context.Context, networkInterfaceID string, address string) error {
+	ret := _m.Called(ctx, networkInterfaceID, address)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unassign")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, networkInterfaceID, address)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ipv6AddressAssigner_Unassign_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unassign'
+type Ipv6AddressAssigner_Unassign_Call struct {
+	*mock.Call
+}
+
+// Unassign is a helper method to define mock.On call
+//   - ctx context.Context
+//   - networkInterfaceID string
+//   - address string
+func (_e *Ipv6AddressAssigner_Expecter) Unassign(ctx interface{}, networkInterfaceID interface{}, address interface{}) *Ipv6AddressAssigner_Unassign_Call {
+	return &Ipv6AddressAssigner_Unassign_Call{Call: _e.mock.On("Unassign", ctx, networkInterfaceID, address)}
+}
+
+func (_c *Ipv6AddressAssigner_Unassign_Call) Run(run func(ctx context.Context, networkInterfaceID string, address string)) *Ipv6AddressAssigner_Unassign_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *Ipv6AddressAssigner_Unassign_Call) Return(_a0 error) *Ipv6AddressAssigner_Unassign_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Ipv6AddressAssigner_Unassign_Call) RunAndReturn(run func(context.Context, string, string) error) *Ipv6AddressAssigner_Unassign_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewIpv6AddressAssigner creates a new instance of Ipv6AddressAssigner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIpv6AddressAssigner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Ipv6AddressAssigner {
+	mock := &Ipv6AddressAssigner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
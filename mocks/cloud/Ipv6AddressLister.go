@@ -0,0 +1,156 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Ipv6AddressLister is an autogenerated mock type for the Ipv6AddressLister type
+type Ipv6AddressLister struct {
+	mock.Mock
+}
+
+type Ipv6AddressLister_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Ipv6AddressLister) EXPECT() *Ipv6AddressLister_Expecter {
+	return &Ipv6AddressLister_Expecter{mock: &_m.Mock}
+}
+
+// ListNetworkInterfaces provides a mock function with given fields: ctx, filter
+func (_m *Ipv6AddressLister) ListNetworkInterfaces(ctx context.Context, filter map[string][]string) ([]types.NetworkInterface, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListNetworkInterfaces")
+	}
+
+	var r0 []types.NetworkInterface
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[string][]string) ([]types.NetworkInterface, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, map[string][]string) []types.NetworkInterface); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.NetworkInterface)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, map[string][]string) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ipv6AddressLister_ListNetworkInterfaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListNetworkInterfaces'
+type Ipv6AddressLister_ListNetworkInterfaces_Call struct {
+	*mock.Call
+}
+
+// ListNetworkInterfaces is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter map[string][]string
+func (_e *Ipv6AddressLister_Expecter) ListNetworkInterfaces(ctx interface{}, filter interface{}) *Ipv6AddressLister_ListNetworkInterfaces_Call {
+	return &Ipv6AddressLister_ListNetworkInterfaces_Call{Call: _e.mock.On("ListNetworkInterfaces", ctx, filter)}
+}
+
+func (_c *Ipv6AddressLister_ListNetworkInterfaces_Call) Run(run func(ctx context.Context, filter map[string][]string)) *Ipv6AddressLister_ListNetworkInterfaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(map[string][]string))
+	})
+	return _c
+}
+
+func (_c *Ipv6AddressLister_ListNetworkInterfaces_Call) Return(_a0 []types.NetworkInterface, _a1 error) *Ipv6AddressLister_ListNetworkInterfaces_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Ipv6AddressLister_ListNetworkInterfaces_Call) RunAndReturn(run func(context.Context, map[string][]string) ([]types.NetworkInterface, error)) *Ipv6AddressLister_ListNetworkInterfaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListReservations provides a mock function with given fields: ctx, subnetID, filter
+func (_m *Ipv6AddressLister) ListReservations(ctx context.Context, subnetID string, filter map[string][]string) ([]types.SubnetCidrReservation, error) {
+	ret := _m.Called(ctx, subnetID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListReservations")
+	}
+
+	var r0 []types.SubnetCidrReservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string][]string) ([]types.SubnetCidrReservation, error)); ok {
+		return rf(ctx, subnetID, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string][]string) []types.SubnetCidrReservation); ok {
+		r0 = rf(ctx, subnetID, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.SubnetCidrReservation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, map[string][]string) error); ok {
+		r1 = rf(ctx, subnetID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ipv6AddressLister_ListReservations_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListReservations'
+type Ipv6AddressLister_ListReservations_Call struct {
+	*mock.Call
+}
+
+// ListReservations is a helper method to define mock.On call
+//   - ctx context.Context
+//   - subnetID string
+//   - filter map[string][]string
+func (_e *Ipv6AddressLister_Expecter) ListReservations(ctx interface{}, subnetID interface{}, filter interface{}) *Ipv6AddressLister_ListReservations_Call {
+	return &Ipv6AddressLister_ListReservations_Call{Call: _e.mock.On("ListReservations", ctx, subnetID, filter)}
+}
+
+func (_c *Ipv6AddressLister_ListReservations_Call) Run(run func(ctx context.Context, subnetID string, filter map[string][]string)) *Ipv6AddressLister_ListReservations_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(map[string][]string))
+	})
+	return _c
+}
+
+func (_c *Ipv6AddressLister_ListReservations_Call) Return(_a0 []types.SubnetCidrReservation, _a1 error) *Ipv6AddressLister_ListReservations_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Ipv6AddressLister_ListReservations_Call) RunAndReturn(run func(context.Context, string, map[string][]string) ([]types.SubnetCidrReservation, error)) *Ipv6AddressLister_ListReservations_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewIpv6AddressLister creates a new instance of Ipv6AddressLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIpv6AddressLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Ipv6AddressLister {
+	mock := &Ipv6AddressLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,14 +1,13 @@
-// Code generated by mockery v2.30.16. DO NOT EDIT.
+// Code generated by mockery v2.53.3. DO NOT EDIT.
 
 package mocks
 
 import (
 	context "context"
 
+	types "github.com/doitintl/kubeip/internal/types"
 	core "github.com/oracle/oci-go-sdk/v65/core"
 	mock "github.com/stretchr/testify/mock"
-
-	types "github.com/doitintl/kubeip/internal/types"
 )
 
 // OCINetworkService is an autogenerated mock type for the OCINetworkService type
@@ -248,6 +247,249 @@ func (_c *OCINetworkService_GetPublicIP_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// GetPublicIPOfPrivateIP provides a mock function with given fields: ctx, privateIPOCID
+func (_m *OCINetworkService) GetPublicIPOfPrivateIP(ctx context.Context, privateIPOCID string) (*core.PublicIp, error) {
+	ret := _m.Called(ctx, privateIPOCID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPublicIPOfPrivateIP")
+	}
+
+	var r0 *core.PublicIp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*core.PublicIp, error)); ok {
+		return rf(ctx, privateIPOCID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *core.PublicIp); ok {
+		r0 = rf(ctx, privateIPOCID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.PublicIp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, privateIPOCID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OCINetworkService_GetPublicIPOfPrivateIP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPublicIPOfPrivateIP'
+type OCINetworkService_GetPublicIPOfPrivateIP_Call struct {
+	*mock.Call
+}
+
+// GetPublicIPOfPrivateIP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - privateIPOCID string
+func (_e *OCINetworkService_Expecter) GetPublicIPOfPrivateIP(ctx interface{}, privateIPOCID interface{}) *OCINetworkService_GetPublicIPOfPrivateIP_Call {
+	return &OCINetworkService_GetPublicIPOfPrivateIP_Call{Call: _e.mock.On("GetPublicIPOfPrivateIP", ctx, privateIPOCID)}
+}
+
+func (_c *OCINetworkService_GetPublicIPOfPrivateIP_Call) Run(run func(ctx context.Context, privateIPOCID string)) *OCINetworkService_GetPublicIPOfPrivateIP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OCINetworkService_GetPublicIPOfPrivateIP_Call) Return(_a0 *core.PublicIp, _a1 error) *OCINetworkService_GetPublicIPOfPrivateIP_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OCINetworkService_GetPublicIPOfPrivateIP_Call) RunAndReturn(run func(context.Context, string) (*core.PublicIp, error)) *OCINetworkService_GetPublicIPOfPrivateIP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPublicIPWithETag provides a mock function with given fields: ctx, publicIPOCID
+func (_m *OCINetworkService) GetPublicIPWithETag(ctx context.Context, publicIPOCID string) (*core.PublicIp, string, error) {
+	ret := _m.Called(ctx, publicIPOCID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPublicIPWithETag")
+	}
+
+	var r0 *core.PublicIp
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*core.PublicIp, string, error)); ok {
+		return rf(ctx, publicIPOCID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *core.PublicIp); ok {
+		r0 = rf(ctx, publicIPOCID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.PublicIp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) string); ok {
+		r1 = rf(ctx, publicIPOCID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, publicIPOCID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// OCINetworkService_GetPublicIPWithETag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPublicIPWithETag'
+type OCINetworkService_GetPublicIPWithETag_Call struct {
+	*mock.Call
+}
+
+// GetPublicIPWithETag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publicIPOCID string
+func (_e *OCINetworkService_Expecter) GetPublicIPWithETag(ctx interface{}, publicIPOCID interface{}) *OCINetworkService_GetPublicIPWithETag_Call {
+	return &OCINetworkService_GetPublicIPWithETag_Call{Call: _e.mock.On("GetPublicIPWithETag", ctx, publicIPOCID)}
+}
+
+func (_c *OCINetworkService_GetPublicIPWithETag_Call) Run(run func(ctx context.Context, publicIPOCID string)) *OCINetworkService_GetPublicIPWithETag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OCINetworkService_GetPublicIPWithETag_Call) Return(_a0 *core.PublicIp, _a1 string, _a2 error) *OCINetworkService_GetPublicIPWithETag_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *OCINetworkService_GetPublicIPWithETag_Call) RunAndReturn(run func(context.Context, string) (*core.PublicIp, string, error)) *OCINetworkService_GetPublicIPWithETag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVnic provides a mock function with given fields: ctx, vnicOCID
+func (_m *OCINetworkService) GetVnic(ctx context.Context, vnicOCID string) (*core.Vnic, error) {
+	ret := _m.Called(ctx, vnicOCID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVnic")
+	}
+
+	var r0 *core.Vnic
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*core.Vnic, error)); ok {
+		return rf(ctx, vnicOCID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *core.Vnic); ok {
+		r0 = rf(ctx, vnicOCID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*core.Vnic)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, vnicOCID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OCINetworkService_GetVnic_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVnic'
+type OCINetworkService_GetVnic_Call struct {
+	*mock.Call
+}
+
+// GetVnic is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vnicOCID string
+func (_e *OCINetworkService_Expecter) GetVnic(ctx interface{}, vnicOCID interface{}) *OCINetworkService_GetVnic_Call {
+	return &OCINetworkService_GetVnic_Call{Call: _e.mock.On("GetVnic", ctx, vnicOCID)}
+}
+
+func (_c *OCINetworkService_GetVnic_Call) Run(run func(ctx context.Context, vnicOCID string)) *OCINetworkService_GetVnic_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OCINetworkService_GetVnic_Call) Return(_a0 *core.Vnic, _a1 error) *OCINetworkService_GetVnic_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OCINetworkService_GetVnic_Call) RunAndReturn(run func(context.Context, string) (*core.Vnic, error)) *OCINetworkService_GetVnic_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListPrivateIPsOfVnic provides a mock function with given fields: ctx, vnicOCID
+func (_m *OCINetworkService) ListPrivateIPsOfVnic(ctx context.Context, vnicOCID string) ([]core.PrivateIp, error) {
+	ret := _m.Called(ctx, vnicOCID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPrivateIPsOfVnic")
+	}
+
+	var r0 []core.PrivateIp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]core.PrivateIp, error)); ok {
+		return rf(ctx, vnicOCID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []core.PrivateIp); ok {
+		r0 = rf(ctx, vnicOCID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]core.PrivateIp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, vnicOCID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OCINetworkService_ListPrivateIPsOfVnic_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListPrivateIPsOfVnic'
+type OCINetworkService_ListPrivateIPsOfVnic_Call struct {
+	*mock.Call
+}
+
+// ListPrivateIPsOfVnic is a helper method to define mock.On call
+//   - ctx context.Context
+//   - vnicOCID string
+func (_e *OCINetworkService_Expecter) ListPrivateIPsOfVnic(ctx interface{}, vnicOCID interface{}) *OCINetworkService_ListPrivateIPsOfVnic_Call {
+	return &OCINetworkService_ListPrivateIPsOfVnic_Call{Call: _e.mock.On("ListPrivateIPsOfVnic", ctx, vnicOCID)}
+}
+
+func (_c *OCINetworkService_ListPrivateIPsOfVnic_Call) Run(run func(ctx context.Context, vnicOCID string)) *OCINetworkService_ListPrivateIPsOfVnic_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OCINetworkService_ListPrivateIPsOfVnic_Call) Return(_a0 []core.PrivateIp, _a1 error) *OCINetworkService_ListPrivateIPsOfVnic_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OCINetworkService_ListPrivateIPsOfVnic_Call) RunAndReturn(run func(context.Context, string) ([]core.PrivateIp, error)) *OCINetworkService_ListPrivateIPsOfVnic_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListPublicIps provides a mock function with given fields: ctx, request, filters
 func (_m *OCINetworkService) ListPublicIps(ctx context.Context, request *core.ListPublicIpsRequest, filters *types.OCIFilters) ([]core.PublicIp, error) {
 	ret := _m.Called(ctx, request, filters)
@@ -356,6 +598,114 @@ func (_c *OCINetworkService_UpdatePublicIP_Call) RunAndReturn(run func(context.C
 	return _c
 }
 
+// UpdatePublicIPIfMatch provides a mock function with given fields: ctx, publicIPOCID, privateIPOCID, etag
+func (_m *OCINetworkService) UpdatePublicIPIfMatch(ctx context.Context, publicIPOCID string, privateIPOCID string, etag string) error {
+	ret := _m.Called(ctx, publicIPOCID, privateIPOCID, etag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePublicIPIfMatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, publicIPOCID, privateIPOCID, etag)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OCINetworkService_UpdatePublicIPIfMatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePublicIPIfMatch'
+type OCINetworkService_UpdatePublicIPIfMatch_Call struct {
+	*mock.Call
+}
+
+// UpdatePublicIPIfMatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publicIPOCID string
+//   - privateIPOCID string
+//   - etag string
+func (_e *OCINetworkService_Expecter) UpdatePublicIPIfMatch(ctx interface{}, publicIPOCID interface{}, privateIPOCID interface{}, etag interface{}) *OCINetworkService_UpdatePublicIPIfMatch_Call {
+	return &OCINetworkService_UpdatePublicIPIfMatch_Call{Call: _e.mock.On("UpdatePublicIPIfMatch", ctx, publicIPOCID, privateIPOCID, etag)}
+}
+
+func (_c *OCINetworkService_UpdatePublicIPIfMatch_Call) Run(run func(ctx context.Context, publicIPOCID string, privateIPOCID string, etag string)) *OCINetworkService_UpdatePublicIPIfMatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *OCINetworkService_UpdatePublicIPIfMatch_Call) Return(_a0 error) *OCINetworkService_UpdatePublicIPIfMatch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *OCINetworkService_UpdatePublicIPIfMatch_Call) RunAndReturn(run func(context.Context, string, string, string) error) *OCINetworkService_UpdatePublicIPIfMatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePublicIPTags provides a mock function with given fields: ctx, publicIPOCID, etag, tags
+func (_m *OCINetworkService) UpdatePublicIPTags(ctx context.Context, publicIPOCID string, etag string, tags map[string]string) (string, error) {
+	ret := _m.Called(ctx, publicIPOCID, etag, tags)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePublicIPTags")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) (string, error)); ok {
+		return rf(ctx, publicIPOCID, etag, tags)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) string); ok {
+		r0 = rf(ctx, publicIPOCID, etag, tags)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, map[string]string) error); ok {
+		r1 = rf(ctx, publicIPOCID, etag, tags)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OCINetworkService_UpdatePublicIPTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePublicIPTags'
+type OCINetworkService_UpdatePublicIPTags_Call struct {
+	*mock.Call
+}
+
+// UpdatePublicIPTags is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publicIPOCID string
+//   - etag string
+//   - tags map[string]string
+func (_e *OCINetworkService_Expecter) UpdatePublicIPTags(ctx interface{}, publicIPOCID interface{}, etag interface{}, tags interface{}) *OCINetworkService_UpdatePublicIPTags_Call {
+	return &OCINetworkService_UpdatePublicIPTags_Call{Call: _e.mock.On("UpdatePublicIPTags", ctx, publicIPOCID, etag, tags)}
+}
+
+func (_c *OCINetworkService_UpdatePublicIPTags_Call) Run(run func(ctx context.Context, publicIPOCID string, etag string, tags map[string]string)) *OCINetworkService_UpdatePublicIPTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *OCINetworkService_UpdatePublicIPTags_Call) Return(_a0 string, _a1 error) *OCINetworkService_UpdatePublicIPTags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OCINetworkService_UpdatePublicIPTags_Call) RunAndReturn(run func(context.Context, string, string, map[string]string) (string, error)) *OCINetworkService_UpdatePublicIPTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewOCINetworkService creates a new instance of OCINetworkService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewOCINetworkService(t interface {
@@ -0,0 +1,89 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/doitintl/kubeip/internal/types"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ConditionReporter is an autogenerated mock type for the ConditionReporter type
+type ConditionReporter struct {
+	mock.Mock
+}
+
+type ConditionReporter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ConditionReporter) EXPECT() *ConditionReporter_Expecter {
+	return &ConditionReporter_Expecter{mock: &_m.Mock}
+}
+
+// SetStaticIPReady provides a mock function with given fields: ctx, _a1, status, reason, message
+func (_m *ConditionReporter) SetStaticIPReady(ctx context.Context, _a1 *types.Node, status v1.ConditionStatus, reason string, message string) error {
+	ret := _m.Called(ctx, _a1, status, reason, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetStaticIPReady")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, v1.ConditionStatus, string, string) error); ok {
+		r0 = rf(ctx, _a1, status, reason, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConditionReporter_SetStaticIPReady_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetStaticIPReady'
+type ConditionReporter_SetStaticIPReady_Call struct {
+	*mock.Call
+}
+
+// SetStaticIPReady is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 *types.Node
+//   - status v1.ConditionStatus
+//   - reason string
+//   - message string
+func (_e *ConditionReporter_Expecter) SetStaticIPReady(ctx interface{}, _a1 interface{}, status interface{}, reason interface{}, message interface{}) *ConditionReporter_SetStaticIPReady_Call {
+	return &ConditionReporter_SetStaticIPReady_Call{Call: _e.mock.On("SetStaticIPReady", ctx, _a1, status, reason, message)}
+}
+
+func (_c *ConditionReporter_SetStaticIPReady_Call) Run(run func(ctx context.Context, _a1 *types.Node, status v1.ConditionStatus, reason string, message string)) *ConditionReporter_SetStaticIPReady_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*types.Node), args[2].(v1.ConditionStatus), args[3].(string), args[4].(string))
+	})
+	return _c
+}
+
+func (_c *ConditionReporter_SetStaticIPReady_Call) Return(_a0 error) *ConditionReporter_SetStaticIPReady_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ConditionReporter_SetStaticIPReady_Call) RunAndReturn(run func(context.Context, *types.Node, v1.ConditionStatus, string, string) error) *ConditionReporter_SetStaticIPReady_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewConditionReporter creates a new instance of ConditionReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewConditionReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ConditionReporter {
+	mock := &ConditionReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,134 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/doitintl/kubeip/internal/types"
+)
+
+// Labeler is an autogenerated mock type for the Labeler type
+type Labeler struct {
+	mock.Mock
+}
+
+type Labeler_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Labeler) EXPECT() *Labeler_Expecter {
+	return &Labeler_Expecter{mock: &_m.Mock}
+}
+
+// ClearAssigned provides a mock function with given fields: ctx, _a1
+func (_m *Labeler) ClearAssigned(ctx context.Context, _a1 *types.Node) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearAssigned")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Labeler_ClearAssigned_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClearAssigned'
+type Labeler_ClearAssigned_Call struct {
+	*mock.Call
+}
+
+// ClearAssigned is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 *types.Node
+func (_e *Labeler_Expecter) ClearAssigned(ctx interface{}, _a1 interface{}) *Labeler_ClearAssigned_Call {
+	return &Labeler_ClearAssigned_Call{Call: _e.mock.On("ClearAssigned", ctx, _a1)}
+}
+
+func (_c *Labeler_ClearAssigned_Call) Run(run func(ctx context.Context, _a1 *types.Node)) *Labeler_ClearAssigned_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*types.Node))
+	})
+	return _c
+}
+
+func (_c *Labeler_ClearAssigned_Call) Return(_a0 error) *Labeler_ClearAssigned_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Labeler_ClearAssigned_Call) RunAndReturn(run func(context.Context, *types.Node) error) *Labeler_ClearAssigned_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetAssigned provides a mock function with given fields: ctx, _a1, address, resourceID
+func (_m *Labeler) SetAssigned(ctx context.Context, _a1 *types.Node, address string, resourceID string) error {
+	ret := _m.Called(ctx, _a1, address, resourceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAssigned")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, string, string) error); ok {
+		r0 = rf(ctx, _a1, address, resourceID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Labeler_SetAssigned_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetAssigned'
+type Labeler_SetAssigned_Call struct {
+	*mock.Call
+}
+
+// SetAssigned is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 *types.Node
+//   - address string
+//   - resourceID string
+func (_e *Labeler_Expecter) SetAssigned(ctx interface{}, _a1 interface{}, address interface{}, resourceID interface{}) *Labeler_SetAssigned_Call {
+	return &Labeler_SetAssigned_Call{Call: _e.mock.On("SetAssigned", ctx, _a1, address, resourceID)}
+}
+
+func (_c *Labeler_SetAssigned_Call) Run(run func(ctx context.Context, _a1 *types.Node, address string, resourceID string)) *Labeler_SetAssigned_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*types.Node), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *Labeler_SetAssigned_Call) Return(_a0 error) *Labeler_SetAssigned_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Labeler_SetAssigned_Call) RunAndReturn(run func(context.Context, *types.Node, string, string) error) *Labeler_SetAssigned_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewLabeler creates a new instance of Labeler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLabeler(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Labeler {
+	mock := &Labeler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
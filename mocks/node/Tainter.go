@@ -0,0 +1,183 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/doitintl/kubeip/internal/types"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Tainter is an autogenerated mock type for the Tainter type
+type Tainter struct {
+	mock.Mock
+}
+
+type Tainter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Tainter) EXPECT() *Tainter_Expecter {
+	return &Tainter_Expecter{mock: &_m.Mock}
+}
+
+// AddTaint provides a mock function with given fields: ctx, _a1, taints
+func (_m *Tainter) AddTaint(ctx context.Context, _a1 *types.Node, taints ...v1.Taint) (bool, error) {
+	_va := make([]interface{}, len(taints))
+	for _i := range taints {
+		_va[_i] = taints[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTaint")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, ...v1.Taint) (bool, error)); ok {
+		return rf(ctx, _a1, taints...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, ...v1.Taint) bool); ok {
+		r0 = rf(ctx, _a1, taints...)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *types.Node, ...v1.Taint) error); ok {
+		r1 = rf(ctx, _a1, taints...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tainter_AddTaint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddTaint'
+type Tainter_AddTaint_Call struct {
+	*mock.Call
+}
+
+// AddTaint is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 *types.Node
+//   - taints ...v1.Taint
+func (_e *Tainter_Expecter) AddTaint(ctx interface{}, _a1 interface{}, taints ...interface{}) *Tainter_AddTaint_Call {
+	return &Tainter_AddTaint_Call{Call: _e.mock.On("AddTaint",
+		append([]interface{}{ctx, _a1}, taints...)...)}
+}
+
+func (_c *Tainter_AddTaint_Call) Run(run func(ctx context.Context, _a1 *types.Node, taints ...v1.Taint)) *Tainter_AddTaint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]v1.Taint, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(v1.Taint)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*types.Node), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Tainter_AddTaint_Call) Return(_a0 bool, _a1 error) *Tainter_AddTaint_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Tainter_AddTaint_Call) RunAndReturn(run func(context.Context, *types.Node, ...v1.Taint) (bool, error)) *Tainter_AddTaint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveTaintKey provides a mock function with given fields: ctx, _a1, taintKeys
+func (_m *Tainter) RemoveTaintKey(ctx context.Context, _a1 *types.Node, taintKeys ...string) (bool, error) {
+	_va := make([]interface{}, len(taintKeys))
+	for _i := range taintKeys {
+		_va[_i] = taintKeys[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveTaintKey")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, ...string) (bool, error)); ok {
+		return rf(ctx, _a1, taintKeys...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, ...string) bool); ok {
+		r0 = rf(ctx, _a1, taintKeys...)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *types.Node, ...string) error); ok {
+		r1 = rf(ctx, _a1, taintKeys...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tainter_RemoveTaintKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveTaintKey'
+type Tainter_RemoveTaintKey_Call struct {
+	*mock.Call
+}
+
+// RemoveTaintKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - _a1 *types.Node
+//   - taintKeys ...string
+func (_e *Tainter_Expecter) RemoveTaintKey(ctx interface{}, _a1 interface{}, taintKeys ...interface{}) *Tainter_RemoveTaintKey_Call {
+	return &Tainter_RemoveTaintKey_Call{Call: _e.mock.On("RemoveTaintKey",
+		append([]interface{}{ctx, _a1}, taintKeys...)...)}
+}
+
+func (_c *Tainter_RemoveTaintKey_Call) Run(run func(ctx context.Context, _a1 *types.Node, taintKeys ...string)) *Tainter_RemoveTaintKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*types.Node), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Tainter_RemoveTaintKey_Call) Return(_a0 bool, _a1 error) *Tainter_RemoveTaintKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Tainter_RemoveTaintKey_Call) RunAndReturn(run func(context.Context, *types.Node, ...string) (bool, error)) *Tainter_RemoveTaintKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewTainter creates a new instance of Tainter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTainter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Tainter {
+	mock := &Tainter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,97 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	pool "github.com/doitintl/kubeip/internal/pool"
+	types "github.com/doitintl/kubeip/internal/types"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Resolver is an autogenerated mock type for the Resolver type
+type Resolver struct {
+	mock.Mock
+}
+
+type Resolver_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Resolver) EXPECT() *Resolver_Expecter {
+	return &Resolver_Expecter{mock: &_m.Mock}
+}
+
+// Resolve provides a mock function with given fields: ctx, node
+func (_m *Resolver) Resolve(ctx context.Context, node *types.Node) (*pool.Selection, error) {
+	ret := _m.Called(ctx, node)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resolve")
+	}
+
+	var r0 *pool.Selection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node) (*pool.Selection, error)); ok {
+		return rf(ctx, node)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node) *pool.Selection); ok {
+		r0 = rf(ctx, node)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pool.Selection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *types.Node) error); ok {
+		r1 = rf(ctx, node)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Resolver_Resolve_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resolve'
+type Resolver_Resolve_Call struct {
+	*mock.Call
+}
+
+// Resolve is a helper method to define mock.On call
+//   - ctx context.Context
+//   - node *types.Node
+func (_e *Resolver_Expecter) Resolve(ctx interface{}, node interface{}) *Resolver_Resolve_Call {
+	return &Resolver_Resolve_Call{Call: _e.mock.On("Resolve", ctx, node)}
+}
+
+func (_c *Resolver_Resolve_Call) Run(run func(ctx context.Context, node *types.Node)) *Resolver_Resolve_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*types.Node))
+	})
+	return _c
+}
+
+func (_c *Resolver_Resolve_Call) Return(_a0 *pool.Selection, _a1 error) *Resolver_Resolve_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Resolver_Resolve_Call) RunAndReturn(run func(context.Context, *types.Node) (*pool.Selection, error)) *Resolver_Resolve_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewResolver creates a new instance of Resolver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewResolver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Resolver {
+	mock := &Resolver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}